@@ -0,0 +1,106 @@
+// Package certificate renders printable immunization certificates.
+package certificate
+
+import (
+	"bytes"
+	"fmt"
+	"school_vaccination_portal/models"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// Data is everything printed on one certificate, QRCode is the content of the verification QR code
+type Data struct {
+	SchoolName    string
+	SchoolAddress string
+	Number        string
+	IssuedAt      time.Time
+	Student       models.StudentManagement
+	Doses         []models.CertificateDose
+	QRCode        string
+}
+
+// Render lays the certificate out on a single A4 page under the school header
+func Render(data Data) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, tr(data.SchoolName), "", 1, "C", false, 0, "")
+	if data.SchoolAddress != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, tr(data.SchoolAddress), "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, "Immunization Certificate", "B", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(90, 6, tr("Certificate No: "+data.Number), "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Issued On: "+data.IssuedAt.Format("02 Jan 2006"), "", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	details := [][]string{
+		{"Name", data.Student.Name},
+		{"Class", data.Student.Class},
+		{"Gender", data.Student.Gender},
+		{"Roll Number", data.Student.RollNumber},
+	}
+	for _, row := range details {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(40, 7, row[0], "1", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 7, tr(row[1]), "1", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	widths := []float64{75, 20, 40, 45}
+	headers := []string{"Vaccine", "Dose", "Date", "Lot Number"}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 10)
+	if len(data.Doses) == 0 {
+		pdf.CellFormat(0, 7, "No vaccinations recorded", "1", 1, "C", false, 0, "")
+	}
+	for _, dose := range data.Doses {
+		date := ""
+		if dose.AdministeredAt != nil {
+			date = dose.AdministeredAt.Format("02 Jan 2006")
+		}
+		values := []string{tr(dose.VaccineName), fmt.Sprintf("%d", dose.DoseNumber), date, tr(dose.LotNumber)}
+		for i, value := range values {
+			pdf.CellFormat(widths[i], 7, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(12)
+	if data.QRCode != "" {
+		png, err := qrcode.Encode(data.QRCode, qrcode.Medium, 512)
+		if err != nil {
+			return nil, err
+		}
+		pdf.RegisterImageOptionsReader("qr", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+		pdf.ImageOptions("qr", pdf.GetX(), pdf.GetY(), 45, 45, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		pdf.SetLeftMargin(65)
+		pdf.SetX(65)
+	}
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.MultiCell(0, 5, "This certificate lists every vaccination recorded for the student by the school as of the issue date. Scan the QR code to verify it, or quote the certificate number when contacting the school about this record.", "", "L", false)
+	pdf.SetLeftMargin(15)
+
+	buf := new(bytes.Buffer)
+	if err := pdf.Output(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package certificate
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/models"
+	"strings"
+)
+
+var ErrInvalidSignature = errors.New("certificate signature is not valid")
+
+// SigningKey decodes the base64 Ed25519 seed (or full private key) configured as CERTIFICATE_SIGNING_KEY
+func SigningKey(cfg config.CertificateConfig) (ed25519.PrivateKey, error) {
+	if err := cfg.CheckKeys(); err != nil {
+		return nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(cfg.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CERTIFICATE_SIGNING_KEY %s", err.Error())
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	case 0:
+		return nil, errors.New("CERTIFICATE_SIGNING_KEY is not configured")
+	}
+	return nil, fmt.Errorf("CERTIFICATE_SIGNING_KEY must be %d or %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize)
+}
+
+// VerifyKey decodes the base64 Ed25519 public key configured as CERTIFICATE_VERIFY_KEY, falling back to the
+// public half of the signing key so the issuing server does not need both configured
+func VerifyKey(cfg config.CertificateConfig) (ed25519.PublicKey, error) {
+	if err := cfg.CheckKeys(); err != nil {
+		return nil, err
+	}
+	if cfg.VerifyKey == "" {
+		private, err := SigningKey(cfg)
+		if err != nil {
+			return nil, errors.New("CERTIFICATE_VERIFY_KEY is not configured")
+		}
+		return private.Public().(ed25519.PublicKey), nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(cfg.VerifyKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CERTIFICATE_VERIFY_KEY %s", err.Error())
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("CERTIFICATE_VERIFY_KEY must be %d bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Sign returns the token <payload>.<signature>, both parts base64url without padding
+func Sign(payload models.CertificatePayload, key ed25519.PrivateKey) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	signature := ed25519.Sign(key, body)
+	return base64.RawURLEncoding.EncodeToString(body) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks the token against the public key and returns the payload it carries
+func Verify(token string, key ed25519.PublicKey) (models.CertificatePayload, error) {
+	payload := models.CertificatePayload{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return payload, errors.New("malformed certificate token")
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return payload, errors.New("malformed certificate token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return payload, errors.New("malformed certificate token")
+	}
+	if !ed25519.Verify(key, body, signature) {
+		return payload, ErrInvalidSignature
+	}
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return payload, errors.New("malformed certificate payload")
+	}
+	return payload, nil
+}
+
+// VerificationLink is the QR content, a link to the public verify endpoint carrying the token
+func VerificationLink(verifyUrl, token string) string {
+	return verifyUrl + "?token=" + url.QueryEscape(token)
+}
+
+// TokenFromQR accepts either the scanned verification link or a bare token
+func TokenFromQR(content string) string {
+	content = strings.TrimSpace(content)
+	if parsed, err := url.Parse(content); err == nil && parsed.Query().Get("token") != "" {
+		return parsed.Query().Get("token")
+	}
+	return content
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/controller"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/server"
+	"school_vaccination_portal/tracing"
+	"school_vaccination_portal/usecase"
+	"school_vaccination_portal/utils/validator"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// command is one subcommand of the binary, a name like "user create" is a group followed by an action
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "Run the HTTP API", runServe},
+	{"worker", "Consume the bulk job or notification queue", runWorker},
+	{"migrate", "Apply pending database migrations", runMigrate},
+	{"seed", "Add the standard school vaccines to the catalog", runSeed},
+	{"user create", "Create a portal user account", runUserCreate},
+	{"report generate", "Generate a vaccination report and print where to download it", runReportGenerate},
+	{"bulk import", "Import a bulk upload file right away instead of queueing it", runBulkImport},
+	{"verify-certificate", "Check a certificate QR code offline", runVerifyCertificate},
+	{"generate-certificate-keys", "Print a new certificate signing key pair", runGenerateCertificateKeys},
+}
+
+// errUsage means the arguments were wrong and the command's help was already printed
+var errUsage = errors.New("invalid usage")
+
+// bulkImportTypes maps bulk import -type values to the bulk job request types
+var bulkImportTypes = map[string]string{
+	"students":        controller.BULK_STUDENT_RECORD,
+	"vaccine-records": controller.BULK_VACCINE_RECORD,
+	"consents":        controller.BULK_CONSENT_RECORD,
+	"guardians":       controller.BULK_GUARDIAN_RECORD,
+}
+
+// run dispatches to the subcommand named by args and returns the process exit code,
+// 2 for unknown commands and bad arguments, 1 when the command itself fails
+func run(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return 2
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) || strings.Join(args[:len(words)], " ") != cmd.name {
+			continue
+		}
+		err := cmd.run(args[len(words):])
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		default:
+			slog.Error("command failed", "command", cmd.name, logging.Err(err))
+			return 1
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", strings.Join(args, " "))
+	printUsage(os.Stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", binaryName())
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-27s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", binaryName())
+}
+
+func binaryName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// newFlagSet gives every command the -config flag and a help text naming its arguments
+func newFlagSet(name, arguments, summary string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML config file, overrides CONFIG_FILE, environment variables win over it")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s\n\n%s\n\nFlags:\n", strings.TrimSpace(fmt.Sprintf("%s %s [flags] %s", binaryName(), name, arguments)), summary)
+		fs.PrintDefaults()
+	}
+	return fs, configFile
+}
+
+// parseFlags parses args and checks the number of positional arguments left over
+func parseFlags(fs *flag.FlagSet, args []string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != positional {
+		fmt.Fprintf(fs.Output(), "expected %d argument(s), got %d\n", positional, fs.NArg())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// loadConfig loads the configuration and builds the logger from it, which also becomes the default logger,
+// commands that reach the portal's services also validate it
+func loadConfig(path string, validate bool) (*config.Config, *slog.Logger, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger, err := logging.New(cfg.Log)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.SetDefault(logger)
+	if validate {
+		if err = cfg.Validate(); err != nil {
+			return nil, nil, err
+		}
+		logger.Info("starting with config", "config", cfg.String())
+	}
+	return cfg, logger, nil
+}
+
+// startTracing sets up tracing for a command, the returned function flushes the spans it recorded
+func startTracing(cfg *config.Config, logger *slog.Logger, component string) (func(), error) {
+	shutdown, err := tracing.Init(cfg.Tracing, component, logger)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			logger.Warn("unable to flush spans", logging.Err(err))
+		}
+	}, nil
+}
+
+func runServe(args []string) error {
+	fs, configFile := newFlagSet("serve", "", "Runs the HTTP API on the configured server address.")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, logger, err := loadConfig(*configFile, true)
+	if err != nil {
+		return err
+	}
+	stopTracing, err := startTracing(cfg, logger, "api")
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	server.Start(cfg, logger)
+	return nil
+}
+
+func runWorker(args []string) error {
+	fs, configFile := newFlagSet("worker", "", "Consumes a queue until stopped: bulk runs uploads and exports, notifications delivers guardian messages and queues the daily reminders. /healthz and /readyz are served on WORKER_HEALTH_PORT.")
+	queue := fs.String("queue", "bulk", "queue to consume: bulk or notifications")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *queue != "bulk" && *queue != "notifications" {
+		fmt.Fprintf(fs.Output(), "unknown queue %q\n", *queue)
+		fs.Usage()
+		return errUsage
+	}
+	cfg, logger, err := loadConfig(*configFile, true)
+	if err != nil {
+		return err
+	}
+	stopTracing, err := startTracing(cfg, logger, *queue+"-worker")
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	if *queue == "notifications" {
+		StartNotificationProcessing(cfg, logger)
+		return nil
+	}
+	StartAsyncFileProcessing(cfg, logger)
+	return nil
+}
+
+func runMigrate(args []string) error {
+	fs, configFile := newFlagSet("migrate", "", "Applies the database migrations not yet recorded in schema_migrations.")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, logger, err := loadConfig(*configFile, true)
+	if err != nil {
+		return err
+	}
+	dbConnection, err := mysql.GetMySQLConnect(cfg.Database, logger)
+	if err != nil {
+		return fmt.Errorf("error in connecting to db %s", err.Error())
+	}
+	return mysql.Migrate(dbConnection, logger)
+}
+
+// seedVaccines is the catalog a new school starts from, edit entries through the vaccines API afterwards
+var seedVaccines = []models.Vaccine{
+	{Name: "MMR", CvxCode: "03", Manufacturer: "Merck", DosesInSeries: 2, MinIntervalDays: 28, EligibleGrades: models.Grades{"Grade 1", "Grade 2", "Grade 3", "Grade 4", "Grade 5", "Grade 6"}},
+	{Name: "Varicella", CvxCode: "21", Manufacturer: "Merck", DosesInSeries: 2, MinIntervalDays: 90, EligibleGrades: models.Grades{"Grade 1", "Grade 2", "Grade 3", "Grade 4", "Grade 5", "Grade 6"}},
+	{Name: "Polio (IPV)", CvxCode: "10", Manufacturer: "Sanofi Pasteur", DosesInSeries: 4, MinIntervalDays: 28, EligibleGrades: models.Grades{"Grade 1", "Grade 2", "Grade 3"}},
+	{Name: "Hepatitis B", CvxCode: "08", Manufacturer: "GSK", DosesInSeries: 3, MinIntervalDays: 28, EligibleGrades: models.Grades{"Grade 1", "Grade 2", "Grade 3", "Grade 4", "Grade 5", "Grade 6", "Grade 7", "Grade 8"}},
+	{Name: "Tdap", CvxCode: "115", Manufacturer: "GSK", DosesInSeries: 1, EligibleGrades: models.Grades{"Grade 6", "Grade 7", "Grade 8"}},
+	{Name: "HPV", CvxCode: "165", Manufacturer: "Merck", DosesInSeries: 2, MinIntervalDays: 180, EligibleGrades: models.Grades{"Grade 5", "Grade 6", "Grade 7", "Grade 8", "Grade 9", "Grade 10", "Grade 11", "Grade 12"}},
+	{Name: "MenACWY", CvxCode: "114", Manufacturer: "Sanofi Pasteur", DosesInSeries: 2, MinIntervalDays: 56, EligibleGrades: models.Grades{"Grade 7", "Grade 8", "Grade 9", "Grade 10", "Grade 11", "Grade 12"}},
+}
+
+func runSeed(args []string) error {
+	fs, configFile := newFlagSet("seed", "", "Adds the standard school vaccines to the catalog, vaccines already there by name are left as they are.")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, logger, err := loadConfig(*configFile, true)
+	if err != nil {
+		return err
+	}
+	dbConnection, err := mysql.GetMySQLConnect(cfg.Database, logger)
+	if err != nil {
+		return fmt.Errorf("error in connecting to db %s", err.Error())
+	}
+	catalogRepo := repository.NewVaccineCatalogRepositoryHandler(dbConnection, logger)
+	catalogUsecase := usecase.NewVaccineCatalogUsecaseHandler(catalogRepo, repository.NewVaccineInventoryHandler(dbConnection, logger), repository.NewConsentRepositoryHandler(dbConnection), repository.NewExemptionRepositoryHandler(dbConnection), logger)
+	ctx := context.Background()
+	for _, vaccine := range seedVaccines {
+		_, existing, err := catalogUsecase.GetVaccines(ctx, &models.Vaccine{Name: vaccine.Name}, requests.Pagination{})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			fmt.Println("exists ", vaccine.Name)
+			continue
+		}
+		if err = catalogUsecase.CreateVaccine(ctx, &vaccine); err != nil {
+			return err
+		}
+		fmt.Println("created", vaccine.Name)
+	}
+	return nil
+}
+
+func runUserCreate(args []string) error {
+	fs, configFile := newFlagSet("user create", "", "Creates a portal user account. Without -password-stdin a random password is generated and printed once.")
+	username := fs.String("username", "", "login name, required")
+	name := fs.String("name", "", "display name, defaults to the username")
+	role := fs.String("role", models.ROLE_STAFF, "ADMIN or STAFF")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from the first line of standard input")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *username == "" {
+		fmt.Fprintln(fs.Output(), "-username is required")
+		fs.Usage()
+		return errUsage
+	}
+	password, generated := "", false
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("unable to read password %s", err.Error())
+		}
+		password = strings.TrimRight(line, "\r\n")
+	} else {
+		raw := make([]byte, 18)
+		if _, err := rand.Read(raw); err != nil {
+			return fmt.Errorf("unable to generate password %s", err.Error())
+		}
+		password, generated = base64.RawURLEncoding.EncodeToString(raw), true
+	}
+	cfg, logger, err := loadConfig(*configFile, true)
+	if err != nil {
+		return err
+	}
+	dbConnection, err := mysql.GetMySQLConnect(cfg.Database, logger)
+	if err != nil {
+		return fmt.Errorf("error in connecting to db %s", err.Error())
+	}
+	user := &models.User{Username: *username, Name: *name, Role: *role}
+	if user.Name == "" {
+		user.Name = *username
+	}
+	if err = usecase.NewUserUsecaseHandler(repository.NewUserRepositoryHandler(dbConnection), logger).CreateUser(context.Background(), user, password); err != nil {
+		return err
+	}
+	fmt.Printf("created user %s with id %d and role %s\n", user.Username, user.Id, user.Role)
+	if generated {
+		fmt.Println("password:", password)
+	}
+	return nil
+}
+
+func runReportGenerate(args []string) error {
+	fs, configFile := newFlagSet("report generate", "", "Generates the vaccination report the genrate-report endpoint returns and prints its download URL.")
+	req := &requests.GenerateReportRequest{RequestId: uuid.NewString()}
+	fs.StringVar(&req.Class, "class", "", "only students of this class, e.g. \"Grade 5\"")
+	fs.StringVar(&req.VaccineName, "vaccine", "", "only records of this vaccine")
+	fs.StringVar(&req.Format, "format", "xlsx", "xlsx, fhir or hl7")
+	fs.BoolVar(&req.ExcludeExempt, "exclude-exempt", false, "leave out exempt unvaccinated students, xlsx only")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if err := validator.NewValidator().Validate(req); err != nil {
+		fmt.Fprintln(fs.Output(), err.Error())
+		fs.Usage()
+		return errUsage
+	}
+	cfg, logger, err := loadConfig(*configFile, true)
+	if err != nil {
+		return err
+	}
+	stopTracing, err := startTracing(cfg, logger, "cli")
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	svc := newServices(cfg, logger)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Report())
+	defer cancel()
+	var fileLoc string
+	switch req.Format {
+	case "fhir":
+		fileLoc, err = svc.fhirExport.ExportBundleFile(ctx, &requests.FHIRExportRequest{Class: req.Class, VaccineName: req.VaccineName, RequestId: req.RequestId})
+	case "hl7":
+		fileLoc, err = svc.hl7Export.ExportBatchFile(ctx, &requests.HL7ExportRequest{Class: req.Class, VaccineName: req.VaccineName, RequestId: req.RequestId})
+	default:
+		fileLoc, err = svc.students.GenerateVaccinationReport(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(fileLoc)
+	return nil
+}
+
+func runBulkImport(args []string) error {
+	types := []string{}
+	for t := range bulkImportTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	fs, configFile := newFlagSet("bulk import", "<file>", "Imports an .xlsx bulk upload file in this process, the same way the bulk worker does, and waits for it to finish.")
+	importType := fs.String("type", "", "what the file holds, required: "+strings.Join(types, ", "))
+	allOrNothing := fs.Bool("all-or-nothing", false, "import nothing when any row is rejected")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	requestType, ok := bulkImportTypes[*importType]
+	if !ok {
+		fmt.Fprintf(fs.Output(), "unknown -type %q\n", *importType)
+		fs.Usage()
+		return errUsage
+	}
+	cfg, logger, err := loadConfig(*configFile, true)
+	if err != nil {
+		return err
+	}
+	//staging removes the local file once uploaded, so upload a copy and leave the operator's file alone
+	file := fs.Arg(0)
+	stagingDir, err := os.MkdirTemp("", "bulk-import-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+	staged := filepath.Join(stagingDir, filepath.Base(file))
+	if err = copyFile(file, staged); err != nil {
+		return err
+	}
+	stopTracing, err := startTracing(cfg, logger, "cli")
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	svc := newServices(cfg, logger)
+	job := &models.BulkFileJobsModel{
+		RequestId:    uuid.NewString(),
+		RequestType:  requestType,
+		FileName:     filepath.Base(file),
+		FilePath:     staged,
+		Status:       "PENDING",
+		AllOrNothing: *allOrNothing,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.BulkJob())
+	defer cancel()
+	if err = svc.bulkJobs.StageBulkFile(ctx, job); err != nil {
+		return err
+	}
+	if err = processBulkJob(ctx, svc, job); err != nil {
+		return err
+	}
+	_, jobs, err := svc.bulkJobs.GetBulkFileJobDetails(ctx, job.RequestId, requests.Pagination{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("bulk job %s not found after processing", job.RequestId)
+	}
+	result := jobs[0]
+	fmt.Printf("request %s %s, %d of %d records processed\n", result.RequestId, result.Status, result.ProcessedRecords, result.TotalRecords)
+	if result.FilePath != "" && result.Status == "PROCESSED" {
+		fmt.Println("report:", result.FilePath)
+	}
+	if result.Status == "FAILED" {
+		return errors.New(result.ErrorMessage)
+	}
+	return nil
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func runVerifyCertificate(args []string) error {
+	fs, configFile := newFlagSet("verify-certificate", "", "Checks a certificate's signature with only the verify key and, when given, a saved copy of the revocation list. It never calls the portal.")
+	token := fs.String("token", "", "token or link scanned from the certificate QR code, required")
+	revocationList := fs.String("revocation-list", "", "saved response of the revoked certificates endpoint")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *token == "" {
+		fmt.Fprintln(fs.Output(), "-token is required")
+		fs.Usage()
+		return errUsage
+	}
+	//offline tool, it must work without the portal's database or storage settings
+	cfg, _, err := loadConfig(*configFile, false)
+	if err != nil {
+		return err
+	}
+	if !VerifyCertificateOffline(cfg.Certificate, *token, *revocationList) {
+		return errors.New("certificate is not valid")
+	}
+	return nil
+}
+
+func runGenerateCertificateKeys(args []string) error {
+	fs, _ := newFlagSet("generate-certificate-keys", "", "Prints a new Ed25519 key pair as CERTIFICATE_SIGNING_KEY and CERTIFICATE_VERIFY_KEY lines.")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	GenerateCertificateKeys()
+	return nil
+}
@@ -0,0 +1,234 @@
+// Package config holds the typed configuration of every service, loaded once at startup and
+// passed to the components that need it through their constructors.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Config is built from defaults, then an optional YAML file, then .env and the process environment.
+// Each field names its environment variable in the env tag and its YAML key in the yaml tag.
+type Config struct {
+	Server       ServerConfig       `yaml:"server"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Rabbit       RabbitConfig       `yaml:"rabbit"`
+	Minio        MinioConfig        `yaml:"minio"`
+	School       SchoolConfig       `yaml:"school"`
+	Vaccination  VaccinationConfig  `yaml:"vaccination"`
+	Notification NotificationConfig `yaml:"notification"`
+	Certificate  CertificateConfig  `yaml:"certificate"`
+	FHIR         FHIRConfig         `yaml:"fhir"`
+	HL7          HL7Config          `yaml:"hl7"`
+	Health       HealthConfig       `yaml:"health"`
+	Log          LogConfig          `yaml:"log"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	Timeouts     TimeoutConfig      `yaml:"timeouts"`
+}
+
+type ServerConfig struct {
+	Host string `yaml:"host" env:"SERVER_HOST"`
+	Port int    `yaml:"port" env:"SERVER_PORT" default:"8080" required:"true"`
+	// PublicURL is the external base URL clients reach the portal on, e.g. https://portal.example.org
+	PublicURL string `yaml:"public_url" env:"PUBLIC_BASE_URL"`
+	// TrustForwardedHeaders lets X-Forwarded-Proto/Host pick the link base when PublicURL is not set,
+	// only enable it behind a proxy that overwrites those headers
+	TrustForwardedHeaders bool `yaml:"trust_forwarded_headers" env:"TRUST_FORWARDED_HEADERS"`
+}
+
+// Address is what the HTTP server listens on
+func (s ServerConfig) Address() string {
+	return net.JoinHostPort(s.Host, fmt.Sprint(s.Port))
+}
+
+// BaseURL is the public URL when configured, else the local listener
+func (s ServerConfig) BaseURL() string {
+	if s.PublicURL != "" {
+		return strings.TrimRight(s.PublicURL, "/")
+	}
+	host := s.Host
+	if host == "" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, fmt.Sprint(s.Port))
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST" default:"localhost" required:"true"`
+	Port     int    `yaml:"port" env:"DB_PORT" default:"3306" required:"true"`
+	User     string `yaml:"user" env:"DB_USER" required:"true"`
+	Password string `yaml:"password" env:"DB_PASS" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME" default:"school_vaccination_portal" required:"true"`
+}
+
+// DSN is the go-sql-driver connection string
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True", d.User, d.Password, net.JoinHostPort(d.Host, fmt.Sprint(d.Port)), d.Name)
+}
+
+type RabbitConfig struct {
+	Host              string `yaml:"host" env:"RABBIT_HOST" default:"localhost" required:"true"`
+	Port              int    `yaml:"port" env:"RABBIT_PORT" default:"5672" required:"true"`
+	User              string `yaml:"user" env:"RABBIT_USER" required:"true"`
+	Password          string `yaml:"password" env:"RABBIT_PASS" secret:"true"`
+	BulkQueue         string `yaml:"bulk_queue" env:"RABBIT_BULK_QUEUE" default:"async-file-processing-queue" required:"true"`
+	NotificationQueue string `yaml:"notification_queue" env:"RABBIT_NOTIFICATION_QUEUE" default:"notification-delivery-queue" required:"true"`
+}
+
+func (r RabbitConfig) URL() string {
+	return fmt.Sprintf("amqp://%s:%s@%s", r.User, r.Password, net.JoinHostPort(r.Host, fmt.Sprint(r.Port)))
+}
+
+type MinioConfig struct {
+	Server   string `yaml:"server" env:"MINIO_SERVER" default:"localhost" required:"true"`
+	Port     int    `yaml:"port" env:"MINIO_PORT" default:"9000" required:"true"`
+	Username string `yaml:"username" env:"MINIO_USERNAME" required:"true"`
+	Password string `yaml:"password" env:"MINIO_PASSWORD" required:"true" secret:"true"`
+	Bucket   string `yaml:"bucket" env:"MINIO_BULK_UPLOAD_BUCKET" required:"true"`
+	Region   string `yaml:"region" env:"MINIO_REGION" default:"us-east-1"`
+	Secure   bool   `yaml:"secure" env:"MINIO_SECURE"`
+	// PublicURL is where clients download objects from when MinIO sits behind a proxy or CDN
+	PublicURL string `yaml:"public_url" env:"MINIO_PUBLIC_URL"`
+}
+
+func (m MinioConfig) Endpoint() string {
+	return net.JoinHostPort(m.Server, fmt.Sprint(m.Port))
+}
+
+// ObjectURL is where a stored object can be downloaded from
+func (m MinioConfig) ObjectURL(key string) string {
+	if m.PublicURL != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimRight(m.PublicURL, "/"), m.Bucket, key)
+	}
+	scheme := "http"
+	if m.Secure {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", scheme, m.Endpoint(), m.Bucket, key)
+}
+
+type SchoolConfig struct {
+	Name    string `yaml:"name" env:"SCHOOL_NAME" default:"School Vaccination Portal"`
+	Address string `yaml:"address" env:"SCHOOL_ADDRESS"`
+}
+
+type VaccinationConfig struct {
+	DuplicatePolicy string `yaml:"duplicate_policy" env:"DUPLICATE_VACCINATION_POLICY" default:"REJECT"`
+}
+
+type NotificationConfig struct {
+	SMSProvider        string     `yaml:"sms_provider" env:"NOTIFY_SMS_PROVIDER" default:"log"`
+	EmailProvider      string     `yaml:"email_provider" env:"NOTIFY_EMAIL_PROVIDER" default:"log"`
+	LogFile            string     `yaml:"log_file" env:"NOTIFY_LOG_FILE" default:"notifications.log"`
+	ReminderDaysBefore int        `yaml:"reminder_days_before" env:"NOTIFY_REMINDER_DAYS_BEFORE" default:"2"`
+	MissedFollowUpDays int        `yaml:"missed_followup_days" env:"NOTIFY_MISSED_FOLLOWUP_DAYS" default:"1"`
+	SMSGateway         SMSGateway `yaml:"sms_gateway"`
+	SMTP               SMTPConfig `yaml:"smtp"`
+}
+
+type SMSGateway struct {
+	URL      string `yaml:"url" env:"SMS_GATEWAY_URL"`
+	APIKey   string `yaml:"api_key" env:"SMS_GATEWAY_API_KEY" secret:"true"`
+	SenderId string `yaml:"sender_id" env:"SMS_SENDER_ID"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT" default:"587"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+	User     string `yaml:"user" env:"SMTP_USER"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// CertificateConfig holds the base64 Ed25519 keys, the verify key falls back to the public half of the signing key
+type CertificateConfig struct {
+	SigningKey string `yaml:"signing_key" env:"CERTIFICATE_SIGNING_KEY" secret:"true"`
+	VerifyKey  string `yaml:"verify_key" env:"CERTIFICATE_VERIFY_KEY"`
+	VerifyURL  string `yaml:"verify_url" env:"CERTIFICATE_VERIFY_URL"`
+}
+
+// publishedCertificateKeys were once committed with the sample env file, anyone can sign with them
+var publishedCertificateKeys = map[string]bool{
+	"XH1tZI9xTTDCy1ptYvInx46ZSrwz+jYx7KdguLEjyog=": true,
+	"h7F52JcRxVSaj2UZMPiWsPOWLd90RT2kjsMW7qxM/ew=": true,
+}
+
+// CheckKeys rejects the published sample keys, certificates signed with them can be forged
+func (c CertificateConfig) CheckKeys() error {
+	if publishedCertificateKeys[c.SigningKey] || publishedCertificateKeys[c.VerifyKey] {
+		return errors.New("the configured certificate keys were published, generate a new pair with generate-certificate-keys")
+	}
+	return nil
+}
+
+type FHIRConfig struct {
+	BaseURL string `yaml:"base_url" env:"FHIR_BASE_URL"`
+}
+
+// HL7Config fills the sender and receiver of MSH, BHS and FHS, the sending facility defaults to the school name
+type HL7Config struct {
+	SendingApplication   string `yaml:"sending_application" env:"HL7_SENDING_APPLICATION" default:"SVP"`
+	SendingFacility      string `yaml:"sending_facility" env:"HL7_SENDING_FACILITY"`
+	ReceivingApplication string `yaml:"receiving_application" env:"HL7_RECEIVING_APPLICATION"`
+	ReceivingFacility    string `yaml:"receiving_facility" env:"HL7_RECEIVING_FACILITY"`
+	ProcessingId         string `yaml:"processing_id" env:"HL7_PROCESSING_ID" default:"P"`
+}
+
+// HealthConfig bounds each readiness check, workers serve /healthz and /readyz on WorkerPort
+type HealthConfig struct {
+	TimeoutMs  int    `yaml:"timeout_ms" env:"HEALTH_CHECK_TIMEOUT_MS" default:"2000"`
+	WorkerHost string `yaml:"worker_host" env:"WORKER_HEALTH_HOST"`
+	WorkerPort int    `yaml:"worker_port" env:"WORKER_HEALTH_PORT" default:"8081"`
+}
+
+func (h HealthConfig) Timeout() time.Duration {
+	return time.Duration(h.TimeoutMs) * time.Millisecond
+}
+
+// WorkerAddress is what a worker's health server listens on
+func (h HealthConfig) WorkerAddress() string {
+	return net.JoinHostPort(h.WorkerHost, fmt.Sprint(h.WorkerPort))
+}
+
+// LogConfig sets the lowest level written and whether lines are JSON or text
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json"`
+}
+
+// TracingConfig picks where spans go: otlp sends them over HTTP to Endpoint, stdout prints them for local runs
+// and none records nothing. SampleRatio is the share of new traces kept, traces started by a caller follow its choice.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"school-vaccination-portal"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+}
+
+// TimeoutConfig is how long each kind of operation may run before its context is cancelled. Requests get RequestMs,
+// the report and export endpoints and the report command get ReportMs.
+type TimeoutConfig struct {
+	RequestMs      int `yaml:"request_ms" env:"REQUEST_TIMEOUT_MS" default:"30000"`
+	ReportMs       int `yaml:"report_ms" env:"REPORT_TIMEOUT_MS" default:"300000"`
+	BulkJobMs      int `yaml:"bulk_job_ms" env:"BULK_JOB_TIMEOUT_MS" default:"3600000"`
+	NotificationMs int `yaml:"notification_ms" env:"NOTIFICATION_TIMEOUT_MS" default:"30000"`
+}
+
+func (t TimeoutConfig) Request() time.Duration {
+	return time.Duration(t.RequestMs) * time.Millisecond
+}
+
+func (t TimeoutConfig) Report() time.Duration {
+	return time.Duration(t.ReportMs) * time.Millisecond
+}
+
+func (t TimeoutConfig) BulkJob() time.Duration {
+	return time.Duration(t.BulkJobMs) * time.Millisecond
+}
+
+func (t TimeoutConfig) Notification() time.Duration {
+	return time.Duration(t.NotificationMs) * time.Millisecond
+}
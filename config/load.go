@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DEFAULT_ENV_FILE = ".env"
+	CONFIG_FILE_ENV  = "CONFIG_FILE"
+	REDACTED         = "******"
+)
+
+// Load builds the configuration: defaults, then the YAML file at path (or CONFIG_FILE) when one is given,
+// then .env, then the process environment. Values already in the environment win over .env, and an empty
+// variable counts as unset. Required fields are not checked here, services call Validate before starting.
+func Load(path string) (*Config, error) {
+	if err := godotenv.Load(DEFAULT_ENV_FILE); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("unable to load env file", "file", DEFAULT_ENV_FILE, "error", err.Error())
+	}
+	cfg := &Config{}
+	if err := walk(reflect.ValueOf(cfg).Elem(), "", applyDefault); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = os.Getenv(CONFIG_FILE_ENV)
+	}
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config file %s", err.Error())
+		}
+		if err = yaml.Unmarshal(content, cfg); err != nil {
+			return nil, fmt.Errorf("invalid config file %s %s", path, err.Error())
+		}
+	}
+	if err := walk(reflect.ValueOf(cfg).Elem(), "", applyEnv); err != nil {
+		return nil, err
+	}
+	if cfg.HL7.SendingFacility == "" {
+		cfg.HL7.SendingFacility = cfg.School.Name
+	}
+	//links that outlive a request, the QR code and FHIR ids, default to the public base
+	if cfg.Certificate.VerifyURL == "" {
+		cfg.Certificate.VerifyURL = cfg.Server.BaseURL() + "/school-vaccine-portal/verify"
+	}
+	if cfg.FHIR.BaseURL == "" {
+		cfg.FHIR.BaseURL = cfg.Server.BaseURL() + "/school-vaccine-portal/fhir"
+	}
+	return cfg, nil
+}
+
+// Validate reports every required field left empty, naming its environment variable, and refuses published
+// certificate keys
+func (c *Config) Validate() error {
+	missing := []string{}
+	walk(reflect.ValueOf(c).Elem(), "", func(field reflect.StructField, value reflect.Value, key string) error {
+		if field.Tag.Get("required") == "true" && value.IsZero() {
+			missing = append(missing, fmt.Sprintf("%s (%s)", key, field.Tag.Get("env")))
+		}
+		return nil
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required configuration: %s", strings.Join(missing, ", "))
+	}
+	return c.Certificate.CheckKeys()
+}
+
+// String lists every setting as key=value with secrets redacted, so the config can be logged safely
+func (c Config) String() string {
+	lines := []string{}
+	walk(reflect.ValueOf(&c).Elem(), "", func(field reflect.StructField, value reflect.Value, key string) error {
+		shown := fmt.Sprint(value.Interface())
+		if field.Tag.Get("secret") == "true" && shown != "" {
+			shown = REDACTED
+		}
+		lines = append(lines, key+"="+shown)
+		return nil
+	})
+	return strings.Join(lines, "\n")
+}
+
+func applyDefault(field reflect.StructField, value reflect.Value, key string) error {
+	if def, ok := field.Tag.Lookup("default"); ok {
+		return setValue(value, def, key)
+	}
+	return nil
+}
+
+func applyEnv(field reflect.StructField, value reflect.Value, key string) error {
+	name := field.Tag.Get("env")
+	if name == "" || os.Getenv(name) == "" {
+		return nil
+	}
+	return setValue(value, os.Getenv(name), name)
+}
+
+func setValue(value reflect.Value, raw, name string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", name, raw)
+		}
+		value.SetInt(int64(number))
+	case reflect.Bool:
+		flag, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", name, raw)
+		}
+		value.SetBool(flag)
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", name, raw)
+		}
+		value.SetFloat(number)
+	default:
+		return fmt.Errorf("%s has unsupported type %s", name, value.Kind())
+	}
+	return nil
+}
+
+// walk calls visit for every leaf setting with its dotted YAML key, descending into nested sections
+func walk(section reflect.Value, prefix string, visit func(reflect.StructField, reflect.Value, string) error) error {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Type().Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		if section.Field(i).Kind() == reflect.Struct {
+			if err := walk(section.Field(i), key+".", visit); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := visit(field, section.Field(i), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdverseEventController interface{}
+type AEController struct {
+	req  requests.AdverseEventRequestHandler
+	uc   usecase.AdverseEventUsecaseHandler
+	resp response.AdverseEventResponseHandler
+}
+
+func (v AEController) CreateAdverseEvent(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.AdverseEventCreateRequest)
+	model := new(models.AdverseEvent)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if err = v.uc.CreateAdverseEvent(ctx, model); err != nil {
+		logging.From(c).Error("error in reporting adverse event", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusCreated, v.resp.ProcessAdverseEventResponse(links.From(c), req, model))
+}
+
+func (v AEController) GetAdverseEvents(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetAdverseEventRequest)
+	model := new(models.AdverseEvent)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	total, data, err := v.uc.GetAdverseEvents(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in fetching adverse events", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	finalResp := v.resp.ProcessAdverseEventResponse(links.From(c), req, data)
+	finalResp.Total = total
+	return c.JSON(http.StatusOK, finalResp)
+}
+
+func (v AEController) GenerateAdverseEventReport(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.AdverseEventReportRequest)
+	model := new(models.AdverseEvent)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding adverse event report request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	fileLoc, err := v.uc.GenerateAdverseEventReport(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in generating adverse event report", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"file": fileLoc,
+	})
+}
+
+func NewAdverseEventController(e *echo.Echo, req requests.AdverseEventRequestHandler, uc usecase.AdverseEventUsecaseHandler, resp response.AdverseEventResponseHandler) AdverseEventController {
+	aefiController := AEController{
+		req:  req,
+		uc:   uc,
+		resp: resp,
+	}
+	e.POST("school-vaccine-portal/adverse-events", aefiController.CreateAdverseEvent)
+	e.GET("school-vaccine-portal/adverse-events", aefiController.GetAdverseEvents)
+	e.GET("school-vaccine-portal/adverse-events/summary-report", aefiController.GenerateAdverseEventReport)
+	e.GET("school-vaccine-portal/adverse-events/:id", aefiController.GetAdverseEvents)
+	return e
+}
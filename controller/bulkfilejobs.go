@@ -1,8 +1,9 @@
 package controller
 
 import (
-	"log"
 	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
 	"school_vaccination_portal/response"
@@ -14,87 +15,112 @@ import (
 type BulkFileJobsController interface{}
 
 type BController struct {
-	req requests.BulkFileJobRequestHandler
-	uc  usecase.BulkFileJobUsecaseHandler
+	req  requests.BulkFileJobRequestHandler
+	uc   usecase.BulkFileJobUsecaseHandler
+	resp response.BulkFileJobResponseHandler
 }
 
 const (
-	BULK_STUDENT_RECORD = "STUDENT_CREATION_REC"
-	BULK_VACCINE_RECORD = "VACCINE_CREATION_REC"
+	BULK_STUDENT_RECORD  = "STUDENT_CREATION_REC"
+	BULK_VACCINE_RECORD  = "VACCINE_CREATION_REC"
+	BULK_CONSENT_RECORD  = "CONSENT_CREATION_REC"
+	BULK_GUARDIAN_RECORD = "GUARDIAN_CREATION_REC"
+	HL7_VXU_EXPORT       = "HL7_VXU_EXPORT"
 )
 
 func (v BController) CreateStudentRecordBulk(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.BulkFileJobRequest)
 	model := new(models.BulkFileJobsModel)
 	model.RequestType = BULK_STUDENT_RECORD
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	if err = v.uc.UploadBulkRequestFile(model); err != nil {
-		log.Println("error in processing student record update Request")
+	if err = v.uc.UploadBulkRequestFile(ctx, model); err != nil {
+		logging.From(c).Error("error in processing student record update Request", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusAccepted, map[string]interface{}{
-		"message_string": "Request Accepted! Please check after sometime",
-		"data": map[string]string{
-			"request_id": model.RequestId,
-			"status":     model.Status,
-		},
-	})
+	return c.JSON(http.StatusAccepted, v.resp.ProcessBulkFileJobResponse(links.From(c), req, model))
 }
 func (v BController) CreateVaccinationRecordBulk(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.BulkFileJobRequest)
 	model := new(models.BulkFileJobsModel)
 	model.RequestType = BULK_VACCINE_RECORD
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	if err = v.uc.UploadBulkRequestFile(model); err != nil {
-		log.Println("error in processing student record update Request")
+	if err = v.uc.UploadBulkRequestFile(ctx, model); err != nil {
+		logging.From(c).Error("error in processing student record update Request", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusAccepted, map[string]interface{}{
-		"message_string": "Request Accepted! Please check after sometime",
-		"data": map[string]string{
-			"request_id": model.RequestId,
-			"status":     model.Status,
-		},
-	})
+	return c.JSON(http.StatusAccepted, v.resp.ProcessBulkFileJobResponse(links.From(c), req, model))
+}
+func (v BController) CreateConsentRecordBulk(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.BulkFileJobRequest)
+	model := new(models.BulkFileJobsModel)
+	model.RequestType = BULK_CONSENT_RECORD
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if err = v.uc.UploadBulkRequestFile(ctx, model); err != nil {
+		logging.From(c).Error("error in processing consent record upload Request", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusAccepted, v.resp.ProcessBulkFileJobResponse(links.From(c), req, model))
+}
+func (v BController) CreateGuardianRecordBulk(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.BulkFileJobRequest)
+	model := new(models.BulkFileJobsModel)
+	model.RequestType = BULK_GUARDIAN_RECORD
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if err = v.uc.UploadBulkRequestFile(ctx, model); err != nil {
+		logging.From(c).Error("error in processing guardian record upload Request", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusAccepted, v.resp.ProcessBulkFileJobResponse(links.From(c), req, model))
 }
 func (v BController) GetBulkJobStatus(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.GetBulkFileRequest)
 	model := new(models.BulkFileJobsModel)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Printf("request received is %+v", req)
-	total, resp, err := v.uc.GetBulkFileJobDetails(req.RequestId, req.Pagination)
+	total, resp, err := v.uc.GetBulkFileJobDetails(ctx, req.RequestId, req.Pagination)
 	if err != nil {
-		log.Println("error in getting bulkUpload details Detail", err.Error())
+		logging.From(c).Error("error in getting bulkUpload details Detail", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message_string": "bulk job details fetched successfully",
-		"data":           resp,
-		"limit":          req.Pagination.Limit,
-		"offset":         req.Pagination.Offset,
-		"total":          total,
-	})
+	finalResp := v.resp.ProcessBulkFileJobResponse(links.From(c), req, resp)
+	finalResp.Total = total
+	return c.JSON(http.StatusOK, finalResp)
 }
 
-func NewBulkUploadController(e *echo.Echo, req requests.BulkFileJobRequestHandler, uc usecase.BulkFileJobUsecaseHandler) BulkFileJobsController {
+func NewBulkUploadController(e *echo.Echo, req requests.BulkFileJobRequestHandler, uc usecase.BulkFileJobUsecaseHandler, resp response.BulkFileJobResponseHandler) BulkFileJobsController {
 	studentServiceController := BController{
-		req: req,
-		uc:  uc,
+		req:  req,
+		uc:   uc,
+		resp: resp,
 	}
 	e.POST("school-vaccine-portal/bulk-upload/students", studentServiceController.CreateStudentRecordBulk)
 	e.POST("school-vaccine-portal/bulk-upload/vaccine-records", studentServiceController.CreateVaccinationRecordBulk)
+	e.POST("school-vaccine-portal/bulk-upload/consents", studentServiceController.CreateConsentRecordBulk)
+	e.POST("school-vaccine-portal/bulk-upload/guardians", studentServiceController.CreateGuardianRecordBulk)
 	e.GET("school-vaccine-portal/bulk-upload/:request_id", studentServiceController.GetBulkJobStatus)
 	e.GET("school-vaccine-portal/bulk-upload", studentServiceController.GetBulkJobStatus)
 	return e
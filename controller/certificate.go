@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CertificateController interface{}
+type CertController struct {
+	req  requests.CertificateRequestHandler
+	uc   usecase.CertificateUsecaseHandler
+	resp response.CertificateResponseHandler
+}
+
+func (v CertController) GetCertificate(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.CertificateRequest)
+	model := new(models.Certificate)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding certificate request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	issued, content, err := v.uc.GenerateCertificate(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in generating certificate", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", issued.CertificateNumber+".pdf"))
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
+
+func (v CertController) GenerateClassCertificates(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.ClassCertificateRequest)
+	model := new(models.Certificate)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding class certificate request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	fileLoc, err := v.uc.GenerateClassCertificates(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in generating class certificates", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"file": fileLoc,
+	})
+}
+
+// VerifyCertificate is public, schools and camps call it from the link in the certificate QR code
+func (v CertController) VerifyCertificate(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.VerifyCertificateRequest)
+	model := new(models.Certificate)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding verify certificate request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.VerifyCertificate(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in verifying certificate", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessCertificateResponse(links.From(c), req, data))
+}
+
+func (v CertController) RevokeCertificate(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.RevokeCertificateRequest)
+	model := new(models.Certificate)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding revoke certificate request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.RevokeCertificate(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in revoking certificate", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessCertificateResponse(links.From(c), req, data))
+}
+
+func (v CertController) GetRevokedCertificates(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(requests.GetRevokedCertificatesRequest)
+	data, err := v.uc.GetRevokedCertificates(ctx)
+	if err != nil {
+		logging.From(c).Error("error in fetching revoked certificates", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessCertificateResponse(links.From(c), req, data))
+}
+
+func NewCertificateController(e *echo.Echo, req requests.CertificateRequestHandler, uc usecase.CertificateUsecaseHandler, resp response.CertificateResponseHandler) CertificateController {
+	certificateController := CertController{
+		req:  req,
+		uc:   uc,
+		resp: resp,
+	}
+	e.GET("school-vaccine-portal/student-management/students/certificates", certificateController.GenerateClassCertificates)
+	e.GET("school-vaccine-portal/student-management/students/:id/certificate", certificateController.GetCertificate)
+	e.GET("school-vaccine-portal/verify", certificateController.VerifyCertificate)
+	e.POST("school-vaccine-portal/verify", certificateController.VerifyCertificate)
+	e.GET("school-vaccine-portal/certificates/revoked", certificateController.GetRevokedCertificates)
+	e.POST("school-vaccine-portal/certificates/:id/revoke", certificateController.RevokeCertificate)
+	return e
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ConsentController interface{}
+type CController struct {
+	req  requests.ConsentRequestHandler
+	uc   usecase.ConsentUsecaseHandler
+	resp response.ConsentResponseHandler
+}
+
+func (v CController) CreateConsent(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.ConsentCreateRequest)
+	model := new(models.Consent)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	result := v.uc.CreateConsents(ctx, &[]models.Consent{*model})
+	if !result[0].Status {
+		logging.From(c).Warn("error in recording consent", "reason", result[0].ErrorReason)
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(errors.New(result[0].ErrorReason)))
+	}
+	return c.JSON(http.StatusCreated, v.resp.ProcessConsentResponse(links.From(c), req, &result[0].Record))
+}
+
+func (v CController) UpdateConsent(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.ConsentUpdateRequest)
+	model := new(models.Consent)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.UpdateConsent(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in updating consent", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessConsentResponse(links.From(c), req, data))
+}
+
+func (v CController) GetConsents(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetConsentRequest)
+	model := new(models.Consent)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	total, data, err := v.uc.GetConsents(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in fetching consents", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	finalResp := v.resp.ProcessConsentResponse(links.From(c), req, data)
+	finalResp.Total = total
+	return c.JSON(http.StatusOK, finalResp)
+}
+
+func (v CController) GeneratePendingConsentReport(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.PendingConsentReportRequest)
+	model := new(models.Consent)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding pending consent report request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	fileLoc, err := v.uc.GeneratePendingConsentReport(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in generating pending consent report", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"file": fileLoc,
+	})
+}
+
+func NewConsentController(e *echo.Echo, req requests.ConsentRequestHandler, uc usecase.ConsentUsecaseHandler, resp response.ConsentResponseHandler) ConsentController {
+	consentController := CController{
+		req:  req,
+		uc:   uc,
+		resp: resp,
+	}
+	e.POST("school-vaccine-portal/consents", consentController.CreateConsent)
+	e.PATCH("school-vaccine-portal/consents", consentController.UpdateConsent)
+	e.GET("school-vaccine-portal/consents", consentController.GetConsents)
+	e.GET("school-vaccine-portal/consents/pending-report", consentController.GeneratePendingConsentReport)
+	e.GET("school-vaccine-portal/consents/:id", consentController.GetConsents)
+	return e
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ExemptionController interface{}
+type EController struct {
+	req  requests.ExemptionRequestHandler
+	uc   usecase.ExemptionUsecaseHandler
+	resp response.ExemptionResponseHandler
+}
+
+func (v EController) CreateExemption(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.ExemptionCreateRequest)
+	model := new(models.Exemption)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if err = v.uc.CreateExemption(ctx, model); err != nil {
+		logging.From(c).Error("error in recording exemption", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusCreated, v.resp.ProcessExemptionResponse(links.From(c), req, model))
+}
+
+func (v EController) GetExemptions(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetExemptionRequest)
+	model := new(models.Exemption)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	total, data, err := v.uc.GetExemptions(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in fetching exemptions", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	finalResp := v.resp.ProcessExemptionResponse(links.From(c), req, data)
+	finalResp.Total = total
+	return c.JSON(http.StatusOK, finalResp)
+}
+
+func (v EController) DeleteExemption(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.DeleteExemptionRequest)
+	model := new(models.Exemption)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if err = v.uc.DeleteExemption(ctx, model.Id); err != nil {
+		logging.From(c).Error("error in deleting exemption", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessExemptionResponse(links.From(c), req, nil))
+}
+
+func NewExemptionController(e *echo.Echo, req requests.ExemptionRequestHandler, uc usecase.ExemptionUsecaseHandler, resp response.ExemptionResponseHandler) ExemptionController {
+	exemptionController := EController{
+		req:  req,
+		uc:   uc,
+		resp: resp,
+	}
+	e.POST("school-vaccine-portal/exemptions", exemptionController.CreateExemption)
+	e.GET("school-vaccine-portal/exemptions", exemptionController.GetExemptions)
+	e.GET("school-vaccine-portal/exemptions/:id", exemptionController.GetExemptions)
+	e.DELETE("school-vaccine-portal/exemptions/:id", exemptionController.DeleteExemption)
+	return e
+}
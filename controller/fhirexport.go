@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"net/http"
+	"school_vaccination_portal/fhir"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type FHIRExportController interface{}
+type FController struct {
+	req requests.FHIRExportRequestHandler
+	uc  usecase.FHIRExportUsecaseHandler
+}
+
+// Export returns a collection Bundle inline, or with format=ndjson writes bulk data files and returns their manifest
+func (v FController) Export(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.FHIRExportRequest)
+	if err = v.req.Bind(c, req); err != nil {
+		logging.From(c).Warn("error in binding fhir export request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if req.Format == "ndjson" {
+		manifest, err := v.uc.ExportNDJSON(ctx, req)
+		if err != nil {
+			logging.From(c).Error("error in generating fhir ndjson export", logging.Err(err))
+			return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+		}
+		return c.JSON(http.StatusOK, manifest)
+	}
+	bundle, err := v.uc.ExportBundle(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in generating fhir bundle", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	c.Response().Header().Set(echo.HeaderContentType, fhir.CONTENT_TYPE)
+	return c.JSON(http.StatusOK, bundle)
+}
+
+func NewFHIRExportController(e *echo.Echo, req requests.FHIRExportRequestHandler, uc usecase.FHIRExportUsecaseHandler) FHIRExportController {
+	fhirExportController := FController{
+		req: req,
+		uc:  uc,
+	}
+	e.GET("school-vaccine-portal/exports/fhir", fhirExportController.Export)
+	return e
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GuardianController interface{}
+type GController struct {
+	req  requests.GuardianRequestHandler
+	uc   usecase.GuardianUsecaseHandler
+	resp response.GuardianResponseHandler
+}
+
+func (v GController) CreateGuardian(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GuardianCreateRequest)
+	model := new(models.Guardian)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	result := v.uc.CreateGuardians(ctx, &[]models.Guardian{*model})
+	if !result[0].Status {
+		logging.From(c).Warn("error in creating guardian", "reason", result[0].ErrorReason)
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(errors.New(result[0].ErrorReason)))
+	}
+	return c.JSON(http.StatusCreated, v.resp.ProcessGuardianResponse(links.From(c), req, &result[0].Record))
+}
+
+func (v GController) UpdateGuardian(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GuardianUpdateRequest)
+	model := new(models.Guardian)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.UpdateGuardian(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in updating guardian", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessGuardianResponse(links.From(c), req, data))
+}
+
+func (v GController) GetGuardians(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetGuardianRequest)
+	model := new(models.Guardian)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	total, data, err := v.uc.GetGuardians(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in fetching guardians", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	finalResp := v.resp.ProcessGuardianResponse(links.From(c), req, data)
+	finalResp.Total = total
+	return c.JSON(http.StatusOK, finalResp)
+}
+
+func (v GController) DeleteGuardian(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.DeleteGuardianRequest)
+	model := new(models.Guardian)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if err = v.uc.DeleteGuardian(ctx, model.Id); err != nil {
+		logging.From(c).Error("error in deleting guardian", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessGuardianResponse(links.From(c), req, nil))
+}
+
+func (v GController) LinkStudents(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GuardianLinkRequest)
+	model := new(models.Guardian)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.LinkStudents(ctx, model.Id, model.StudentIds)
+	if err != nil {
+		logging.From(c).Error("error in linking students", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessGuardianResponse(links.From(c), req, data))
+}
+
+func (v GController) UnlinkStudent(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GuardianUnlinkRequest)
+	model := new(models.Guardian)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.UnlinkStudent(ctx, req.Id, req.StudentId)
+	if err != nil {
+		logging.From(c).Error("error in unlinking student", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessGuardianResponse(links.From(c), req, data))
+}
+
+func NewGuardianController(e *echo.Echo, req requests.GuardianRequestHandler, uc usecase.GuardianUsecaseHandler, resp response.GuardianResponseHandler) GuardianController {
+	guardianController := GController{
+		req:  req,
+		uc:   uc,
+		resp: resp,
+	}
+	e.POST("school-vaccine-portal/guardians", guardianController.CreateGuardian)
+	e.PATCH("school-vaccine-portal/guardians", guardianController.UpdateGuardian)
+	e.GET("school-vaccine-portal/guardians", guardianController.GetGuardians)
+	e.GET("school-vaccine-portal/guardians/:id", guardianController.GetGuardians)
+	e.DELETE("school-vaccine-portal/guardians/:id", guardianController.DeleteGuardian)
+	e.POST("school-vaccine-portal/guardians/:id/students", guardianController.LinkStudents)
+	e.DELETE("school-vaccine-portal/guardians/:id/students/:student_id", guardianController.UnlinkStudent)
+	return e
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HL7ExportController interface{}
+type HController struct {
+	req  requests.HL7ExportRequestHandler
+	uc   usecase.HL7ExportUsecaseHandler
+	resp response.BulkFileJobResponseHandler
+}
+
+// Export queues a VXU export for the bulk worker, its progress and file are under bulk-upload/:request_id
+func (v HController) Export(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.HL7ExportRequest)
+	model := new(models.BulkFileJobsModel)
+	model.RequestType = HL7_VXU_EXPORT
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding hl7 export request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	if err = v.uc.QueueExport(ctx, model); err != nil {
+		logging.From(c).Error("error in queueing hl7 export", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusAccepted, v.resp.ProcessBulkFileJobResponse(links.From(c), req, model))
+}
+
+func NewHL7ExportController(e *echo.Echo, req requests.HL7ExportRequestHandler, uc usecase.HL7ExportUsecaseHandler, resp response.BulkFileJobResponseHandler) HL7ExportController {
+	hl7ExportController := HController{
+		req:  req,
+		uc:   uc,
+		resp: resp,
+	}
+	e.POST("school-vaccine-portal/exports/hl7", hl7ExportController.Export)
+	return e
+}
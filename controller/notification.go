@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationController interface{}
+type NController struct {
+	req  requests.NotificationRequestHandler
+	uc   usecase.NotificationUsecaseHandler
+	resp response.NotificationResponseHandler
+	// schedule defaults used when a request leaves days out
+	schedule config.NotificationConfig
+}
+
+func (v NController) GetNotifications(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetNotificationRequest)
+	model := new(models.Notification)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	total, data, err := v.uc.GetNotifications(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in fetching notifications", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	finalResp := v.resp.ProcessNotificationResponse(links.From(c), req, data)
+	finalResp.Total = total
+	return c.JSON(http.StatusOK, finalResp)
+}
+
+func (v NController) QueueDriveReminders(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.NotificationScheduleRequest)
+	model := new(models.Notification)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	days := v.schedule.ReminderDaysBefore
+	if req.Days != nil {
+		days = *req.Days
+	}
+	queued, err := v.uc.QueueDriveReminders(ctx, days)
+	if err != nil {
+		logging.From(c).Error("error in queueing drive reminders", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusAccepted, v.resp.ProcessNotificationResponse(links.From(c), req, queued))
+}
+
+func (v NController) QueueMissedDriveFollowUps(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.NotificationScheduleRequest)
+	model := new(models.Notification)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	days := v.schedule.MissedFollowUpDays
+	if req.Days != nil {
+		days = *req.Days
+	}
+	queued, err := v.uc.QueueMissedDriveFollowUps(ctx, days)
+	if err != nil {
+		logging.From(c).Error("error in queueing missed drive follow ups", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusAccepted, v.resp.ProcessNotificationResponse(links.From(c), req, queued))
+}
+
+func (v NController) RetryNotification(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.RetryNotificationRequest)
+	model := new(models.Notification)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.RetryNotification(ctx, model.Id)
+	if err != nil {
+		logging.From(c).Error("error in retrying notification", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusAccepted, v.resp.ProcessNotificationResponse(links.From(c), req, data))
+}
+
+func NewNotificationController(e *echo.Echo, req requests.NotificationRequestHandler, uc usecase.NotificationUsecaseHandler, resp response.NotificationResponseHandler, schedule config.NotificationConfig) NotificationController {
+	notificationController := NController{
+		req:      req,
+		uc:       uc,
+		resp:     resp,
+		schedule: schedule,
+	}
+	e.GET("school-vaccine-portal/notifications", notificationController.GetNotifications)
+	e.GET("school-vaccine-portal/notifications/:id", notificationController.GetNotifications)
+	e.POST("school-vaccine-portal/notifications/drive-reminders", notificationController.QueueDriveReminders)
+	e.POST("school-vaccine-portal/notifications/missed-drive-followups", notificationController.QueueMissedDriveFollowUps)
+	e.POST("school-vaccine-portal/notifications/:id/retry", notificationController.RetryNotification)
+	return e
+}
@@ -1,8 +1,10 @@
 package controller
 
 import (
-	"log"
+	"math"
 	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
 	"school_vaccination_portal/response"
@@ -13,107 +15,167 @@ import (
 
 type StudentManagementController interface{}
 type SController struct {
-	req  requests.StudentManagementRequestHandler
-	uc   usecase.StudentManagementUsecaseHandler
-	resp response.StudentManagementResponseHandler
+	req    requests.StudentManagementRequestHandler
+	uc     usecase.StudentManagementUsecaseHandler
+	resp   response.StudentManagementResponseHandler
+	fhirUc usecase.FHIRExportUsecaseHandler
+	hl7Uc  usecase.HL7ExportUsecaseHandler
 }
 
 func (v SController) CreateStudentRecord(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.StudentManagementCreateRequest)
 	model := new([]models.StudentManagement)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Println("request for adding student record is", model)
-	resp := v.uc.CreateStudentRecords(model)
+	logging.From(c).Info("creating student records", "count", len(*model))
+	resp := v.uc.CreateStudentRecords(ctx, model)
 	if resp[0].Status {
-		return c.JSON(http.StatusCreated, v.resp.ProcessResponse(req, resp))
+		return c.JSON(http.StatusCreated, v.resp.ProcessResponse(links.From(c), req, resp))
 	}
-	return c.JSON(http.StatusBadRequest, v.resp.ProcessResponse(req, resp))
+	return c.JSON(http.StatusBadRequest, v.resp.ProcessResponse(links.From(c), req, resp))
 }
 func (v SController) CreateStudentRecordBulk(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.StudentManagementUpdateRequest)
 	model := new(models.StudentManagement)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Println("request for adding student record is", model)
-	resp, err := v.uc.UpdateStudentRecord(*model)
+	logging.From(c).Info("updating student record", "student_id", model.Id)
+	resp, err := v.uc.UpdateStudentRecord(ctx, *model)
 	if err != nil {
-		log.Println("student record update failed", err.Error())
+		logging.From(c).Error("student record update failed", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusOK, v.resp.ProcessResponse(req, resp))
+	return c.JSON(http.StatusOK, v.resp.ProcessResponse(links.From(c), req, resp))
 }
 func (v SController) EditStudentRecord(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.StudentManagementUpdateRequest)
 	model := new(models.StudentManagement)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Println("request for adding student record is", model)
-	resp, err := v.uc.UpdateStudentRecord(*model)
+	logging.From(c).Info("updating student record", "student_id", model.Id)
+	resp, err := v.uc.UpdateStudentRecord(ctx, *model)
 	if err != nil {
-		log.Println("student record update failed", err.Error())
+		logging.From(c).Error("student record update failed", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusOK, v.resp.ProcessResponse(req, resp))
+	return c.JSON(http.StatusOK, v.resp.ProcessResponse(links.From(c), req, resp))
 }
 func (v SController) CreateVaccineRecord(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.StudentVaccinationRecordCreateRequest)
 	model := new([]models.StudentVaccineRecord)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Println("request for adding vaccine record is", model)
-	resp := v.uc.CreateVaccinationRecords(model)
+	logging.From(c).Info("creating vaccination records", "count", len(*model))
+	resp := v.uc.CreateVaccinationRecords(ctx, model)
 	if resp[0].Status {
-		return c.JSON(http.StatusCreated, v.resp.ProcessResponse(req, resp))
+		return c.JSON(http.StatusCreated, v.resp.ProcessResponse(links.From(c), req, resp))
 	}
-	return c.JSON(http.StatusBadRequest, v.resp.ProcessResponse(req, resp))
+	return c.JSON(http.StatusBadRequest, v.resp.ProcessResponse(links.From(c), req, resp))
 }
-func (v SController) GetStudentVaccinationRecord(c echo.Context) error {
+func (v SController) VoidVaccineRecord(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
-	req := new(requests.GetStudentVaccinationRecordRequest)
+	req := new(requests.VaccinationRecordVoidRequest)
+	model := new(models.StudentVaccineRecord)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	resp, err := v.uc.VoidVaccinationRecord(ctx, model.Id, model.StatusReason)
+	if err != nil {
+		logging.From(c).Error("vaccination record void failed", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessResponse(links.From(c), req, resp))
+}
+func (v SController) CorrectVaccineRecord(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.VaccinationRecordCorrectRequest)
+	model := new([]models.StudentVaccineRecord)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	logging.From(c).Info("correcting vaccination record", "record_id", req.Id)
+	resp := v.uc.CorrectVaccinationRecord(ctx, req.Id, req.Reason, (*model)[0])
+	if resp.Status {
+		return c.JSON(http.StatusCreated, v.resp.ProcessResponse(links.From(c), req, resp))
+	}
+	return c.JSON(http.StatusBadRequest, v.resp.ProcessResponse(links.From(c), req, resp))
+}
+func (v SController) GetStudentVaccinationHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetStudentVaccinationHistoryRequest)
 	model := new(models.StudentManagement)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Printf("request received is %+v", req)
-	total, resp, err := v.uc.GetStudentVaccinationRecords(req)
+	resp, err := v.uc.GetStudentVaccinationHistory(ctx, model.Id)
 	if err != nil {
-		log.Println("error in getting vaccination Detail", err.Error())
+		logging.From(c).Error("error in getting vaccination history", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessResponse(links.From(c), req, resp))
+}
+func (v SController) GetStudentVaccinationRecord(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetStudentVaccinationRecordRequest)
+	model := new(models.StudentManagement)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Printf("resp generated received is %+v and total records are %d", resp, total)
+	total, resp, err := v.uc.GetStudentVaccinationRecords(ctx, req)
 	if err != nil {
+		logging.From(c).Error("error in getting vaccination Detail", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
-	finalResp := v.resp.ProcessResponse(req, resp)
+	finalResp := v.resp.ProcessResponse(links.From(c), req, resp)
 	finalResp.Total = total
 	return c.JSON(http.StatusOK, finalResp)
 }
 
 func (v SController) GenerateReport(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.GenerateReportRequest)
 	model := new(models.StudentManagement)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding generate report request")
+		logging.From(c).Warn("error in binding generate report request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Printf("request received for generating report is %+v", req)
-	fileLoc, err := v.uc.GenerateVaccinationReport(req)
+	logging.From(c).Info("generating report", "format", req.Format, "class", req.Class, "vaccine_name", req.VaccineName)
+	var fileLoc string
+	switch req.Format {
+	case "fhir":
+		fileLoc, err = v.fhirUc.ExportBundleFile(ctx, &requests.FHIRExportRequest{Class: req.Class, VaccineName: req.VaccineName, RequestId: req.RequestId})
+	case "hl7":
+		fileLoc, err = v.hl7Uc.ExportBatchFile(ctx, &requests.HL7ExportRequest{Class: req.Class, VaccineName: req.VaccineName, RequestId: req.RequestId})
+	default:
+		fileLoc, err = v.uc.GenerateVaccinationReport(ctx, req)
+	}
 	if err != nil {
-		log.Println("error in getting vaccination Detail", err.Error())
+		logging.From(c).Error("error in getting vaccination Detail", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
 	return c.JSON(http.StatusOK, map[string]string{
@@ -122,34 +184,47 @@ func (v SController) GenerateReport(c echo.Context) error {
 }
 
 func (v SController) GetVaccinationRecordDashBoard(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
-	req := new(requests.StudentManagementRequest)
+	req := new(requests.VaccinationDashboardRequest)
 	model := new(models.StudentManagement)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, response.ProcessErrorResponse(err))
 	}
-	log.Printf("request received is %+v", req)
-	total, vaccinated, err := v.uc.GetVaccinationDashBoardData()
+	total, vaccinated, exempt, err := v.uc.GetVaccinationDashBoardData(ctx, req.VaccineName, req.ExcludeExempt)
 	if err != nil {
-		log.Println("error in getting vaccination Detail", err.Error())
+		logging.From(c).Error("error in getting vaccination Detail", logging.Err(err))
 		return c.JSON(http.StatusInternalServerError, response.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusOK, map[string]int{
+	coverage := 0.0
+	if total > 0 {
+		coverage = float64(vaccinated) * 100 / float64(total)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
 		"total_students":      total,
 		"vaccinated_students": vaccinated,
+		"exempt_students":     exempt,
+		"exempt_excluded":     req.ExcludeExempt,
+		"vaccine_name":        req.VaccineName,
+		"coverage_percentage": math.Round(coverage*100) / 100,
 	})
 }
 
-func NewStudentManagementServiceController(e *echo.Echo, req requests.StudentManagementRequestHandler, uc usecase.StudentManagementUsecaseHandler, resp response.StudentManagementResponseHandler) StudentManagementController {
+func NewStudentManagementServiceController(e *echo.Echo, req requests.StudentManagementRequestHandler, uc usecase.StudentManagementUsecaseHandler, resp response.StudentManagementResponseHandler, fhirUc usecase.FHIRExportUsecaseHandler, hl7Uc usecase.HL7ExportUsecaseHandler) StudentManagementController {
 	studentServiceController := SController{
-		req:  req,
-		uc:   uc,
-		resp: resp,
+		req:    req,
+		uc:     uc,
+		resp:   resp,
+		fhirUc: fhirUc,
+		hl7Uc:  hl7Uc,
 	}
 	e.POST("school-vaccine-portal/student-management/students", studentServiceController.CreateStudentRecord)
 	e.PATCH("school-vaccine-portal/student-management/students", studentServiceController.EditStudentRecord)
 	e.POST("school-vaccine-portal/student-management/vaccine-records", studentServiceController.CreateVaccineRecord)
+	e.POST("school-vaccine-portal/student-management/vaccine-records/:id/void", studentServiceController.VoidVaccineRecord)
+	e.POST("school-vaccine-portal/student-management/vaccine-records/:id/correct", studentServiceController.CorrectVaccineRecord)
+	e.GET("school-vaccine-portal/student-management/vaccine-records/students/:id/history", studentServiceController.GetStudentVaccinationHistory)
 	e.GET("school-vaccine-portal/student-management/vaccine-records/students/:id", studentServiceController.GetStudentVaccinationRecord)
 	e.GET("school-vaccine-portal/student-management/vaccine-records/students", studentServiceController.GetStudentVaccinationRecord)
 	e.GET("school-vaccine-portal/student-management/vaccine-records/dashboard", studentServiceController.GetVaccinationRecordDashBoard)
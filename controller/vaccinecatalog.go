@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type VaccineCatalogController interface{}
+type CatalogController struct {
+	req  requests.VaccineCatalogRequestHandler
+	uc   usecase.VaccineCatalogUsecaseHandler
+	resp response.VaccineCatalogResponseHandler
+}
+
+func (v CatalogController) CreateVaccine(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.VaccineCreateRequest)
+	model := new(models.Vaccine)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	if err = v.uc.CreateVaccine(ctx, model); err != nil {
+		logging.From(c).Error("error in creating vaccine", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusCreated, v.resp.ProcessVaccineCatalogResponse(links.From(c), req, model))
+}
+
+func (v CatalogController) GetVaccines(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.GetVaccineRequest)
+	model := new(models.Vaccine)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	total, data, err := v.uc.GetVaccines(ctx, model, req.Pagination)
+	if err != nil {
+		logging.From(c).Error("error in fetching vaccines", logging.Err(err))
+		return c.JSON(http.StatusInternalServerError, v.resp.ProcessErrorResponse(err))
+	}
+	resp := v.resp.ProcessVaccineCatalogResponse(links.From(c), req, data).(response.VaccineCatalogResponse)
+	resp.Total = total
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v CatalogController) EditVaccine(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.VaccineUpdateRequest)
+	model := new(models.Vaccine)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	data, err := v.uc.UpdateVaccine(ctx, req)
+	if err != nil {
+		logging.From(c).Error("error in updating vaccine", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessVaccineCatalogResponse(links.From(c), req, data))
+}
+
+func (v CatalogController) DeleteVaccine(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.DeleteVaccineRequest)
+	model := new(models.Vaccine)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	if err = v.uc.DeleteVaccine(ctx, model.Id); err != nil {
+		logging.From(c).Error("error in deleting vaccine", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessVaccineCatalogResponse(links.From(c), req, nil))
+}
+
+func NewVaccineCatalogServiceController(e *echo.Echo, req requests.VaccineCatalogRequestHandler, uc usecase.VaccineCatalogUsecaseHandler, resp response.VaccineCatalogResponseHandler) VaccineCatalogController {
+	catalogController := CatalogController{
+		req:  req,
+		uc:   uc,
+		resp: resp,
+	}
+	e.POST("school-vaccine-portal/vaccines", catalogController.CreateVaccine)
+	e.GET("school-vaccine-portal/vaccines", catalogController.GetVaccines)
+	e.GET("school-vaccine-portal/vaccines/:id", catalogController.GetVaccines)
+	e.PATCH("school-vaccine-portal/vaccines", catalogController.EditVaccine)
+	e.DELETE("school-vaccine-portal/vaccines/:id", catalogController.DeleteVaccine)
+	return e
+}
@@ -1,8 +1,9 @@
 package controller
 
 import (
-	"log"
 	"net/http"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
 	"school_vaccination_portal/response"
@@ -19,54 +20,78 @@ type VController struct {
 }
 
 func (v VController) GetVaccinationDriveDetails(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.GetVaccineInventoryRequest)
 	model := new(models.VaccineInventory)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
 	}
 	var data []models.VaccineInventory
-	if data, err = v.uc.GetVaccineDriveDetails(model); err != nil {
-		log.Println("error in creating drive", err.Error())
+	if data, err = v.uc.GetVaccineDriveDetails(ctx, model); err != nil {
+		logging.From(c).Error("error in creating drive", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusOK, v.resp.ProcessVaccineInventoryResponse(req, data))
+	return c.JSON(http.StatusOK, v.resp.ProcessVaccineInventoryResponse(links.From(c), req, data))
 }
 
 func (v VController) CreateVaccinationDrive(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.VaccineInventoryCreateRequest)
 	model := new(models.VaccineInventory)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
 	}
-	if err = v.uc.CreatevaccineDrive(model); err != nil {
-		log.Println("error in creating drive", err.Error())
+	if err = v.uc.CreatevaccineDrive(ctx, model); err != nil {
+		logging.From(c).Error("error in creating drive", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusCreated, v.resp.ProcessVaccineInventoryResponse(req, model))
+	return c.JSON(http.StatusCreated, v.resp.ProcessVaccineInventoryResponse(links.From(c), req, model))
 }
 func (v VController) EditVaccinationDrive(c echo.Context) error {
+	ctx := c.Request().Context()
 	var err error
 	req := new(requests.VaccineInventoryUpdateRequest)
 	model := new(models.VaccineInventory)
 	if err = v.req.Bind(c, req, model); err != nil {
-		log.Println("error in binding request")
+		logging.From(c).Warn("error in binding request", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
 	}
-	if err = v.uc.EditVaccineDrive(req); err != nil {
-		log.Println("error in creating drive", err.Error())
+	if err = v.uc.EditVaccineDrive(ctx, req); err != nil {
+		logging.From(c).Error("error in creating drive", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
 	}
 	var data []models.VaccineInventory
 	model.Id = req.Id
-	if data, err = v.uc.GetVaccineDriveDetails(model); err != nil {
-		log.Println("error in getting drive", err.Error())
+	if data, err = v.uc.GetVaccineDriveDetails(ctx, model); err != nil {
+		logging.From(c).Error("error in getting drive", logging.Err(err))
 		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
 	}
-	return c.JSON(http.StatusOK, v.resp.ProcessVaccineInventoryResponse(req, data))
+	return c.JSON(http.StatusOK, v.resp.ProcessVaccineInventoryResponse(links.From(c), req, data))
+}
+
+func (v VController) CancelVaccinationDrive(c echo.Context) error {
+	ctx := c.Request().Context()
+	var err error
+	req := new(requests.VaccineInventoryCancelRequest)
+	model := new(models.VaccineInventory)
+	if err = v.req.Bind(c, req, model); err != nil {
+		logging.From(c).Warn("error in binding request", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	if err = v.uc.CancelVaccineDrive(ctx, model); err != nil {
+		logging.From(c).Error("error in cancelling drive", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	var data []models.VaccineInventory
+	if data, err = v.uc.GetVaccineDriveDetails(ctx, &models.VaccineInventory{Id: model.Id}); err != nil {
+		logging.From(c).Error("error in getting drive", logging.Err(err))
+		return c.JSON(http.StatusBadRequest, v.resp.ProcessErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, v.resp.ProcessVaccineInventoryResponse(links.From(c), req, data))
 }
 
 func NewVaccineInventoryServiceController(e *echo.Echo, req requests.VaccineInventoryRequestHandler, uc usecase.VaccineInventoryUsecaseHandler, resp response.VacinneInventoryResponseHandler) VaccineInventoryController {
@@ -79,5 +104,6 @@ func NewVaccineInventoryServiceController(e *echo.Echo, req requests.VaccineInve
 	e.GET("school-vaccine-portal/vaccine-inventory/drives", vaccineServiceController.GetVaccinationDriveDetails)
 	e.GET("school-vaccine-portal/vaccine-inventory/drives/:id", vaccineServiceController.GetVaccinationDriveDetails)
 	e.PATCH("school-vaccine-portal/vaccine-inventory/drives", vaccineServiceController.EditVaccinationDrive)
+	e.POST("school-vaccine-portal/vaccine-inventory/drives/:id/cancel", vaccineServiceController.CancelVaccinationDrive)
 	return e
 }
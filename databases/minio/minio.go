@@ -1,26 +1,31 @@
 package minio
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/tracing"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
-func GetMinIOClient() (*minio.Client, error) {
-	var err error
-	minioClient, err := minio.New(fmt.Sprintf("%s:%s", os.Getenv("MINIO_SERVER"), os.Getenv("MINIO_PORT")), &minio.Options{
-		Creds:  credentials.NewStaticV4(os.Getenv("MINIO_USERNAME"), os.Getenv("MINIO_PASSWORD"), ""),
-		Secure: false, // Set to true for HTTPS
-		Region: os.Getenv("MINIO_REGION"),
+func GetMinIOClient(cfg config.MinioConfig, logger *slog.Logger) (*minio.Client, error) {
+	transport, err := minio.DefaultTransport(cfg.Secure)
+	if err != nil {
+		logger.Error("failed to create minio transport", logging.Err(err))
+		return nil, err
+	}
+	minioClient, err := minio.New(cfg.Endpoint(), &minio.Options{
+		Creds:     credentials.NewStaticV4(cfg.Username, cfg.Password, ""),
+		Secure:    cfg.Secure,
+		Region:    cfg.Region,
+		Transport: tracing.Transport("minio", transport),
 	})
 	if err != nil {
-		log.Fatalf("Failed to connect to MinIO: %v", err)
+		logger.Error("failed to create minio client", logging.Err(err))
 		return minioClient, err
-	} else {
-		log.Println("MinIO connected successfully")
 	}
-	return minioClient, err
+	logger.Info("minio client created", "endpoint", cfg.Endpoint(), "bucket", cfg.Bucket)
+	return minioClient, nil
 }
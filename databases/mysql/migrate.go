@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"embed"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/logging"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrate applies every migration under migrations/ that is not yet recorded
+// in schema_migrations, in file name order.
+func Migrate(db *MysqlConnect, logger *slog.Logger) error {
+	if err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version VARCHAR(255) PRIMARY KEY, applied_at DATETIME NOT NULL)").Error; err != nil {
+		logger.Error("unable to create schema_migrations table", logging.Err(err))
+		return err
+	}
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var applied int
+		if err = db.Table("schema_migrations").Where("version = ?", name).Count(&applied).Error; err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+		content, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return err
+		}
+		logger.Info("applying migration", "migration", name)
+		for _, stmt := range splitStatements(string(content)) {
+			if err = db.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("migration %s failed: %s", name, err.Error())
+			}
+		}
+		if err = db.Exec("INSERT INTO schema_migrations (version, applied_at) VALUES (?, NOW())", name).Error; err != nil {
+			return err
+		}
+	}
+	logger.Info("migrations up to date")
+	return nil
+}
+
+// the DSN does not enable multiStatements, so every statement is sent on its own
+func splitStatements(content string) []string {
+	statements := []string{}
+	for _, stmt := range strings.Split(content, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	return statements
+}
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS student_management (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    class VARCHAR(20) NOT NULL,
+    gender VARCHAR(20) NOT NULL,
+    roll_number VARCHAR(50) NOT NULL,
+    phone_no VARCHAR(20) NOT NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE TABLE IF NOT EXISTS vaccination_inventory (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vaccine_name VARCHAR(255) NOT NULL,
+    drive_date DATETIME NOT NULL,
+    doses INT NOT NULL,
+    classes TEXT,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE TABLE IF NOT EXISTS student_vaccination_records (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    student_id INT NOT NULL,
+    drive_id INT NOT NULL,
+    created_at DATETIME NULL,
+    KEY idx_svr_student (student_id),
+    KEY idx_svr_drive (drive_id)
+);
+
+CREATE TABLE IF NOT EXISTS bulk_file_jobs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(1024) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error_message TEXT,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    processed_records INT NOT NULL DEFAULT 0,
+    total_records INT NOT NULL DEFAULT 0,
+    request_id VARCHAR(64) NOT NULL,
+    request_type VARCHAR(50) NOT NULL,
+    KEY idx_bfj_request (request_id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE vaccination_inventory
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'SCHEDULED',
+    ADD COLUMN cancellation_reason TEXT NULL;
+
+UPDATE vaccination_inventory SET status = 'COMPLETED' WHERE drive_date < CURDATE();
@@ -0,0 +1,6 @@
+ALTER TABLE vaccination_inventory
+    ADD COLUMN doses_used INT NOT NULL DEFAULT 0,
+    ADD COLUMN doses_wasted INT NOT NULL DEFAULT 0;
+
+UPDATE vaccination_inventory vi
+SET doses_used = (SELECT COUNT(*) FROM student_vaccination_records r WHERE r.drive_id = vi.id);
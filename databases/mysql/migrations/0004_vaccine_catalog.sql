@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS vaccines (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    manufacturer VARCHAR(255) NOT NULL DEFAULT '',
+    doses_in_series INT NOT NULL DEFAULT 1,
+    min_interval_days INT NOT NULL DEFAULT 0,
+    eligible_grades TEXT,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    UNIQUE KEY uq_vaccines_name (name)
+);
+
+INSERT INTO vaccines (name, doses_in_series, min_interval_days, eligible_grades, created_at, updated_at)
+SELECT DISTINCT TRIM(vaccine_name), 1, 0, '[]', NOW(), NOW() FROM vaccination_inventory;
+
+ALTER TABLE vaccination_inventory ADD COLUMN vaccine_id INT NULL;
+
+UPDATE vaccination_inventory vi
+INNER JOIN vaccines v ON v.name = TRIM(vi.vaccine_name)
+SET vi.vaccine_id = v.id, vi.vaccine_name = v.name;
+
+ALTER TABLE vaccination_inventory ADD KEY idx_vi_vaccine (vaccine_id);
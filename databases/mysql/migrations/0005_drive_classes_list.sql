@@ -0,0 +1,5 @@
+UPDATE vaccination_inventory SET classes = '[]' WHERE classes IS NULL OR TRIM(classes) = '';
+
+UPDATE vaccination_inventory
+SET classes = CONCAT('["', REPLACE(REPLACE(TRIM(classes), ', ', ','), ',', '","'), '"]')
+WHERE classes NOT LIKE '[%';
@@ -0,0 +1,41 @@
+ALTER TABLE student_vaccination_records
+    ADD COLUMN vaccine_id INT NULL,
+    ADD COLUMN dose_number INT NOT NULL DEFAULT 1;
+
+UPDATE student_vaccination_records r
+INNER JOIN vaccination_inventory d ON d.id = r.drive_id
+SET r.vaccine_id = d.vaccine_id;
+
+CREATE TABLE student_vaccination_record_duplicates LIKE student_vaccination_records;
+
+ALTER TABLE student_vaccination_record_duplicates
+    ADD COLUMN duplicate_of INT NOT NULL,
+    ADD COLUMN archived_reason VARCHAR(255) NOT NULL,
+    ADD COLUMN archived_at DATETIME NOT NULL;
+
+INSERT INTO student_vaccination_record_duplicates
+SELECT r.*, kept.id, 'another record of the same student at the same drive was kept', NOW()
+FROM student_vaccination_records r
+INNER JOIN (
+    SELECT student_id, drive_id, MIN(id) AS id
+    FROM student_vaccination_records
+    GROUP BY student_id, drive_id
+) kept ON kept.student_id = r.student_id AND kept.drive_id = r.drive_id AND r.id > kept.id;
+
+DELETE r FROM student_vaccination_records r
+INNER JOIN student_vaccination_record_duplicates a ON a.id = r.id;
+
+UPDATE student_vaccination_records r
+INNER JOIN (
+    SELECT r.id, ROW_NUMBER() OVER (PARTITION BY r.student_id, r.vaccine_id ORDER BY d.drive_date, r.id) AS dose
+    FROM student_vaccination_records r
+    INNER JOIN vaccination_inventory d ON d.id = r.drive_id
+) numbered ON numbered.id = r.id
+SET r.dose_number = numbered.dose;
+
+UPDATE vaccination_inventory vi
+SET doses_used = (SELECT COUNT(*) FROM student_vaccination_records r WHERE r.drive_id = vi.id);
+
+ALTER TABLE student_vaccination_records
+    ADD UNIQUE KEY uq_svr_student_drive (student_id, drive_id),
+    ADD UNIQUE KEY uq_svr_student_vaccine_dose (student_id, vaccine_id, dose_number);
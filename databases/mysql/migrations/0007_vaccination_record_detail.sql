@@ -0,0 +1,12 @@
+ALTER TABLE student_vaccination_records
+    ADD COLUMN administered_at DATETIME NULL,
+    ADD COLUMN lot_number VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN expiry_date DATE NULL,
+    ADD COLUMN injection_site VARCHAR(30) NOT NULL DEFAULT '',
+    ADD COLUMN administered_by VARCHAR(255) NOT NULL DEFAULT '',
+    ADD KEY idx_svr_lot (lot_number);
+
+UPDATE student_vaccination_records r
+INNER JOIN vaccination_inventory d ON d.id = r.drive_id
+SET r.administered_at = d.drive_date
+WHERE r.administered_at IS NULL;
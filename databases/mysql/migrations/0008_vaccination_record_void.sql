@@ -0,0 +1,13 @@
+ALTER TABLE student_vaccination_records
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    ADD COLUMN status_reason TEXT NULL,
+    ADD COLUMN status_changed_at DATETIME NULL,
+    ADD COLUMN superseded_by INT NULL,
+    ADD COLUMN corrects_record_id INT NULL,
+    ADD COLUMN is_active TINYINT AS (IF(status = 'ACTIVE', 1, NULL)) STORED;
+
+ALTER TABLE student_vaccination_records
+    DROP INDEX uq_svr_student_drive,
+    DROP INDEX uq_svr_student_vaccine_dose,
+    ADD UNIQUE KEY uq_svr_student_drive (student_id, drive_id, is_active),
+    ADD UNIQUE KEY uq_svr_student_vaccine_dose (student_id, vaccine_id, dose_number, is_active);
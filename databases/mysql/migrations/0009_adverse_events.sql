@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS adverse_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vaccination_record_id INT NOT NULL,
+    student_id INT NOT NULL,
+    drive_id INT NOT NULL,
+    lot_number VARCHAR(100) NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL,
+    onset_at DATETIME NOT NULL,
+    symptoms TEXT NOT NULL,
+    actions_taken TEXT,
+    outcome VARCHAR(20) NOT NULL,
+    reported_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    KEY idx_ae_record (vaccination_record_id),
+    KEY idx_ae_drive_lot (drive_id, lot_number)
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS consents (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    student_id INT NOT NULL,
+    drive_id INT NOT NULL DEFAULT 0,
+    vaccine_id INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    guardian_name VARCHAR(255) NOT NULL,
+    consent_date DATE NOT NULL,
+    form_path VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    KEY idx_consent_student (student_id),
+    KEY idx_consent_drive (drive_id),
+    KEY idx_consent_vaccine (vaccine_id)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS exemptions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    student_id INT NOT NULL,
+    vaccine_id INT NOT NULL,
+    exemption_type VARCHAR(20) NOT NULL,
+    reason TEXT NULL,
+    expiry_date DATE NULL,
+    document_path VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    KEY idx_exemption_student (student_id),
+    KEY idx_exemption_vaccine (vaccine_id)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS guardians (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    relationship VARCHAR(20) NOT NULL,
+    phone_primary VARCHAR(20) NOT NULL,
+    phone_secondary VARCHAR(20) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    preferred_language VARCHAR(10) NOT NULL DEFAULT 'en',
+    notification_opt_in TINYINT(1) NOT NULL DEFAULT 1,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    KEY idx_guardian_phone (phone_primary)
+);
+
+CREATE TABLE IF NOT EXISTS student_guardians (
+    student_id INT NOT NULL,
+    guardian_id INT NOT NULL,
+    created_at DATETIME NULL,
+    PRIMARY KEY (student_id, guardian_id),
+    KEY idx_student_guardian_guardian (guardian_id)
+);
+
+ALTER TABLE consents ADD COLUMN guardian_id INT NOT NULL DEFAULT 0 AFTER status;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    notification_type VARCHAR(40) NOT NULL,
+    channel VARCHAR(10) NOT NULL,
+    guardian_id INT NOT NULL,
+    student_id INT NOT NULL,
+    drive_id INT NOT NULL DEFAULT 0,
+    record_id INT NOT NULL DEFAULT 0,
+    recipient VARCHAR(255) NOT NULL,
+    language VARCHAR(10) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    error_message TEXT NULL,
+    sent_at DATETIME NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    UNIQUE KEY uq_notification (notification_type, channel, guardian_id, student_id, drive_id, record_id),
+    KEY idx_notification_status (status)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS certificates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    certificate_number VARCHAR(40) NOT NULL,
+    student_id INT NOT NULL,
+    issued_at DATETIME NOT NULL,
+    file_path VARCHAR(1024) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'ISSUED',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    UNIQUE KEY uq_certificate_number (certificate_number),
+    KEY idx_certificate_student (student_id)
+);
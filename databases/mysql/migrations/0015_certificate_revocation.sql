@@ -0,0 +1,4 @@
+ALTER TABLE certificates
+    ADD COLUMN revoked_at DATETIME NULL,
+    ADD COLUMN revoked_reason VARCHAR(255) NOT NULL DEFAULT '',
+    ADD KEY idx_certificate_status (status);
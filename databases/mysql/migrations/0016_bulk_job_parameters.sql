@@ -0,0 +1 @@
+ALTER TABLE bulk_file_jobs ADD COLUMN parameters TEXT NULL;
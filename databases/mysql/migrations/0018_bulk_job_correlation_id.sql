@@ -0,0 +1 @@
+ALTER TABLE bulk_file_jobs ADD COLUMN correlation_id VARCHAR(64) NULL;
@@ -0,0 +1 @@
+ALTER TABLE bulk_file_jobs ADD COLUMN all_or_nothing TINYINT(1) NOT NULL DEFAULT 0;
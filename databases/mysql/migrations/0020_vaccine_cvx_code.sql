@@ -0,0 +1,9 @@
+ALTER TABLE vaccines ADD COLUMN cvx_code VARCHAR(3) NOT NULL DEFAULT '' AFTER name;
+
+UPDATE vaccines SET cvx_code = '03' WHERE name = 'MMR' AND cvx_code = '';
+UPDATE vaccines SET cvx_code = '21' WHERE name = 'Varicella' AND cvx_code = '';
+UPDATE vaccines SET cvx_code = '10' WHERE name = 'Polio (IPV)' AND cvx_code = '';
+UPDATE vaccines SET cvx_code = '08' WHERE name = 'Hepatitis B' AND cvx_code = '';
+UPDATE vaccines SET cvx_code = '115' WHERE name = 'Tdap' AND cvx_code = '';
+UPDATE vaccines SET cvx_code = '165' WHERE name = 'HPV' AND cvx_code = '';
+UPDATE vaccines SET cvx_code = '114' WHERE name = 'MenACWY' AND cvx_code = '';
@@ -1,9 +1,12 @@
 package mysql
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"context"
+	"database/sql"
+	"log/slog"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/tracing"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jinzhu/gorm"
@@ -15,21 +18,67 @@ type MysqlConnect struct {
 
 var mysqlConnect *MysqlConnect
 
-func GetMySQLConnect() (*MysqlConnect, error) {
+func GetMySQLConnect(cfg config.DatabaseConfig, logger *slog.Logger) (*MysqlConnect, error) {
 	var err error
-	connection, err := gorm.Open("mysql", getConnectionString())
+	connection, err := gorm.Open("mysql", cfg.DSN())
 	if err != nil {
-		log.Println("Error connecting to MySQL", err.Error())
+		logger.Error("error connecting to mysql", logging.Err(err))
 		return mysqlConnect, err
 	}
+	tracing.InstrumentGorm()
 	mysqlConnect = &MysqlConnect{connection}
-	log.Println("MySQL is Connected")
+	logger.Info("mysql connected", "host", cfg.Host, "database", cfg.Name)
 	return mysqlConnect, err
 }
-func getConnectionString() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True", os.Getenv("DB_USER"), os.Getenv("DB_PASS"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
-}
-func Close() {
-	log.Println("Closing MySQL Connection")
+func Close(logger *slog.Logger) {
+	logger.Info("closing mysql connection")
 	mysqlConnect.Close()
 }
+
+// WithContext gives a handle on the same connection pool whose statements and transactions are cancelled
+// once ctx is done and whose queries join the trace in ctx. gorm v1 takes no context, so the handle
+// runs its statements through the context variants of database/sql instead. Inside a Transaction the handle
+// is the transaction's.
+func (m *MysqlConnect) WithContext(ctx context.Context) *gorm.DB {
+	if tx := transactionFrom(ctx); tx != nil {
+		return tracing.WithContext(ctx, tx.db)
+	}
+	db, err := gorm.Open("mysql", contextDB{ctx: ctx, db: m.DB.DB()})
+	if err != nil {
+		//only a source of an unknown type fails, keep the error on the handle like gorm does
+		db = m.DB.New()
+		db.AddError(err)
+	}
+	return tracing.WithContext(ctx, db)
+}
+
+// contextDB binds a context to every statement run on db
+type contextDB struct {
+	ctx context.Context
+	db  *sql.DB
+}
+
+func (c contextDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(c.ctx, query, args...)
+}
+
+func (c contextDB) Prepare(query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(c.ctx, query)
+}
+
+func (c contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c contextDB) Begin() (*sql.Tx, error) {
+	return c.db.BeginTx(c.ctx, nil)
+}
+
+// BeginTx ties the transaction to the handle's context, gorm's Begin passes context.Background
+func (c contextDB) BeginTx(_ context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return c.db.BeginTx(c.ctx, opts)
+}
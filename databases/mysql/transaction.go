@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+type transactionKey struct{}
+
+// transaction is the open transaction a context carries, with the work held back until it commits
+type transaction struct {
+	db          *gorm.DB
+	savepoints  int
+	afterCommit []func()
+}
+
+func transactionFrom(ctx context.Context) *transaction {
+	tx, _ := ctx.Value(transactionKey{}).(*transaction)
+	return tx
+}
+
+// Transaction runs fn in a transaction, every query made through WithContext with the context fn is given joins it.
+// The transaction commits when fn returns nil and rolls back when it returns an error or panics. Inside another
+// transaction fn runs under a savepoint instead, so its error undoes only what fn wrote and the caller decides
+// whether the outer transaction still commits.
+func (m *MysqlConnect) Transaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if tx := transactionFrom(ctx); tx != nil {
+		return tx.savepoint(ctx, fn)
+	}
+	db := m.WithContext(ctx).Begin()
+	if db.Error != nil {
+		return db.Error
+	}
+	tx := &transaction{db: db}
+	defer func() {
+		if p := recover(); p != nil {
+			db.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(context.WithValue(ctx, transactionKey{}, tx)); err != nil {
+		db.Rollback()
+		return err
+	}
+	if err = db.Commit().Error; err != nil {
+		return err
+	}
+	for _, hook := range tx.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+func (t *transaction) savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.savepoints++
+	name := fmt.Sprintf("sp_%d", t.savepoints)
+	if err := t.db.Exec("SAVEPOINT " + name).Error; err != nil {
+		return err
+	}
+	hooks := len(t.afterCommit)
+	if err := fn(ctx); err != nil {
+		t.afterCommit = t.afterCommit[:hooks]
+		if rollbackErr := t.db.Exec("ROLLBACK TO SAVEPOINT " + name).Error; rollbackErr != nil {
+			return fmt.Errorf("%s, and rolling back to the savepoint failed %s", err.Error(), rollbackErr.Error())
+		}
+		return err
+	}
+	return t.db.Exec("RELEASE SAVEPOINT " + name).Error
+}
+
+// InTransaction tells whether queries made with ctx run in a Transaction
+func InTransaction(ctx context.Context) bool {
+	return transactionFrom(ctx) != nil
+}
+
+// WithoutTransaction keeps ctx's deadline and values but runs its queries outside the transaction it carries,
+// for work done after that transaction has ended
+func WithoutTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transactionKey{}, (*transaction)(nil))
+}
+
+// AfterCommit holds fn back until the transaction in ctx commits and drops it if the transaction, or the savepoint
+// it was added under, rolls back. Without a transaction fn runs straight away. Messages other services act on go
+// through here so they never point at rows that were not committed.
+func AfterCommit(ctx context.Context, fn func()) {
+	tx := transactionFrom(ctx)
+	if tx == nil {
+		fn()
+		return
+	}
+	tx.afterCommit = append(tx.afterCommit, fn)
+}
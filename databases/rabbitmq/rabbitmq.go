@@ -1,30 +1,68 @@
 package rabbitmq
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"errors"
+	"log/slog"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"sync"
 
 	"github.com/streadway/amqp"
 )
 
-// type Rabbit {
-
-// }
 type RabbitChannel struct {
 	*amqp.Channel
+	conn *amqp.Connection
+
+	mu          sync.Mutex
+	closeReason error
 }
 
-func GetRabbitConn() *RabbitChannel {
-	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s", os.Getenv("RABBIT_USER"), os.Getenv("RABBIT_PASS"), os.Getenv("RABBIT_HOST"), os.Getenv("RABBIT_PORT")))
+func GetRabbitConn(cfg config.RabbitConfig, logger *slog.Logger) (*RabbitChannel, error) {
+	conn, err := amqp.Dial(cfg.URL())
 	if err != nil {
-		log.Println("Unable to connect to rabbitmq ", err.Error())
-		os.Exit(1)
+		logger.Error("unable to connect to rabbitmq", logging.Err(err))
+		return nil, err
 	}
 	rabChannel, err := conn.Channel()
 	if err != nil {
-		log.Println("Unable to create channel to rabbitmq ", err.Error())
-		os.Exit(1)
+		logger.Error("unable to create rabbitmq channel", logging.Err(err))
+		conn.Close()
+		return nil, err
+	}
+	rabbit := &RabbitChannel{Channel: rabChannel, conn: conn}
+	closed := rabChannel.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		//amqp sends the reason when the broker closes the channel, and just closes the notify channel on a clean close
+		reason := errors.New("channel closed")
+		if amqpErr, ok := <-closed; ok && amqpErr != nil {
+			reason = amqpErr
+		}
+		logger.Warn("rabbitmq channel closed", logging.Err(reason))
+		rabbit.mu.Lock()
+		rabbit.closeReason = reason
+		rabbit.mu.Unlock()
+	}()
+	return rabbit, nil
+}
+
+// Ping reports whether the connection and the channel are still usable, amqp has no round trip for it
+func (r *RabbitChannel) Ping() error {
+	if r.conn.IsClosed() {
+		return errors.New("connection closed")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeReason
+}
+
+// Inspect reports the ready messages and consumers of queue, on a channel of its own since the broker
+// closes the channel it is asked on when the queue does not exist
+func (r *RabbitChannel) Inspect(queue string) (amqp.Queue, error) {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return amqp.Queue{}, err
 	}
-	return &RabbitChannel{rabChannel}
+	defer ch.Close()
+	return ch.QueueInspect(queue)
 }
@@ -0,0 +1,141 @@
+package fhir
+
+import (
+	"fmt"
+	"school_vaccination_portal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	STUDENT_SYSTEM     = "urn:school-vaccination-portal:student"
+	ROLL_NUMBER_SYSTEM = "urn:school-vaccination-portal:roll-number"
+	RECORD_SYSTEM      = "urn:school-vaccination-portal:vaccination-record"
+	VACCINE_SYSTEM     = "urn:school-vaccination-portal:vaccine"
+	DRIVE_SYSTEM       = "urn:school-vaccination-portal:drive"
+)
+
+// PatientFromStudent leaves out the student's phone number, it belongs to no known person
+func PatientFromStudent(student models.StudentManagement) Patient {
+	patient := Patient{
+		ResourceType: RESOURCE_PATIENT,
+		Id:           strconv.Itoa(student.Id),
+		Identifier: []Identifier{
+			{System: STUDENT_SYSTEM, Value: strconv.Itoa(student.Id)},
+		},
+		Active: true,
+		Name:   []HumanName{humanName(student.Name)},
+		Gender: patientGender(student.Gender),
+	}
+	if student.RollNumber != "" {
+		patient.Identifier = append(patient.Identifier, Identifier{System: ROLL_NUMBER_SYSTEM, Value: fmt.Sprintf("%s-%s", student.Class, student.RollNumber)})
+	}
+	if student.UpdatedAt != nil {
+		patient.Meta = &Meta{LastUpdated: student.UpdatedAt.Format(time.RFC3339)}
+	}
+	return patient
+}
+
+// ImmunizationFromRecord maps one vaccination record with its drive, vaccine is the catalog entry when the record has one.
+// A voided record is exported as entered-in-error so receivers can retract what they imported before.
+func ImmunizationFromRecord(record models.StudentVaccineRecord, drive models.VaccineInventory, vaccine *models.Vaccine) Immunization {
+	occurrence := drive.DriveDate
+	if record.AdministeredAt != nil {
+		occurrence = *record.AdministeredAt
+	}
+	vaccineName := drive.VaccineName
+	if vaccine != nil {
+		vaccineName = vaccine.Name
+	}
+	immunization := Immunization{
+		ResourceType: RESOURCE_IMMUNIZATION,
+		Id:           strconv.Itoa(record.Id),
+		Identifier: []Identifier{
+			{System: RECORD_SYSTEM, Value: strconv.Itoa(record.Id)},
+		},
+		Status: IMMUNIZATION_COMPLETED,
+		VaccineCode: CodeableConcept{
+			Coding: []Coding{{System: VACCINE_SYSTEM, Code: strconv.Itoa(drive.VaccineId), Display: vaccineName}},
+			Text:   vaccineName,
+		},
+		Patient:            Reference{Reference: fmt.Sprintf("%s/%d", RESOURCE_PATIENT, record.StudentId)},
+		OccurrenceDateTime: occurrence.Format(time.RFC3339),
+		PrimarySource:      true,
+		Location:           &Reference{Display: fmt.Sprintf("Vaccination drive %d on %s", drive.Id, drive.DriveDate.Format("2006-01-02"))},
+		LotNumber:          record.LotNumber,
+		ProtocolApplied:    []ImmunizationProtocolApplied{{DoseNumberPositiveInt: record.DoseNumber}},
+	}
+	immunization.Identifier = append(immunization.Identifier, Identifier{System: DRIVE_SYSTEM, Value: strconv.Itoa(drive.Id)})
+	if record.Status == models.RECORD_VOIDED {
+		immunization.Status = IMMUNIZATION_ENTERED_IN_ERROR
+		if record.StatusReason != "" {
+			immunization.StatusReason = &CodeableConcept{Text: record.StatusReason}
+		}
+	}
+	if record.CreatedAt != nil {
+		immunization.Recorded = record.CreatedAt.Format(time.RFC3339)
+	}
+	if record.ExpiryDate != nil {
+		immunization.ExpirationDate = record.ExpiryDate.Format("2006-01-02")
+	}
+	if record.InjectionSite != "" {
+		immunization.Site = &CodeableConcept{Text: record.InjectionSite}
+	}
+	if record.AdministeredBy != "" {
+		immunization.Performer = []ImmunizationPerformer{{Actor: Reference{Display: record.AdministeredBy}}}
+	}
+	if vaccine != nil {
+		if vaccine.Manufacturer != "" {
+			immunization.Manufacturer = &Reference{Display: vaccine.Manufacturer}
+		}
+		immunization.ProtocolApplied[0].Series = vaccine.Name
+		immunization.ProtocolApplied[0].SeriesDosesPositiveInt = vaccine.DosesInSeries
+	}
+	return immunization
+}
+
+// NewBundle collects the resources into a collection bundle, patients first, baseUrl prefixes the entry fullUrls
+func NewBundle(baseUrl, id string, patients []Patient, immunizations []Immunization) Bundle {
+	baseUrl = strings.TrimRight(baseUrl, "/")
+	bundle := Bundle{
+		ResourceType: RESOURCE_BUNDLE,
+		Id:           id,
+		Type:         BUNDLE_TYPE_COLLECTION,
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Entry:        []BundleEntry{},
+	}
+	for _, patient := range patients {
+		bundle.Entry = append(bundle.Entry, BundleEntry{FullUrl: fmt.Sprintf("%s/%s/%s", baseUrl, RESOURCE_PATIENT, patient.Id), Resource: patient})
+	}
+	for _, immunization := range immunizations {
+		bundle.Entry = append(bundle.Entry, BundleEntry{FullUrl: fmt.Sprintf("%s/%s/%s", baseUrl, RESOURCE_IMMUNIZATION, immunization.Id), Resource: immunization})
+	}
+	return bundle
+}
+
+// students carry a single full name, the last word is taken as the family name
+func humanName(name string) HumanName {
+	human := HumanName{Use: "official", Text: strings.TrimSpace(name)}
+	parts := strings.Fields(name)
+	if len(parts) > 1 {
+		human.Family = parts[len(parts)-1]
+		human.Given = parts[:len(parts)-1]
+	} else if len(parts) == 1 {
+		human.Given = parts
+	}
+	return human
+}
+
+// gender is free text in the portal, anything not recognisable is exported as unknown
+func patientGender(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "m", "male", "boy":
+		return "male"
+	case "f", "female", "girl":
+		return "female"
+	case "o", "other":
+		return "other"
+	}
+	return "unknown"
+}
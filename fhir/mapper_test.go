@@ -0,0 +1,212 @@
+package fhir
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"school_vaccination_portal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testStudent() models.StudentManagement {
+	updated := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	return models.StudentManagement{
+		Id:         7,
+		Name:       "Asha Devi Rao",
+		Class:      "5",
+		Gender:     "F",
+		RollNumber: "12",
+		PhoneNo:    "9876543210",
+		UpdatedAt:  &updated,
+	}
+}
+
+func testDrive() models.VaccineInventory {
+	return models.VaccineInventory{
+		Id:          3,
+		VaccineId:   2,
+		VaccineName: "MMR",
+		DriveDate:   time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func testRecords() []models.StudentVaccineRecord {
+	administered := time.Date(2025, 2, 10, 10, 15, 0, 0, time.UTC)
+	expiry := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	return []models.StudentVaccineRecord{
+		{
+			Id:             41,
+			StudentId:      7,
+			DriveId:        3,
+			VaccineId:      2,
+			DoseNumber:     1,
+			AdministeredAt: &administered,
+			LotNumber:      "MMR-2025-A",
+			ExpiryDate:     &expiry,
+			InjectionSite:  "left arm",
+			AdministeredBy: "Nurse Iyer",
+			Status:         models.RECORD_ACTIVE,
+			CreatedAt:      &administered,
+		},
+		{
+			Id:           42,
+			StudentId:    7,
+			DriveId:      3,
+			VaccineId:    2,
+			DoseNumber:   2,
+			LotNumber:    "MMR-2025-A",
+			Status:       models.RECORD_VOIDED,
+			StatusReason: "recorded against the wrong student",
+		},
+	}
+}
+
+func testImmunizations() []Immunization {
+	vaccine := &models.Vaccine{Id: 2, Name: "MMR", Manufacturer: "Serum Institute", DosesInSeries: 2}
+	immunizations := []Immunization{}
+	for _, record := range testRecords() {
+		immunizations = append(immunizations, ImmunizationFromRecord(record, testDrive(), vaccine))
+	}
+	return immunizations
+}
+
+func TestPatientFromStudent(t *testing.T) {
+	patient := PatientFromStudent(testStudent())
+	if patient.Id != "7" || patient.Gender != "female" || !patient.Active {
+		t.Fatalf("unexpected patient %+v", patient)
+	}
+	name := patient.Name[0]
+	if name.Family != "Rao" || strings.Join(name.Given, " ") != "Asha Devi" || name.Text != "Asha Devi Rao" {
+		t.Errorf("unexpected name %+v", name)
+	}
+	if len(patient.Identifier) != 2 || patient.Identifier[1].System != ROLL_NUMBER_SYSTEM || patient.Identifier[1].Value != "5-12" {
+		t.Errorf("unexpected identifiers %+v", patient.Identifier)
+	}
+	if patient.Meta == nil || patient.Meta.LastUpdated != "2025-03-01T09:30:00Z" {
+		t.Errorf("unexpected meta %+v", patient.Meta)
+	}
+	//the number on the student record has no known owner and is not exported
+	if raw, _ := json.Marshal(patient); strings.Contains(string(raw), "9876543210") {
+		t.Errorf("patient carries the student's phone number %s", raw)
+	}
+}
+
+func TestImmunizationFromRecord(t *testing.T) {
+	immunizations := testImmunizations()
+
+	given := immunizations[0]
+	if given.Status != IMMUNIZATION_COMPLETED || given.StatusReason != nil {
+		t.Errorf("active record status %s, reason %+v", given.Status, given.StatusReason)
+	}
+	if given.Patient.Reference != "Patient/7" {
+		t.Errorf("patient reference %s", given.Patient.Reference)
+	}
+	if given.OccurrenceDateTime != "2025-02-10T10:15:00Z" {
+		t.Errorf("occurrence %s is not the administration time", given.OccurrenceDateTime)
+	}
+	if given.VaccineCode.Coding[0].Code != "2" || given.VaccineCode.Text != "MMR" {
+		t.Errorf("unexpected vaccine code %+v", given.VaccineCode)
+	}
+	if given.LotNumber != "MMR-2025-A" || given.ExpirationDate != "2026-01-31" {
+		t.Errorf("lot %s expiring %s", given.LotNumber, given.ExpirationDate)
+	}
+	if given.Manufacturer == nil || given.Manufacturer.Display != "Serum Institute" {
+		t.Errorf("unexpected manufacturer %+v", given.Manufacturer)
+	}
+	protocol := given.ProtocolApplied[0]
+	if protocol.DoseNumberPositiveInt != 1 || protocol.SeriesDosesPositiveInt != 2 || protocol.Series != "MMR" {
+		t.Errorf("unexpected protocol %+v", protocol)
+	}
+
+	voided := immunizations[1]
+	if voided.Status != IMMUNIZATION_ENTERED_IN_ERROR {
+		t.Errorf("voided record exported as %s", voided.Status)
+	}
+	if voided.StatusReason == nil || voided.StatusReason.Text != "recorded against the wrong student" {
+		t.Errorf("unexpected status reason %+v", voided.StatusReason)
+	}
+	if voided.OccurrenceDateTime != "2025-02-10T00:00:00Z" {
+		t.Errorf("record without administration time occurs at %s, not the drive date", voided.OccurrenceDateTime)
+	}
+}
+
+func TestBundleValidates(t *testing.T) {
+	bundle := NewBundle("https://portal.example/fhir/", "export-1", []Patient{PatientFromStudent(testStudent())}, testImmunizations())
+	if len(bundle.Entry) != 3 {
+		t.Fatalf("bundle has %d entries", len(bundle.Entry))
+	}
+	if bundle.Entry[0].FullUrl != "https://portal.example/fhir/Patient/7" || bundle.Entry[2].FullUrl != "https://portal.example/fhir/Immunization/42" {
+		t.Errorf("unexpected full urls %s, %s", bundle.Entry[0].FullUrl, bundle.Entry[2].FullUrl)
+	}
+	raw, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Validate(raw); err != nil {
+		t.Errorf("bundle does not validate %s", err.Error())
+	}
+}
+
+func TestWriteNDJSONValidates(t *testing.T) {
+	resources := []interface{}{PatientFromStudent(testStudent())}
+	for _, immunization := range testImmunizations() {
+		resources = append(resources, immunization)
+	}
+	var out bytes.Buffer
+	if err := WriteNDJSON(&out, resources); err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		lines++
+		if err := Validate(scanner.Bytes()); err != nil {
+			t.Errorf("line %d does not validate %s", lines, err.Error())
+		}
+	}
+	if lines != len(resources) {
+		t.Errorf("wrote %d lines for %d resources", lines, len(resources))
+	}
+}
+
+func TestWriteNDJSONRejectsInvalidResource(t *testing.T) {
+	immunization := testImmunizations()[0]
+	immunization.ProtocolApplied[0].DoseNumberPositiveInt = 0
+	var out bytes.Buffer
+	err := WriteNDJSON(&out, []interface{}{PatientFromStudent(testStudent()), immunization})
+	if err == nil || !strings.Contains(err.Error(), "doseNumberPositiveInt") {
+		t.Fatalf("expected a dose number error, got %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("the invalid resource was written %q", out.String())
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	cases := []struct {
+		name     string
+		resource string
+		message  string
+	}{
+		{"not an object", `[]`, "not a JSON object"},
+		{"no resource type", `{"id":"1"}`, "resourceType is required"},
+		{"unsupported type", `{"resourceType":"Observation","id":"1"}`, "unsupported resourceType"},
+		{"bad id", `{"resourceType":"Patient","id":"7 7"}`, "id has invalid format"},
+		{"bad gender", `{"resourceType":"Patient","id":"7","gender":"F"}`, "Patient.gender"},
+		{"bad status", `{"resourceType":"Immunization","id":"1","status":"voided","vaccineCode":{"text":"MMR"},"patient":{"reference":"Patient/7"},"occurrenceDateTime":"2025-02-10"}`, "Immunization.status"},
+		{"no occurrence", `{"resourceType":"Immunization","id":"1","status":"completed","vaccineCode":{"text":"MMR"},"patient":{"reference":"Patient/7"}}`, "occurrenceDateTime or occurrenceString"},
+		{"bad occurrence", `{"resourceType":"Immunization","id":"1","status":"completed","vaccineCode":{"text":"MMR"},"patient":{"reference":"Patient/7"},"occurrenceDateTime":"10/02/2025"}`, "occurrenceDateTime has invalid format"},
+		{"total on a collection", `{"resourceType":"Bundle","type":"collection","total":1,"entry":[]}`, "Bundle.total"},
+		{"invalid entry", `{"resourceType":"Bundle","type":"collection","entry":[{"fullUrl":"Patient/7","resource":{"resourceType":"Patient","gender":"boy"}}]}`, "Bundle.entry[0]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Validate([]byte(c.resource))
+			if err == nil || !strings.Contains(err.Error(), c.message) {
+				t.Errorf("expected an error containing %q, got %v", c.message, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package fhir
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// WriteNDJSON validates and writes each resource on its own line, as the bulk data format expects
+func WriteNDJSON(w io.Writer, resources []interface{}) error {
+	for _, resource := range resources {
+		line, err := json.Marshal(resource)
+		if err != nil {
+			return err
+		}
+		if err = Validate(line); err != nil {
+			return err
+		}
+		if _, err = w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package fhir maps students and their vaccination records to FHIR R4 Patient and Immunization resources.
+// Only the elements the portal has data for are modelled.
+package fhir
+
+const (
+	RESOURCE_PATIENT      = "Patient"
+	RESOURCE_IMMUNIZATION = "Immunization"
+	RESOURCE_BUNDLE       = "Bundle"
+
+	BUNDLE_TYPE_COLLECTION = "collection"
+
+	IMMUNIZATION_COMPLETED        = "completed"
+	IMMUNIZATION_ENTERED_IN_ERROR = "entered-in-error"
+
+	CONTENT_TYPE        = "application/fhir+json"
+	NDJSON_CONTENT_TYPE = "application/fhir+ndjson"
+)
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Identifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Patient struct {
+	ResourceType string       `json:"resourceType"`
+	Id           string       `json:"id"`
+	Meta         *Meta        `json:"meta,omitempty"`
+	Identifier   []Identifier `json:"identifier,omitempty"`
+	Active       bool         `json:"active"`
+	Name         []HumanName  `json:"name,omitempty"`
+	Gender       string       `json:"gender,omitempty"`
+}
+
+type ImmunizationPerformer struct {
+	Actor Reference `json:"actor"`
+}
+
+type ImmunizationProtocolApplied struct {
+	Series                 string `json:"series,omitempty"`
+	DoseNumberPositiveInt  int    `json:"doseNumberPositiveInt"`
+	SeriesDosesPositiveInt int    `json:"seriesDosesPositiveInt,omitempty"`
+}
+
+type Immunization struct {
+	ResourceType       string                        `json:"resourceType"`
+	Id                 string                        `json:"id"`
+	Meta               *Meta                         `json:"meta,omitempty"`
+	Identifier         []Identifier                  `json:"identifier,omitempty"`
+	Status             string                        `json:"status"`
+	StatusReason       *CodeableConcept              `json:"statusReason,omitempty"`
+	VaccineCode        CodeableConcept               `json:"vaccineCode"`
+	Patient            Reference                     `json:"patient"`
+	OccurrenceDateTime string                        `json:"occurrenceDateTime"`
+	Recorded           string                        `json:"recorded,omitempty"`
+	PrimarySource      bool                          `json:"primarySource"`
+	Location           *Reference                    `json:"location,omitempty"`
+	Manufacturer       *Reference                    `json:"manufacturer,omitempty"`
+	LotNumber          string                        `json:"lotNumber,omitempty"`
+	ExpirationDate     string                        `json:"expirationDate,omitempty"`
+	Site               *CodeableConcept              `json:"site,omitempty"`
+	Performer          []ImmunizationPerformer       `json:"performer,omitempty"`
+	ProtocolApplied    []ImmunizationProtocolApplied `json:"protocolApplied,omitempty"`
+}
+
+type BundleEntry struct {
+	FullUrl  string      `json:"fullUrl"`
+	Resource interface{} `json:"resource"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Id           string        `json:"id"`
+	Meta         *Meta         `json:"meta,omitempty"`
+	Type         string        `json:"type"`
+	Timestamp    string        `json:"timestamp"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+// BulkManifest follows the FHIR bulk data export manifest, one output entry per NDJSON file
+type BulkManifest struct {
+	TransactionTime     string           `json:"transactionTime"`
+	Request             string           `json:"request"`
+	RequiresAccessToken bool             `json:"requiresAccessToken"`
+	Output              []BulkFileOutput `json:"output"`
+	Error               []BulkFileOutput `json:"error"`
+}
+
+type BulkFileOutput struct {
+	Type  string `json:"type"`
+	Url   string `json:"url"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,234 @@
+package fhir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// patterns from the R4 JSON schema (fhir.schema.json) for the primitive types used here
+var (
+	idPattern       = regexp.MustCompile(`^[A-Za-z0-9\-\.]{1,64}$`)
+	datePattern     = regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)(-(0[1-9]|1[0-2])(-(0[1-9]|[1-2][0-9]|3[0-1]))?)?$`)
+	dateTimePattern = regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)(-(0[1-9]|1[0-2])(-(0[1-9]|[1-2][0-9]|3[0-1])(T([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?(Z|(\+|-)((0[0-9]|1[0-3]):[0-5][0-9]|14:00)))?)?)?$`)
+	instantPattern  = regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)-(0[1-9]|1[0-2])-(0[1-9]|[1-2][0-9]|3[0-1])T([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?(Z|(\+|-)((0[0-9]|1[0-3]):[0-5][0-9]|14:00))$`)
+
+	patientGenders      = []string{"male", "female", "other", "unknown"}
+	immunizationStatus  = []string{"completed", "entered-in-error", "not-done"}
+	bundleTypes         = []string{"document", "message", "transaction", "transaction-response", "batch", "batch-response", "history", "searchset", "collection"}
+	contactPointSystems = []string{"phone", "fax", "email", "pager", "url", "sms", "other"}
+)
+
+// Validate checks an encoded resource against the R4 JSON structure of the resources this package emits:
+// required elements, primitive formats and required value sets. Unknown resource types are rejected.
+func Validate(raw []byte) error {
+	resource := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &resource); err != nil {
+		return fmt.Errorf("resource is not a JSON object %s", err.Error())
+	}
+	return validateResource(resource)
+}
+
+func validateResource(resource map[string]interface{}) error {
+	resourceType, _ := resource["resourceType"].(string)
+	if err := optionalPattern(resource, "id", idPattern); err != nil {
+		return err
+	}
+	if meta, ok := resource["meta"].(map[string]interface{}); ok {
+		if err := optionalPattern(meta, "lastUpdated", instantPattern); err != nil {
+			return fmt.Errorf("meta.%s", err.Error())
+		}
+	}
+	switch resourceType {
+	case RESOURCE_PATIENT:
+		return validatePatient(resource)
+	case RESOURCE_IMMUNIZATION:
+		return validateImmunization(resource)
+	case RESOURCE_BUNDLE:
+		return validateBundle(resource)
+	case "":
+		return errors.New("resourceType is required")
+	}
+	return fmt.Errorf("unsupported resourceType %s", resourceType)
+}
+
+func validatePatient(patient map[string]interface{}) error {
+	if err := optionalCode(patient, "gender", patientGenders); err != nil {
+		return fmt.Errorf("Patient.%s", err.Error())
+	}
+	if _, ok := patient["active"]; ok {
+		if _, isBool := patient["active"].(bool); !isBool {
+			return errors.New("Patient.active must be a boolean")
+		}
+	}
+	if err := eachObject(patient, "identifier", func(identifier map[string]interface{}) error {
+		return requiredString(identifier, "value")
+	}); err != nil {
+		return fmt.Errorf("Patient.%s", err.Error())
+	}
+	if err := eachObject(patient, "name", func(name map[string]interface{}) error {
+		if _, ok := name["given"]; ok {
+			if _, isArray := name["given"].([]interface{}); !isArray {
+				return errors.New("given must be an array")
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("Patient.name.%s", err.Error())
+	}
+	if err := eachObject(patient, "telecom", func(telecom map[string]interface{}) error {
+		return optionalCode(telecom, "system", contactPointSystems)
+	}); err != nil {
+		return fmt.Errorf("Patient.telecom.%s", err.Error())
+	}
+	return nil
+}
+
+func validateImmunization(immunization map[string]interface{}) error {
+	if err := requiredCode(immunization, "status", immunizationStatus); err != nil {
+		return fmt.Errorf("Immunization.%s", err.Error())
+	}
+	vaccineCode, ok := immunization["vaccineCode"].(map[string]interface{})
+	if !ok {
+		return errors.New("Immunization.vaccineCode is required")
+	}
+	if _, hasText := vaccineCode["text"]; !hasText {
+		if codings, _ := vaccineCode["coding"].([]interface{}); len(codings) == 0 {
+			return errors.New("Immunization.vaccineCode needs a coding or text")
+		}
+	}
+	patient, ok := immunization["patient"].(map[string]interface{})
+	if !ok {
+		return errors.New("Immunization.patient is required")
+	}
+	if err := requiredString(patient, "reference"); err != nil {
+		return fmt.Errorf("Immunization.patient.%s", err.Error())
+	}
+	_, hasDateTime := immunization["occurrenceDateTime"]
+	_, hasString := immunization["occurrenceString"]
+	if hasDateTime == hasString {
+		return errors.New("Immunization needs exactly one of occurrenceDateTime or occurrenceString")
+	}
+	if err := optionalPattern(immunization, "occurrenceDateTime", dateTimePattern); err != nil {
+		return fmt.Errorf("Immunization.%s", err.Error())
+	}
+	if err := optionalPattern(immunization, "recorded", dateTimePattern); err != nil {
+		return fmt.Errorf("Immunization.%s", err.Error())
+	}
+	if err := optionalPattern(immunization, "expirationDate", datePattern); err != nil {
+		return fmt.Errorf("Immunization.%s", err.Error())
+	}
+	if err := eachObject(immunization, "performer", func(performer map[string]interface{}) error {
+		if _, ok := performer["actor"].(map[string]interface{}); !ok {
+			return errors.New("actor is required")
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("Immunization.performer.%s", err.Error())
+	}
+	return eachObject(immunization, "protocolApplied", func(protocol map[string]interface{}) error {
+		_, hasInt := protocol["doseNumberPositiveInt"]
+		_, hasDoseString := protocol["doseNumberString"]
+		if hasInt == hasDoseString {
+			return errors.New("Immunization.protocolApplied needs exactly one of doseNumberPositiveInt or doseNumberString")
+		}
+		for _, field := range []string{"doseNumberPositiveInt", "seriesDosesPositiveInt"} {
+			if value, ok := protocol[field]; ok {
+				if number, isNumber := value.(float64); !isNumber || number < 1 || number != float64(int(number)) {
+					return fmt.Errorf("Immunization.protocolApplied.%s must be a positive integer", field)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func validateBundle(bundle map[string]interface{}) error {
+	if err := requiredCode(bundle, "type", bundleTypes); err != nil {
+		return fmt.Errorf("Bundle.%s", err.Error())
+	}
+	if err := optionalPattern(bundle, "timestamp", instantPattern); err != nil {
+		return fmt.Errorf("Bundle.%s", err.Error())
+	}
+	bundleType := bundle["type"].(string)
+	if _, hasTotal := bundle["total"]; hasTotal && bundleType != "searchset" && bundleType != "history" {
+		return errors.New("Bundle.total is only allowed on searchset and history bundles")
+	}
+	index := 0
+	return eachObject(bundle, "entry", func(entry map[string]interface{}) error {
+		index++
+		resource, ok := entry["resource"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Bundle.entry[%d].resource is required", index-1)
+		}
+		if err := requiredString(entry, "fullUrl"); err != nil {
+			return fmt.Errorf("Bundle.entry[%d].%s", index-1, err.Error())
+		}
+		if err := validateResource(resource); err != nil {
+			return fmt.Errorf("Bundle.entry[%d] %s", index-1, err.Error())
+		}
+		return nil
+	})
+}
+
+func requiredString(object map[string]interface{}, field string) error {
+	value, ok := object[field].(string)
+	if !ok || value == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	return nil
+}
+
+func requiredCode(object map[string]interface{}, field string, allowed []string) error {
+	if err := requiredString(object, field); err != nil {
+		return err
+	}
+	return optionalCode(object, field, allowed)
+}
+
+func optionalCode(object map[string]interface{}, field string, allowed []string) error {
+	value, ok := object[field]
+	if !ok {
+		return nil
+	}
+	for _, code := range allowed {
+		if value == code {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s has invalid code %v", field, value)
+}
+
+func optionalPattern(object map[string]interface{}, field string, pattern *regexp.Regexp) error {
+	value, ok := object[field]
+	if !ok {
+		return nil
+	}
+	if text, isString := value.(string); !isString || !pattern.MatchString(text) {
+		return fmt.Errorf("%s has invalid format %v", field, value)
+	}
+	return nil
+}
+
+// calls check for each object of an array element, a present element that is not an array of objects is an error
+func eachObject(object map[string]interface{}, field string, check func(map[string]interface{}) error) error {
+	value, ok := object[field]
+	if !ok {
+		return nil
+	}
+	items, isArray := value.([]interface{})
+	if !isArray {
+		return fmt.Errorf("%s must be an array", field)
+	}
+	for _, item := range items {
+		element, isObject := item.(map[string]interface{})
+		if !isObject {
+			return fmt.Errorf("%s must contain objects", field)
+		}
+		if err := check(element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
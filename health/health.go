@@ -0,0 +1,175 @@
+// Package health serves the liveness and readiness endpoints the orchestrator probes,
+// readiness pings every dependency the process needs with a timeout.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/databases/rabbitmq"
+	"school_vaccination_portal/logging"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	STATUS_UP   = "UP"
+	STATUS_DOWN = "DOWN"
+
+	LIVENESS_PATH  = "/healthz"
+	READINESS_PATH = "/readyz"
+)
+
+// Check reports why a dependency is unusable, it must give up once ctx is done
+type Check func(ctx context.Context) error
+
+// DependencyStatus is the outcome of one check
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
+type Checker struct {
+	timeout time.Duration
+	logger  *slog.Logger
+	names   []string
+	checks  map[string]Check
+}
+
+func NewChecker(timeout time.Duration, logger *slog.Logger) *Checker {
+	return &Checker{timeout: timeout, logger: logger, checks: map[string]Check{}}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+		sort.Strings(c.names)
+	}
+	c.checks[name] = check
+}
+
+// Ready runs every check at once, each bounded by the timeout, and is UP only when all of them are
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: STATUS_UP, Dependencies: map[string]DependencyStatus{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			status := run(ctx, check, c.timeout)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[name] = status
+			if status.Status != STATUS_UP {
+				report.Status = STATUS_DOWN
+			}
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+	return report
+}
+
+// a check that ignores ctx still cannot hold the probe past the timeout
+func run(ctx context.Context, check Check, timeout time.Duration) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	status := DependencyStatus{Status: STATUS_UP, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		status.Status = STATUS_DOWN
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (c *Checker) Liveness(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, Report{Status: STATUS_UP})
+}
+
+func (c *Checker) Readiness(ctx echo.Context) error {
+	report := c.Ready(ctx.Request().Context())
+	if report.Status != STATUS_UP {
+		return ctx.JSON(http.StatusServiceUnavailable, report)
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// Register mounts /healthz and /readyz on e
+func (c *Checker) Register(e *echo.Echo) {
+	e.GET(LIVENESS_PATH, c.Liveness)
+	e.GET(READINESS_PATH, c.Readiness)
+}
+
+// Serve runs the endpoints on their own listener, for processes without an HTTP API,
+// mounts add whatever else the process exposes there
+func (c *Checker) Serve(address string, mounts ...func(e *echo.Echo)) {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	c.Register(e)
+	for _, mount := range mounts {
+		mount(e)
+	}
+	go func() {
+		c.logger.Info("health endpoints listening", "address", address)
+		if err := e.Start(address); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			c.logger.Error("health server stopped", logging.Err(err))
+		}
+	}()
+}
+
+// IsProbe tells the request logger to skip the endpoints the orchestrator polls
+func IsProbe(c echo.Context) bool {
+	path := c.Request().URL.Path
+	return path == LIVENESS_PATH || path == READINESS_PATH
+}
+
+func MySQL(db *mysql.MysqlConnect) Check {
+	return func(ctx context.Context) error {
+		return db.DB.DB().PingContext(ctx)
+	}
+}
+
+func RabbitMQ(rabbit *rabbitmq.RabbitChannel) Check {
+	return func(ctx context.Context) error {
+		return rabbit.Ping()
+	}
+}
+
+// MinIO checks the bucket the portal stores files in, which also proves the credentials work
+func MinIO(client *minio.Client, bucket string) Check {
+	return func(ctx context.Context) error {
+		exists, err := client.BucketExists(ctx, bucket)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("bucket %s does not exist", bucket)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+// Package hl7 builds and parses HL7 v2.5.1 VXU^V04 immunization messages and FHS/BHS batch files.
+package hl7
+
+import "strings"
+
+const (
+	FIELD_SEPARATOR     = "|"
+	COMPONENT_SEPARATOR = "^"
+	REPETITION          = "~"
+	ESCAPE              = "\\"
+	SUBCOMPONENT        = "&"
+	ENCODING_CHARACTERS = COMPONENT_SEPARATOR + REPETITION + ESCAPE + SUBCOMPONENT
+	SEGMENT_TERMINATOR  = "\r"
+
+	VERSION         = "2.5.1"
+	DATE_FORMAT     = "20060102"
+	DATETIME_FORMAT = "20060102150405-0700"
+)
+
+var (
+	escaper = strings.NewReplacer(
+		ESCAPE, `\E\`,
+		FIELD_SEPARATOR, `\F\`,
+		COMPONENT_SEPARATOR, `\S\`,
+		SUBCOMPONENT, `\T\`,
+		REPETITION, `\R\`,
+		"\r", " ",
+		"\n", " ",
+	)
+	unescaper = strings.NewReplacer(
+		`\E\`, ESCAPE,
+		`\F\`, FIELD_SEPARATOR,
+		`\S\`, COMPONENT_SEPARATOR,
+		`\T\`, SUBCOMPONENT,
+		`\R\`, REPETITION,
+	)
+)
+
+// Escape makes a value safe to place inside a component
+func Escape(value string) string {
+	return escaper.Replace(value)
+}
+
+func Unescape(value string) string {
+	return unescaper.Replace(value)
+}
+
+// Components escapes each value and joins them into one field, trailing empty components are dropped
+func Components(values ...string) string {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = Escape(value)
+	}
+	return strings.TrimRight(strings.Join(escaped, COMPONENT_SEPARATOR), COMPONENT_SEPARATOR)
+}
+
+// segment joins already encoded fields, fields[0] being field 1, trailing empty fields are dropped
+func segment(name string, fields ...string) string {
+	return strings.TrimRight(name+FIELD_SEPARATOR+strings.Join(fields, FIELD_SEPARATOR), FIELD_SEPARATOR)
+}
@@ -0,0 +1,123 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment is a parsed segment, Fields[0] is the segment name so Fields[n] is field n.
+// For MSH, FHS and BHS, field 1 is the field separator as in the standard.
+type Segment struct {
+	Fields []string
+}
+
+type Message struct {
+	Segments []Segment
+}
+
+func (s Segment) Name() string {
+	return s.Fields[0]
+}
+
+// Field returns field n still encoded, empty when absent
+func (s Segment) Field(n int) string {
+	if n < len(s.Fields) {
+		return s.Fields[n]
+	}
+	return ""
+}
+
+// Component returns component c (1 based) of field n, unescaped
+func (s Segment) Component(n, c int) string {
+	components := strings.Split(s.Field(n), COMPONENT_SEPARATOR)
+	if c-1 < len(components) {
+		return Unescape(components[c-1])
+	}
+	return ""
+}
+
+// SegmentsNamed lists the message segments with the given name in order
+func (m Message) SegmentsNamed(name string) []Segment {
+	found := []Segment{}
+	for _, segment := range m.Segments {
+		if segment.Name() == name {
+			found = append(found, segment)
+		}
+	}
+	return found
+}
+
+// ParseSegment splits one segment, re-inserting the field separator as field 1 of header segments
+func ParseSegment(raw string) (Segment, error) {
+	if len(raw) < 3 {
+		return Segment{}, fmt.Errorf("segment %q is too short", raw)
+	}
+	fields := strings.Split(raw, FIELD_SEPARATOR)
+	switch fields[0] {
+	case "MSH", "FHS", "BHS":
+		if len(fields) < 2 || fields[1] != ENCODING_CHARACTERS {
+			return Segment{}, fmt.Errorf("%s segment has unsupported encoding characters", fields[0])
+		}
+		fields = append([]string{fields[0], FIELD_SEPARATOR}, fields[1:]...)
+	}
+	return Segment{Fields: fields}, nil
+}
+
+// ParseMessage parses a single message, which must start with MSH
+func ParseMessage(raw string) (Message, error) {
+	message := Message{}
+	for _, line := range splitSegments(raw) {
+		segment, err := ParseSegment(line)
+		if err != nil {
+			return message, err
+		}
+		message.Segments = append(message.Segments, segment)
+	}
+	if len(message.Segments) == 0 || message.Segments[0].Name() != "MSH" {
+		return message, errors.New("message must start with an MSH segment")
+	}
+	return message, nil
+}
+
+// ParseBatch parses an FHS/BHS batch file, or a bare run of messages, checking BTS-1 against the messages found
+func ParseBatch(raw string) ([]Message, error) {
+	messages := []Message{}
+	declared := -1
+	for _, line := range splitSegments(raw) {
+		segment, err := ParseSegment(line)
+		if err != nil {
+			return messages, err
+		}
+		switch segment.Name() {
+		case "FHS", "BHS", "FTS":
+		case "BTS":
+			if declared, err = strconv.Atoi(segment.Field(1)); err != nil {
+				return messages, errors.New("BTS-1 is not a message count")
+			}
+		case "MSH":
+			messages = append(messages, Message{Segments: []Segment{segment}})
+		default:
+			if len(messages) == 0 {
+				return messages, fmt.Errorf("%s segment found before any MSH", segment.Name())
+			}
+			messages[len(messages)-1].Segments = append(messages[len(messages)-1].Segments, segment)
+		}
+	}
+	if declared != -1 && declared != len(messages) {
+		return messages, fmt.Errorf("BTS declares %d messages but the batch holds %d", declared, len(messages))
+	}
+	return messages, nil
+}
+
+// segments end in CR, LF and CRLF are accepted too since files are often rewritten by other tools
+func splitSegments(raw string) []string {
+	lines := []string{}
+	for _, line := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,180 @@
+package hl7
+
+import (
+	"fmt"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header identifies sender and receiver in MSH, BHS and FHS segments
+type Header struct {
+	SendingApplication   string
+	SendingFacility      string
+	ReceivingApplication string
+	ReceivingFacility    string
+	ProcessingId         string
+}
+
+// NewHeader takes sender and receiver from the HL7 configuration
+func NewHeader(cfg config.HL7Config) Header {
+	return Header{
+		SendingApplication:   cfg.SendingApplication,
+		SendingFacility:      cfg.SendingFacility,
+		ReceivingApplication: cfg.ReceivingApplication,
+		ReceivingFacility:    cfg.ReceivingFacility,
+		ProcessingId:         cfg.ProcessingId,
+	}
+}
+
+// Dose is one RXA, a voided record is sent with action code D so the registry removes what it holds.
+// Registries read the vaccine as a CVX code, the catalog id is sent as a local code only when it has none.
+type Dose struct {
+	RecordId       int
+	CvxCode        string
+	VaccineCode    string
+	VaccineName    string
+	AdministeredAt time.Time
+	LotNumber      string
+	ExpiryDate     *time.Time
+	Manufacturer   string
+	AdministeredBy string
+	Voided         bool
+}
+
+// BuildVXU returns one VXU^V04 message for the student with an ORC/RXA pair per dose
+func BuildVXU(header Header, controlId string, student models.StudentManagement, doses []Dose, now time.Time) string {
+	segments := []string{
+		msh(header, controlId, now),
+		segment("PID",
+			"1",
+			"",
+			Components(strconv.Itoa(student.Id), "", "", header.SendingApplication, "MR"),
+			"",
+			personName(student.Name),
+			"",
+			"",
+			administrativeSex(student.Gender),
+			"", "", "", "",
+			phone(student.PhoneNo),
+		),
+	}
+	for _, dose := range doses {
+		action := "A"
+		if dose.Voided {
+			action = "D"
+		}
+		expiry := ""
+		if dose.ExpiryDate != nil {
+			expiry = dose.ExpiryDate.Format(DATE_FORMAT)
+		}
+		manufacturer := ""
+		if dose.Manufacturer != "" {
+			manufacturer = Components("", dose.Manufacturer)
+		}
+		vaccine := Components(dose.VaccineCode, dose.VaccineName, "L")
+		if dose.CvxCode != "" {
+			vaccine = Components(dose.CvxCode, dose.VaccineName, "CVX")
+		}
+		administeredBy := ""
+		if dose.AdministeredBy != "" {
+			administeredBy = Components("", dose.AdministeredBy)
+		}
+		segments = append(segments,
+			segment("ORC", "RE", "", Components(strconv.Itoa(dose.RecordId), header.SendingApplication)),
+			segment("RXA",
+				"0",
+				"1",
+				dose.AdministeredAt.Format(DATETIME_FORMAT),
+				"",
+				vaccine,
+				"999",
+				"",
+				"",
+				Components("00", "New immunization record", "NIP001"),
+				administeredBy,
+				Components("", "", "", header.SendingFacility),
+				"", "", "",
+				Escape(dose.LotNumber),
+				expiry,
+				manufacturer,
+				"", "",
+				"CP",
+				action,
+			),
+		)
+	}
+	return strings.Join(segments, SEGMENT_TERMINATOR) + SEGMENT_TERMINATOR
+}
+
+// BuildBatch wraps the messages in FHS/BHS ... BTS/FTS with the message count in BTS-1
+func BuildBatch(header Header, batchControlId string, messages []string, now time.Time) string {
+	timestamp := now.Format(DATETIME_FORMAT)
+	senders := []string{Escape(header.SendingApplication), Escape(header.SendingFacility), Escape(header.ReceivingApplication), Escape(header.ReceivingFacility), timestamp}
+	content := segment("FHS", append([]string{ENCODING_CHARACTERS}, senders...)...) + SEGMENT_TERMINATOR
+	content += segment("BHS", append(append([]string{ENCODING_CHARACTERS}, senders...), "", "", "", Escape(batchControlId))...) + SEGMENT_TERMINATOR
+	for _, message := range messages {
+		content += message
+	}
+	content += segment("BTS", strconv.Itoa(len(messages))) + SEGMENT_TERMINATOR
+	content += segment("FTS", "1") + SEGMENT_TERMINATOR
+	return content
+}
+
+// MSH-1 is the field separator itself, so the encoding characters are the first field written
+func msh(header Header, controlId string, now time.Time) string {
+	return segment("MSH",
+		ENCODING_CHARACTERS,
+		Escape(header.SendingApplication),
+		Escape(header.SendingFacility),
+		Escape(header.ReceivingApplication),
+		Escape(header.ReceivingFacility),
+		now.Format(DATETIME_FORMAT),
+		"",
+		Components("VXU", "V04", "VXU_V04"),
+		Escape(controlId),
+		Escape(header.ProcessingId),
+		VERSION,
+		"", "",
+		"ER",
+		"AL",
+		"", "", "", "",
+		Components("Z22", "CDCPHINVS"),
+	)
+}
+
+// students carry a single full name, the last word is taken as the family name
+func personName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return Components(strings.TrimSpace(name), "", "", "", "", "", "L")
+	}
+	return Components(parts[len(parts)-1], strings.Join(parts[:len(parts)-1], " "), "", "", "", "", "L")
+}
+
+func administrativeSex(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "m", "male", "boy":
+		return "M"
+	case "f", "female", "girl":
+		return "F"
+	case "o", "other":
+		return "O"
+	}
+	return "U"
+}
+
+// the portal keeps phone numbers unformatted, so they go in XTN-12
+func phone(number string) string {
+	if number == "" {
+		return ""
+	}
+	return Components("", "PRN", "PH", "", "", "", "", "", "", "", "", number)
+}
+
+// ControlId keeps message and batch control ids within the 20 characters MSH-10 allows
+func ControlId(now time.Time, sequence int) string {
+	return fmt.Sprintf("SVP%d%05d", now.Unix(), sequence%100000)
+}
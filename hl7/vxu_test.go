@@ -0,0 +1,195 @@
+package hl7
+
+import (
+	"school_vaccination_portal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2025, 3, 1, 14, 5, 30, 0, time.FixedZone("IST", 5*3600+1800))
+
+func testHeader() Header {
+	return Header{
+		SendingApplication:   "SVP",
+		SendingFacility:      "Green Valley | East^Wing",
+		ReceivingApplication: "IIS",
+		ReceivingFacility:    "STATE",
+		ProcessingId:         "P",
+	}
+}
+
+// a name holding every delimiter, the last word is the family name
+func testStudent() models.StudentManagement {
+	return models.StudentManagement{
+		Id:         7,
+		Name:       `Ana|Maria R^o~s\a O&Neil`,
+		Class:      "5",
+		Gender:     "girl",
+		RollNumber: "12",
+		PhoneNo:    "9876543210",
+	}
+}
+
+func testDoses() []Dose {
+	expiry := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	return []Dose{
+		{
+			RecordId:       41,
+			CvxCode:        "03",
+			VaccineCode:    "2",
+			VaccineName:    "MMR",
+			AdministeredAt: time.Date(2025, 2, 10, 10, 15, 0, 0, time.FixedZone("IST", 5*3600+1800)),
+			LotNumber:      "MMR|25^A",
+			ExpiryDate:     &expiry,
+			Manufacturer:   "Serum Institute",
+			AdministeredBy: "Nurse Iyer",
+		},
+		{
+			RecordId:       42,
+			VaccineCode:    "2",
+			VaccineName:    "MMR",
+			AdministeredAt: time.Date(2025, 2, 12, 9, 0, 0, 0, time.FixedZone("IST", 5*3600+1800)),
+			LotNumber:      "MMR-25-B",
+			Voided:         true,
+		},
+	}
+}
+
+func assertVXU(t *testing.T, message Message) {
+	t.Helper()
+	msh := message.Segments[0]
+	if msh.Field(1) != FIELD_SEPARATOR || msh.Field(2) != ENCODING_CHARACTERS {
+		t.Errorf("MSH-1 %q MSH-2 %q", msh.Field(1), msh.Field(2))
+	}
+	if msh.Component(4, 1) != "Green Valley | East^Wing" {
+		t.Errorf("MSH-4 %q", msh.Component(4, 1))
+	}
+	if msh.Component(9, 1) != "VXU" || msh.Component(9, 2) != "V04" || msh.Field(12) != VERSION {
+		t.Errorf("MSH-9 %q MSH-12 %q", msh.Field(9), msh.Field(12))
+	}
+
+	pids := message.SegmentsNamed("PID")
+	if len(pids) != 1 {
+		t.Fatalf("message has %d PID segments", len(pids))
+	}
+	pid := pids[0]
+	if pid.Component(3, 1) != "7" || pid.Component(3, 5) != "MR" {
+		t.Errorf("PID-3 %q", pid.Field(3))
+	}
+	if pid.Component(5, 1) != "O&Neil" || pid.Component(5, 2) != `Ana|Maria R^o~s\a` || pid.Component(5, 7) != "L" {
+		t.Errorf("PID-5 %q parsed as family %q given %q", pid.Field(5), pid.Component(5, 1), pid.Component(5, 2))
+	}
+	//the portal records no date of birth, PID-7 is left empty rather than guessed
+	if pid.Field(7) != "" {
+		t.Errorf("PID-7 %q", pid.Field(7))
+	}
+	if pid.Field(8) != "F" || pid.Component(13, 12) != "9876543210" {
+		t.Errorf("PID-8 %q PID-13 %q", pid.Field(8), pid.Field(13))
+	}
+
+	rxas := message.SegmentsNamed("RXA")
+	if len(rxas) != 2 || len(message.SegmentsNamed("ORC")) != 2 {
+		t.Fatalf("message has %d RXA and %d ORC segments", len(rxas), len(message.SegmentsNamed("ORC")))
+	}
+	given := rxas[0]
+	if given.Field(3) != "20250210101500+0530" {
+		t.Errorf("RXA-3 %q", given.Field(3))
+	}
+	if given.Component(5, 1) != "03" || given.Component(5, 2) != "MMR" || given.Component(5, 3) != "CVX" {
+		t.Errorf("RXA-5 %q", given.Field(5))
+	}
+	if given.Component(15, 1) != "MMR|25^A" || given.Field(16) != "20260131" {
+		t.Errorf("RXA-15 %q RXA-16 %q", given.Field(15), given.Field(16))
+	}
+	if given.Component(10, 2) != "Nurse Iyer" || given.Component(17, 2) != "Serum Institute" {
+		t.Errorf("RXA-10 %q RXA-17 %q", given.Field(10), given.Field(17))
+	}
+	if given.Field(20) != "CP" || given.Field(21) != "A" {
+		t.Errorf("RXA-20 %q RXA-21 %q", given.Field(20), given.Field(21))
+	}
+	voided := rxas[1]
+	//a vaccine without a CVX code falls back to the catalog id
+	if voided.Component(5, 1) != "2" || voided.Component(5, 3) != "L" {
+		t.Errorf("voided RXA-5 %q", voided.Field(5))
+	}
+	if voided.Field(3) != "20250212090000+0530" || voided.Component(15, 1) != "MMR-25-B" || voided.Field(21) != "D" {
+		t.Errorf("voided RXA-3 %q RXA-15 %q RXA-21 %q", voided.Field(3), voided.Field(15), voided.Field(21))
+	}
+}
+
+func TestVXURoundTrip(t *testing.T) {
+	raw := BuildVXU(testHeader(), ControlId(testNow, 1), testStudent(), testDoses(), testNow)
+	if !strings.HasSuffix(raw, SEGMENT_TERMINATOR) || strings.Count(raw, SEGMENT_TERMINATOR) != 6 {
+		t.Fatalf("expected six segments ending in CR, got %q", raw)
+	}
+	message, err := ParseMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(message.Segments) != 6 {
+		t.Fatalf("parsed %d segments", len(message.Segments))
+	}
+	assertVXU(t, message)
+	if message.Segments[0].Field(10) != ControlId(testNow, 1) {
+		t.Errorf("MSH-10 %q", message.Segments[0].Field(10))
+	}
+}
+
+func TestBatchRoundTrip(t *testing.T) {
+	messages := []string{
+		BuildVXU(testHeader(), ControlId(testNow, 1), testStudent(), testDoses(), testNow),
+		BuildVXU(testHeader(), ControlId(testNow, 2), testStudent(), testDoses(), testNow),
+	}
+	//other tools often rewrite the CR terminators as CRLF
+	raw := strings.ReplaceAll(BuildBatch(testHeader(), "BATCH-1", messages, testNow), SEGMENT_TERMINATOR, "\r\n")
+	parsed, err := ParseBatch(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 {
+		t.Fatalf("parsed %d messages", len(parsed))
+	}
+	for i, message := range parsed {
+		assertVXU(t, message)
+		if message.Segments[0].Field(10) != ControlId(testNow, i+1) {
+			t.Errorf("message %d MSH-10 %q", i, message.Segments[0].Field(10))
+		}
+	}
+	bhs, err := ParseSegment(strings.Split(raw, "\r\n")[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bhs.Name() != "BHS" || bhs.Component(4, 1) != "Green Valley | East^Wing" || bhs.Field(11) != "BATCH-1" {
+		t.Errorf("unexpected BHS %v", bhs.Fields)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	valid := BuildVXU(testHeader(), "1", testStudent(), testDoses(), testNow)
+	batch := BuildBatch(testHeader(), "BATCH-1", []string{valid}, testNow)
+	if _, err := ParseMessage(strings.SplitN(valid, SEGMENT_TERMINATOR, 2)[1]); err == nil {
+		t.Error("a message without MSH was accepted")
+	}
+	if _, err := ParseMessage(strings.Replace(valid, ENCODING_CHARACTERS, "^~\\#", 1)); err == nil {
+		t.Error("unsupported encoding characters were accepted")
+	}
+	if _, err := ParseBatch(strings.Replace(batch, "BTS|1", "BTS|2", 1)); err == nil {
+		t.Error("a batch holding fewer messages than BTS-1 declares was accepted")
+	}
+	if _, err := ParseBatch("FHS|^~\\&\rPID|1\r"); err == nil {
+		t.Error("a PID before any MSH was accepted")
+	}
+}
+
+func TestEscapeRoundTrip(t *testing.T) {
+	for _, value := range []string{`|`, `^`, `~`, `\`, `&`, `a\F\b`, `\E\`, `x|y^z~w\v&u`} {
+		escaped := Escape(value)
+		if strings.ContainsAny(escaped, FIELD_SEPARATOR+COMPONENT_SEPARATOR+REPETITION+SUBCOMPONENT) {
+			t.Errorf("%q escaped to %q still holds a delimiter", value, escaped)
+		}
+		if Unescape(escaped) != value {
+			t.Errorf("%q escaped to %q came back as %q", value, escaped, Unescape(escaped))
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package links builds the absolute URLs returned in HATEOAS _links, resolved against the base URL
+// the client actually used to reach the portal.
+package links
+
+import (
+	"fmt"
+	"net/http"
+	"school_vaccination_portal/config"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// API_PREFIX is where every portal route is mounted
+	API_PREFIX  = "/school-vaccine-portal"
+	CONTEXT_KEY = "links"
+)
+
+// Builder turns portal paths into absolute links under one base URL
+type Builder struct {
+	base string
+}
+
+func NewBuilder(base string) Builder {
+	return Builder{base: strings.TrimRight(base, "/")}
+}
+
+// Href is the absolute URL of a portal route, path is relative to API_PREFIX and formatted with args
+func (b Builder) Href(path string, args ...interface{}) string {
+	return b.base + API_PREFIX + "/" + strings.TrimLeft(fmt.Sprintf(path, args...), "/")
+}
+
+// Link is one _links entry in the href and method shape every response uses
+func (b Builder) Link(method, path string, args ...interface{}) map[string]string {
+	return map[string]string{
+		"href":   b.Href(path, args...),
+		"method": method,
+	}
+}
+
+// Resolver picks the link base for each request
+type Resolver struct {
+	publicUrl      string
+	trustForwarded bool
+}
+
+func NewResolver(cfg config.ServerConfig) Resolver {
+	return Resolver{publicUrl: cfg.PublicURL, trustForwarded: cfg.TrustForwardedHeaders}
+}
+
+// For uses the configured public URL, else X-Forwarded-Proto/Host when trusted, else the request's own scheme and host
+func (r Resolver) For(req *http.Request) Builder {
+	if r.publicUrl != "" {
+		return NewBuilder(r.publicUrl)
+	}
+	scheme, host := "http", req.Host
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	if r.trustForwarded {
+		if proto := firstValue(req.Header.Get("X-Forwarded-Proto")); proto != "" {
+			scheme = proto
+		}
+		if forwardedHost := firstValue(req.Header.Get("X-Forwarded-Host")); forwardedHost != "" {
+			host = forwardedHost
+		}
+	}
+	return NewBuilder(scheme + "://" + host)
+}
+
+// Middleware resolves the builder once per request for the controllers to hand to their response handlers
+func Middleware(r Resolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(CONTEXT_KEY, r.For(c.Request()))
+			return next(c)
+		}
+	}
+}
+
+// From returns the builder Middleware stored, falling back to the request's own host
+func From(c echo.Context) Builder {
+	if builder, ok := c.Get(CONTEXT_KEY).(Builder); ok {
+		return builder
+	}
+	return Resolver{}.For(c.Request())
+}
+
+// proxies chaining through each other append to the header, the first entry is the client facing one
+func firstValue(header string) string {
+	return strings.TrimSpace(strings.Split(header, ",")[0])
+}
@@ -0,0 +1,150 @@
+// Package logging builds the leveled JSON logger every component is given, ties log lines to the
+// HTTP request or bulk job they belong to and keeps guardian and student contact details out of them.
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HEADER_REQUEST_ID carries the request id in HTTP requests and responses and in queue message headers
+	HEADER_REQUEST_ID = echo.HeaderXRequestID
+	CONTEXT_KEY       = "logger"
+	REQUEST_ID_KEY    = "request_id"
+	REDACTED          = config.REDACTED
+)
+
+// piiKeys are attribute names whose values are never written out, whatever component logs them
+var piiKeys = map[string]bool{
+	"phone":           true,
+	"phone_no":        true,
+	"phone_primary":   true,
+	"phone_secondary": true,
+	"email":           true,
+	"recipient":       true,
+	"password":        true,
+}
+
+// a client supplied id is kept only when it cannot break the log line or the header it is echoed in
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// New builds the process logger, LOG_FORMAT=text is there for reading logs on a terminal
+func New(cfg config.LogConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q, use debug, info, warn or error", cfg.Level)
+	}
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stdout, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid LOG_FORMAT %q, use json or text", cfg.Format)
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if piiKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redact(a.Value.String()))
+	}
+	return a
+}
+
+// Redact hides a contact detail while still showing whether one was there
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return REDACTED
+}
+
+// Err is the attribute every error is logged under
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String("error", "")
+	}
+	return slog.String("error", err.Error())
+}
+
+// Fatal logs and exits, for startup failures the process cannot run without
+func Fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Middleware gives every request an id, taken from X-Request-Id when the caller sent a usable one,
+// echoes it in the response, stores a logger carrying it for the handlers and logs the request once served.
+// skipper leaves out the requests not worth a line, they still get an id.
+func Middleware(logger *slog.Logger, skipper func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(HEADER_REQUEST_ID)
+			if !validRequestId.MatchString(id) {
+				id = uuid.NewString()
+			}
+			c.Response().Header().Set(HEADER_REQUEST_ID, id)
+			requestLogger := logger.With(REQUEST_ID_KEY, id)
+			c.Set(REQUEST_ID_KEY, id)
+			c.Set(CONTEXT_KEY, requestLogger)
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			if skipper != nil && skipper(c) {
+				return err
+			}
+			status := c.Response().Status
+			level := slog.LevelInfo
+			if status >= 500 {
+				level = slog.LevelError
+			}
+			requestLogger.Log(req.Context(), level, "request served",
+				"method", req.Method,
+				"route", c.Path(),
+				"uri", req.URL.Path,
+				"status", status,
+				"latency_ms", time.Since(start).Milliseconds(),
+				"bytes_out", c.Response().Size,
+				"remote_ip", c.RealIP(),
+			)
+			return err
+		}
+	}
+}
+
+// From returns the request's logger, falling back to the process logger outside Middleware
+func From(c echo.Context) *slog.Logger {
+	if logger, ok := c.Get(CONTEXT_KEY).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID is the id Middleware gave the request, empty outside it
+func RequestID(c echo.Context) string {
+	id, _ := c.Get(REQUEST_ID_KEY).(string)
+	return id
+}
+
+// ForJob ties a bulk job's log lines to the job and to the request that created it
+func ForJob(logger *slog.Logger, job *models.BulkFileJobsModel) *slog.Logger {
+	return logger.With(
+		REQUEST_ID_KEY, job.CorrelationId,
+		"job_id", job.RequestId,
+		"job_type", job.RequestType,
+	)
+}
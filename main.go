@@ -1,83 +1,291 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
-	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
+	"school_vaccination_portal/certificate"
+	"school_vaccination_portal/config"
 	"school_vaccination_portal/controller"
 	"school_vaccination_portal/databases/minio"
 	"school_vaccination_portal/databases/mysql"
 	"school_vaccination_portal/databases/rabbitmq"
+	"school_vaccination_portal/health"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/metrics"
 	"school_vaccination_portal/models"
+	"school_vaccination_portal/notifier"
 	"school_vaccination_portal/repository"
-	"school_vaccination_portal/server"
+	"school_vaccination_portal/response"
+	"school_vaccination_portal/tracing"
 	"school_vaccination_portal/usecase"
+	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func init() {
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Println("Error loading env file", err.Error())
-	}
+// services wires the usecases the bulk worker and the one-off commands share
+type services struct {
+	bulkJobs   usecase.BulkFileJobUsecaseHandler
+	students   usecase.StudentManagementUsecaseHandler
+	fhirExport usecase.FHIRExportUsecaseHandler
+	hl7Export  usecase.HL7ExportUsecaseHandler
+	rabbit     *rabbitmq.RabbitChannel
+	health     *health.Checker
 }
 
-func StartAsyncFileProcessing(queue string) {
-	dbConnection, err := mysql.GetMySQLConnect()
+func newServices(cfg *config.Config, logger *slog.Logger) *services {
+	dbConnection, err := mysql.GetMySQLConnect(cfg.Database, logger)
+	if err != nil {
+		logging.Fatal(logger, "error in connecting to db", logging.Err(err))
+	}
+	minIo, err := minio.GetMinIOClient(cfg.Minio, logger)
 	if err != nil {
-		log.Println("error in connecting to db", err.Error())
-		os.Exit(1)
+		logging.Fatal(logger, "error creating min IO Client", logging.Err(err))
 	}
-	minIo, err := minio.GetMinIOClient()
+	rabbitConnection, err := rabbitmq.GetRabbitConn(cfg.Rabbit, logger)
 	if err != nil {
-		log.Fatalln("error creating min IO Client", err.Error())
+		logging.Fatal(logger, "error connecting to rabbitmq", logging.Err(err))
 	}
-	rabbitConnection := rabbitmq.GetRabbitConn()
-	bulkfilejobrepo := repository.NewBulkFileJobsRepositoryHandler(dbConnection, minIo, rabbitConnection)
+	unitOfWork := repository.NewUnitOfWorkHandler(dbConnection)
+	bulkfilejobrepo := repository.NewBulkFileJobsRepositoryHandler(dbConnection, minIo, rabbitConnection, cfg.Rabbit.BulkQueue, logger)
 	studentManagementRepo := repository.NewStudentRepositoryHandler(dbConnection)
 	studentvaccinationrecordrepo := repository.NewVaccineRecordRepositoryHandler(dbConnection)
-	vaccineInventoryRepo := repository.NewVaccineInventoryHandler(dbConnection)
-	studentmanagementusecase := usecase.NewStudentManagementUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, bulkfilejobrepo, vaccineInventoryRepo)
-	asyncfileprocessingUsecase := usecase.NewBulkFileJobUsecaseHandler(studentmanagementusecase, bulkfilejobrepo)
-	rabbitConnection.Qos(10, 0, false)
-	ch, err := rabbitConnection.Consume(queue, "", false, false, false, false, amqp.Table{})
+	vaccineInventoryRepo := repository.NewVaccineInventoryHandler(dbConnection, logger)
+	vaccineCatalogRepo := repository.NewVaccineCatalogRepositoryHandler(dbConnection, logger)
+	consentRepo := repository.NewConsentRepositoryHandler(dbConnection)
+	exemptionRepo := repository.NewExemptionRepositoryHandler(dbConnection)
+	guardianRepo := repository.NewGuardianRepositoryHandler(dbConnection)
+	notificationRepo := repository.NewNotificationRepositoryHandler(dbConnection, rabbitConnection, cfg.Rabbit.NotificationQueue, logger)
+	notificationUsecase := usecase.NewNotificationUsecaseHandler(notificationRepo, guardianRepo, studentManagementRepo, studentvaccinationrecordrepo, vaccineInventoryRepo, exemptionRepo, notifier.NewNotifiers(cfg.Notification, logger), cfg, logger)
+	studentmanagementusecase := usecase.NewStudentManagementUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, bulkfilejobrepo, vaccineInventoryRepo, vaccineCatalogRepo, consentRepo, exemptionRepo, notificationUsecase, unitOfWork, cfg, logger)
+	consentUsecase := usecase.NewConsentUsecaseHandler(consentRepo, guardianRepo, studentManagementRepo, vaccineInventoryRepo, vaccineCatalogRepo, bulkfilejobrepo, cfg, logger)
+	guardianUsecase := usecase.NewGuardianUsecaseHandler(guardianRepo, studentManagementRepo, logger)
+	checker := health.NewChecker(cfg.Health.Timeout(), logger)
+	checker.Add("mysql", health.MySQL(dbConnection))
+	checker.Add("rabbitmq", health.RabbitMQ(rabbitConnection))
+	checker.Add("minio", health.MinIO(minIo, cfg.Minio.Bucket))
+	return &services{
+		bulkJobs:   usecase.NewBulkFileJobUsecaseHandler(studentmanagementusecase, consentUsecase, guardianUsecase, bulkfilejobrepo, unitOfWork, cfg, logger),
+		students:   studentmanagementusecase,
+		fhirExport: usecase.NewFHIRExportUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, vaccineInventoryRepo, vaccineCatalogRepo, bulkfilejobrepo, cfg, logger),
+		hl7Export:  usecase.NewHL7ExportUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, vaccineInventoryRepo, vaccineCatalogRepo, bulkfilejobrepo, cfg, logger),
+		rabbit:     rabbitConnection,
+		health:     checker,
+	}
+}
+
+// processBulkJob runs one bulk job to completion, the job row records how it went
+func processBulkJob(ctx context.Context, svc *services, job *models.BulkFileJobsModel) error {
+	ctx, span := tracing.Tracer().Start(ctx, "bulk job "+job.RequestType, trace.WithAttributes(
+		attribute.String("svp.job.id", job.RequestId),
+		attribute.String("svp.job.type", job.RequestType),
+	))
+	defer span.End()
+	start := time.Now()
+	err := runBulkJob(ctx, svc, job)
+	status := job.Status
+	if err != nil {
+		status = "FAILED"
+		tracing.RecordError(span, err)
+	}
+	span.SetAttributes(attribute.String("svp.job.status", status), attribute.Int("svp.job.processed_records", job.ProcessedRecords))
+	metrics.ObserveBulkJob(job.RequestType, status, job.TotalRecords, job.ProcessedRecords, time.Since(start))
+	return err
+}
+
+func runBulkJob(ctx context.Context, svc *services, job *models.BulkFileJobsModel) error {
+	switch job.RequestType {
+	case controller.BULK_STUDENT_RECORD:
+		return svc.bulkJobs.ProcessBulkStudentRecord(ctx, job)
+	case controller.BULK_VACCINE_RECORD:
+		return svc.bulkJobs.ProcessBulkVaccineRecord(ctx, job)
+	case controller.BULK_CONSENT_RECORD:
+		return svc.bulkJobs.ProcessBulkConsentRecord(ctx, job)
+	case controller.BULK_GUARDIAN_RECORD:
+		return svc.bulkJobs.ProcessBulkGuardianRecord(ctx, job)
+	case controller.HL7_VXU_EXPORT:
+		return svc.hl7Export.ProcessExportJob(ctx, job)
+	}
+	return fmt.Errorf("unknown request type %s", job.RequestType)
+}
+
+func StartAsyncFileProcessing(cfg *config.Config, logger *slog.Logger) {
+	svc := newServices(cfg, logger)
+	metrics.Registry.MustRegister(metrics.NewQueueCollector(logger, svc.rabbit, cfg.Rabbit.BulkQueue))
+	svc.health.Serve(cfg.Health.WorkerAddress(), metrics.Register)
+	svc.rabbit.Qos(10, 0, false)
+	ch, err := svc.rabbit.Consume(cfg.Rabbit.BulkQueue, "", false, false, false, false, amqp.Table{})
 	if err != nil {
-		log.Fatalf("Unable to start Processing from queue %s", err.Error())
+		logging.Fatal(logger, "unable to start processing from queue", "queue", cfg.Rabbit.BulkQueue, logging.Err(err))
 	}
-	log.Println("Async Processor Started")
-	forever := make(chan bool)
+	logger.Info("bulk processor started", "queue", cfg.Rabbit.BulkQueue)
 	for j := range ch {
 		//UnMarshall and see what kind of data
 		data := new(models.BulkFileJobsModel)
 		if err = json.Unmarshal(j.Body, data); err != nil {
-			log.Println("Unable to Unmarshall Data Packet for processing ", err.Error())
+			logger.Error("unable to unmarshal bulk job message", logging.Err(err))
 			j.Ack(false)
+			continue
 		}
-		switch data.RequestType {
-		case controller.BULK_STUDENT_RECORD:
-			go asyncfileprocessingUsecase.ProcessBulkStudentRecord(data)
-			j.Ack(false)
-		case controller.BULK_VACCINE_RECORD:
-			go asyncfileprocessingUsecase.ProcessBulkVaccineRecord(data)
+		//jobs queued before correlation ids were stored carry the id only in the message
+		if data.CorrelationId == "" {
+			data.CorrelationId = messageRequestId(j)
+		}
+		metrics.ObserveQueueLag(data.RequestType, data.CreatedAt)
+		ctx, span := tracing.StartConsume(cfg.Rabbit.BulkQueue, j)
+		ctx, cancel := context.WithTimeout(ctx, cfg.Timeouts.BulkJob())
+		go func(job *models.BulkFileJobsModel) {
+			defer span.End()
+			defer cancel()
+			if err := processBulkJob(ctx, svc, job); err != nil {
+				logging.ForJob(logger, job).Error("error processing bulk job", logging.Err(err))
+			}
+		}(data)
+		j.Ack(false)
+	}
+}
+
+// messageRequestId is the request id a message was published under
+func messageRequestId(d amqp.Delivery) string {
+	if id, ok := d.Headers[logging.HEADER_REQUEST_ID].(string); ok && id != "" {
+		return id
+	}
+	return d.CorrelationId
+}
+
+// StartNotificationProcessing delivers queued notifications and, once a day,
+// queues drive reminders and missed drive follow ups
+func StartNotificationProcessing(cfg *config.Config, logger *slog.Logger) {
+	dbConnection, err := mysql.GetMySQLConnect(cfg.Database, logger)
+	if err != nil {
+		logging.Fatal(logger, "error in connecting to db", logging.Err(err))
+	}
+	rabbitConnection, err := rabbitmq.GetRabbitConn(cfg.Rabbit, logger)
+	if err != nil {
+		logging.Fatal(logger, "error connecting to rabbitmq", logging.Err(err))
+	}
+	checker := health.NewChecker(cfg.Health.Timeout(), logger)
+	checker.Add("mysql", health.MySQL(dbConnection))
+	checker.Add("rabbitmq", health.RabbitMQ(rabbitConnection))
+	metrics.Registry.MustRegister(metrics.NewQueueCollector(logger, rabbitConnection, cfg.Rabbit.NotificationQueue))
+	checker.Serve(cfg.Health.WorkerAddress(), metrics.Register)
+	notificationUsecase := usecase.NewNotificationUsecaseHandler(
+		repository.NewNotificationRepositoryHandler(dbConnection, rabbitConnection, cfg.Rabbit.NotificationQueue, logger),
+		repository.NewGuardianRepositoryHandler(dbConnection),
+		repository.NewStudentRepositoryHandler(dbConnection),
+		repository.NewVaccineRecordRepositoryHandler(dbConnection),
+		repository.NewVaccineInventoryHandler(dbConnection, logger),
+		repository.NewExemptionRepositoryHandler(dbConnection),
+		notifier.NewNotifiers(cfg.Notification, logger),
+		cfg,
+		logger,
+	)
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.BulkJob())
+			reminders, err := notificationUsecase.QueueDriveReminders(ctx, cfg.Notification.ReminderDaysBefore)
+			if err != nil {
+				logger.Error("error queueing drive reminders", logging.Err(err))
+			}
+			followUps, err := notificationUsecase.QueueMissedDriveFollowUps(ctx, cfg.Notification.MissedFollowUpDays)
+			cancel()
+			if err != nil {
+				logger.Error("error queueing missed drive follow ups", logging.Err(err))
+			}
+			logger.Info("scheduled notifications queued", "reminders", reminders, "follow_ups", followUps)
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+	rabbitConnection.Qos(10, 0, false)
+	ch, err := rabbitConnection.Consume(cfg.Rabbit.NotificationQueue, "", false, false, false, false, amqp.Table{})
+	if err != nil {
+		logging.Fatal(logger, "unable to start processing from queue", "queue", cfg.Rabbit.NotificationQueue, logging.Err(err))
+	}
+	logger.Info("notification processor started", "queue", cfg.Rabbit.NotificationQueue)
+	for j := range ch {
+		data := struct {
+			NotificationId int `json:"notification_id"`
+		}{}
+		if err = json.Unmarshal(j.Body, &data); err != nil {
+			logger.Error("unable to unmarshal notification message", logging.Err(err))
 			j.Ack(false)
-		default:
-			log.Println("Unknown Request Type")
+			continue
+		}
+		ctx, span := tracing.StartConsume(cfg.Rabbit.NotificationQueue, j)
+		span.SetAttributes(attribute.Int("svp.notification.id", data.NotificationId))
+		ctx, cancel := context.WithTimeout(ctx, cfg.Timeouts.Notification())
+		err = notificationUsecase.DeliverNotification(ctx, data.NotificationId)
+		cancel()
+		if err != nil {
+			logger.Error("error delivering notification", "notification_id", data.NotificationId, logging.Err(err))
+			tracing.RecordError(span, err)
 		}
+		span.End()
+		j.Ack(false)
 	}
-	<-forever
 }
 
-func main() {
-	service := flag.String("service", "", "Service Being Requested: server, bulkProcessor")
-	flag.Parse()
-	switch *service {
-	case "schoool-vaccination-portal-server":
-		server.Start()
-	default:
-		log.Println("Starting Bulk processor")
-		StartAsyncFileProcessing("async-file-processing-queue")
+// VerifyCertificateOffline checks a scanned certificate with only the public key and, when given, a saved copy
+// of the revocation list from GET school-vaccine-portal/certificates/revoked. It never calls the portal.
+func VerifyCertificateOffline(cfg config.CertificateConfig, token, revocationList string) bool {
+	key, err := certificate.VerifyKey(cfg)
+	if err != nil {
+		slog.Error("unable to load certificate verify key", logging.Err(err))
+		return false
+	}
+	payload, err := certificate.Verify(certificate.TokenFromQR(token), key)
+	if err != nil {
+		fmt.Println("INVALID:", err.Error())
+		return false
+	}
+	fmt.Println("Signature valid for certificate", payload.CertificateNumber)
+	fmt.Println("Student:", payload.StudentName, "issued", payload.IssuedAt)
+	for _, dose := range payload.Doses {
+		fmt.Printf("  %s dose %d on %s\n", dose.Vaccine, dose.Dose, dose.Date)
+	}
+	if revocationList == "" {
+		fmt.Println("Revocation status unknown, pass -revocation-list to check it")
+		return true
 	}
+	content, err := os.ReadFile(revocationList)
+	if err != nil {
+		slog.Error("unable to read revocation list", logging.Err(err))
+		return false
+	}
+	revoked := struct {
+		Data []response.RevokedCertificateResponse `json:"data"`
+	}{}
+	if err = json.Unmarshal(content, &revoked); err != nil {
+		slog.Error("unable to parse revocation list", logging.Err(err))
+		return false
+	}
+	for _, entry := range revoked.Data {
+		if entry.CertificateNumber == payload.CertificateNumber {
+			fmt.Println("REVOKED on", entry.RevokedAt, "reason:", entry.RevokedReason)
+			return false
+		}
+	}
+	fmt.Println("Not revoked")
+	return true
+}
+
+// GenerateCertificateKeys prints a fresh Ed25519 key pair in the form the env file expects
+func GenerateCertificateKeys() {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		logging.Fatal(slog.Default(), "unable to generate certificate keys", logging.Err(err))
+	}
+	fmt.Println("CERTIFICATE_SIGNING_KEY=" + base64.StdEncoding.EncodeToString(private.Seed()))
+	fmt.Println("CERTIFICATE_VERIFY_KEY=" + base64.StdEncoding.EncodeToString(public))
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
 }
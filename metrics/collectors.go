@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/databases/rabbitmq"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/usecase"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// upcoming drives are the ones still to be held or running today
+var upcomingDriveFilter = fmt.Sprintf("status IN ('%s', '%s') AND DATE(drive_date) >= CURDATE()", models.DRIVE_SCHEDULED, models.DRIVE_IN_PROGRESS)
+
+var (
+	queueMessagesDesc = prometheus.NewDesc(prometheus.BuildFQName(NAMESPACE, "queue", "messages"),
+		"Messages ready for delivery in the queue.", []string{"queue"}, nil)
+	queueConsumersDesc = prometheus.NewDesc(prometheus.BuildFQName(NAMESPACE, "queue", "consumers"),
+		"Consumers attached to the queue.", []string{"queue"}, nil)
+
+	studentsDesc = prometheus.NewDesc(prometheus.BuildFQName(NAMESPACE, "students", "total"),
+		"Students enrolled.", nil, nil)
+	vaccinatedDesc = prometheus.NewDesc(prometheus.BuildFQName(NAMESPACE, "students", "vaccinated"),
+		"Students with at least one active vaccination record.", nil, nil)
+	exemptDesc = prometheus.NewDesc(prometheus.BuildFQName(NAMESPACE, "students", "exempt"),
+		"Unvaccinated students holding an exemption in force.", nil, nil)
+	remainingDosesDesc = prometheus.NewDesc(prometheus.BuildFQName(NAMESPACE, "drive", "remaining_doses"),
+		"Doses left in each upcoming drive.", []string{"drive_id", "vaccine", "drive_date"}, nil)
+	bulkJobStatusDesc = prometheus.NewDesc(prometheus.BuildFQName(NAMESPACE, "bulk_jobs", "by_status"),
+		"Bulk jobs on record, by request type and current status.", []string{"type", "status"}, nil)
+)
+
+// QueueCollector reads the queue depth from the broker on every scrape, a queue that cannot be inspected is left out
+type QueueCollector struct {
+	rabbit *rabbitmq.RabbitChannel
+	queues []string
+	logger *slog.Logger
+}
+
+func NewQueueCollector(logger *slog.Logger, rabbit *rabbitmq.RabbitChannel, queues ...string) *QueueCollector {
+	return &QueueCollector{rabbit: rabbit, queues: queues, logger: logger}
+}
+
+func (q *QueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueMessagesDesc
+	ch <- queueConsumersDesc
+}
+
+func (q *QueueCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, name := range q.queues {
+		queue, err := q.rabbit.Inspect(name)
+		if err != nil {
+			q.logger.Warn("unable to inspect queue for metrics", "queue", name, logging.Err(err))
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(queueMessagesDesc, prometheus.GaugeValue, float64(queue.Messages), name)
+		ch <- prometheus.MustNewConstMetric(queueConsumersDesc, prometheus.GaugeValue, float64(queue.Consumers), name)
+	}
+}
+
+// DomainCollector reads the coverage, drive stock and bulk job figures from the database on every scrape,
+// a query that fails only drops its own metrics
+type DomainCollector struct {
+	students usecase.StudentManagementUsecaseHandler
+	drives   repository.VaccineInventoryHandler
+	bulkJobs repository.BulkFileJobsRepositoryHandler
+	logger   *slog.Logger
+}
+
+func NewDomainCollector(students usecase.StudentManagementUsecaseHandler, drives repository.VaccineInventoryHandler, bulkJobs repository.BulkFileJobsRepositoryHandler, logger *slog.Logger) *DomainCollector {
+	return &DomainCollector{students: students, drives: drives, bulkJobs: bulkJobs, logger: logger}
+}
+
+func (d *DomainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- studentsDesc
+	ch <- vaccinatedDesc
+	ch <- exemptDesc
+	ch <- remainingDosesDesc
+	ch <- bulkJobStatusDesc
+}
+
+func (d *DomainCollector) Collect(ch chan<- prometheus.Metric) {
+	//a scrape has no request context, prometheus gives up on it after its own scrape timeout
+	ctx := context.Background()
+	total, vaccinated, exempt, err := d.students.GetVaccinationDashBoardData(ctx, "", false)
+	if err != nil {
+		d.logger.Warn("unable to collect vaccination coverage metrics", logging.Err(err))
+	} else {
+		ch <- prometheus.MustNewConstMetric(studentsDesc, prometheus.GaugeValue, float64(total))
+		ch <- prometheus.MustNewConstMetric(vaccinatedDesc, prometheus.GaugeValue, float64(vaccinated))
+		ch <- prometheus.MustNewConstMetric(exemptDesc, prometheus.GaugeValue, float64(exempt))
+	}
+	drives, err := d.drives.GetVaccineInventory(ctx, upcomingDriveFilter)
+	if err != nil {
+		d.logger.Warn("unable to collect drive metrics", logging.Err(err))
+	}
+	for _, drive := range drives {
+		ch <- prometheus.MustNewConstMetric(remainingDosesDesc, prometheus.GaugeValue, float64(drive.RemainingDoses()),
+			strconv.Itoa(drive.Id), drive.VaccineName, drive.DriveDate.Format("2006-01-02"))
+	}
+	counts, err := d.bulkJobs.GetBulkFileJobStatusCounts(ctx)
+	if err != nil {
+		d.logger.Warn("unable to collect bulk job metrics", logging.Err(err))
+	}
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(bulkJobStatusDesc, prometheus.GaugeValue, float64(count.Count), count.RequestType, count.Status)
+	}
+}
@@ -0,0 +1,129 @@
+// Package metrics exposes the Prometheus metrics of the API and the workers on /metrics,
+// every process serves its own registry.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	NAMESPACE    = "svp"
+	METRICS_PATH = "/metrics"
+
+	ROWS_ACCEPTED = "accepted"
+	ROWS_REJECTED = "rejected"
+)
+
+// Registry holds everything this process exports, alongside the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	bulkJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "bulk_jobs_processed_total",
+		Help:      "Bulk jobs run by this worker, by request type and the status they finished in.",
+	}, []string{"type", "status"})
+	bulkRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "bulk_job_rows_total",
+		Help:      "Rows of bulk jobs, by request type and whether they were accepted or rejected.",
+	}, []string{"type", "result"})
+	bulkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "bulk_job_duration_seconds",
+		Help:      "Time taken to run a bulk job, by request type and status.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"type", "status"})
+	bulkLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "bulk_job_queue_lag_seconds",
+		Help:      "Time a bulk job waited between being created and a worker picking it up, by request type.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 3600},
+	}, []string{"type"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		bulkJobs, bulkRows, bulkDuration, bulkLag,
+	)
+}
+
+// Middleware counts and times every request against its route template, so ids in the path do not explode the labels
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			//the error handler decides the status code, run it now so the status is known
+			if err != nil {
+				c.Error(err)
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			status := strconv.Itoa(c.Response().Status)
+			httpRequests.WithLabelValues(c.Request().Method, route, status).Inc()
+			httpDuration.WithLabelValues(c.Request().Method, route, status).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// ObserveBulkJob records a finished job, of a PROCESSED job the processed rows were accepted and the rest
+// of the file rejected, a FAILED job stopped before judging its rows
+func ObserveBulkJob(requestType, status string, total, processed int, took time.Duration) {
+	bulkJobs.WithLabelValues(requestType, status).Inc()
+	bulkDuration.WithLabelValues(requestType, status).Observe(took.Seconds())
+	if status != "PROCESSED" {
+		return
+	}
+	bulkRows.WithLabelValues(requestType, ROWS_ACCEPTED).Add(float64(processed))
+	if total > processed {
+		bulkRows.WithLabelValues(requestType, ROWS_REJECTED).Add(float64(total - processed))
+	}
+}
+
+// ObserveQueueLag records how long a job sat in the queue, jobs without a creation time are skipped
+func ObserveQueueLag(requestType string, createdAt time.Time) {
+	if createdAt.IsZero() {
+		return
+	}
+	bulkLag.WithLabelValues(requestType).Observe(time.Since(createdAt).Seconds())
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Register mounts /metrics on e
+func Register(e *echo.Echo) {
+	e.GET(METRICS_PATH, echo.WrapHandler(Handler()))
+}
+
+// IsScrape tells the request logger to skip the endpoint Prometheus polls
+func IsScrape(c echo.Context) bool {
+	return c.Request().URL.Path == METRICS_PATH
+}
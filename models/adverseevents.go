@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	AEFI_MILD     = "MILD"
+	AEFI_MODERATE = "MODERATE"
+	AEFI_SEVERE   = "SEVERE"
+	AEFI_SERIOUS  = "SERIOUS"
+)
+
+type AdverseEvent struct {
+	Id                  int       `json:"id"`
+	VaccinationRecordId int       `json:"vaccination_record_id"`
+	StudentId           int       `json:"student_id"`
+	DriveId             int       `json:"drive_id"`
+	LotNumber           string    `json:"lot_number"`
+	Severity            string    `json:"severity"`
+	OnsetAt             time.Time `json:"onset_at"`
+	Symptoms            string    `json:"symptoms"`
+	ActionsTaken        string    `json:"actions_taken"`
+	Outcome             string    `json:"outcome"`
+	ReportedBy          string    `json:"reported_by"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// AdverseEventSummary aggregates the events reported against one lot of one drive
+type AdverseEventSummary struct {
+	DriveId           int    `json:"drive_id"`
+	VaccineName       string `json:"vaccine_name"`
+	LotNumber         string `json:"lot_number"`
+	DosesAdministered int    `json:"doses_administered"`
+	TotalEvents       int    `json:"total_events"`
+	Mild              int    `json:"mild"`
+	Moderate          int    `json:"moderate"`
+	Severe            int    `json:"severe"`
+	Serious           int    `json:"serious"`
+}
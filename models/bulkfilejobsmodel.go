@@ -14,4 +14,16 @@ type BulkFileJobsModel struct {
 	TotalRecords     int       `json:"total_records"`
 	RequestId        string    `json:"request_id"`
 	RequestType      string    `json:"request_Type"`
+	Parameters       string    `json:"parameters,omitempty"`
+	// CorrelationId is the id of the HTTP request that created the job, carried into the worker's logs
+	CorrelationId string `json:"correlation_id,omitempty"`
+	// AllOrNothing imports the file only when every row is accepted, otherwise rows are imported one by one
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+// BulkFileJobCount is how many jobs of one request type are in one status
+type BulkFileJobCount struct {
+	RequestType string `json:"request_type"`
+	Status      string `json:"status"`
+	Count       int    `json:"count"`
 }
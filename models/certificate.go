@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	CERTIFICATE_ISSUED  = "ISSUED"
+	CERTIFICATE_REVOKED = "REVOKED"
+)
+
+// Certificate is one issued immunization certificate, the number printed on it identifies it for later checks
+type Certificate struct {
+	Id                int        `json:"id"`
+	CertificateNumber string     `json:"certificate_number"`
+	StudentId         int        `json:"student_id"`
+	IssuedAt          time.Time  `json:"issued_at"`
+	FilePath          string     `json:"file_path"`
+	Status            string     `json:"status"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	RevokedReason     string     `json:"revoked_reason"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// CertificateDose is a line on the certificate, one per active vaccination record
+type CertificateDose struct {
+	StudentId      int        `json:"student_id"`
+	RecordId       int        `json:"record_id"`
+	VaccineName    string     `json:"vaccine_name"`
+	DoseNumber     int        `json:"dose_number"`
+	AdministeredAt *time.Time `json:"administered_at"`
+	LotNumber      string     `json:"lot_number"`
+	DriveId        int        `json:"drive_id"`
+}
+
+// CertificatePayload is what the certificate QR code carries, enough for a reader to compare against the printed certificate
+type CertificatePayload struct {
+	CertificateNumber string                   `json:"id"`
+	StudentName       string                   `json:"name"`
+	IssuedAt          string                   `json:"issued"`
+	Doses             []CertificatePayloadDose `json:"doses"`
+}
+
+type CertificatePayloadDose struct {
+	Vaccine string `json:"vaccine"`
+	Dose    int    `json:"dose"`
+	Date    string `json:"date"`
+}
+
+// CertificateVerification is the outcome of checking a scanned certificate, Certificate is nil when the
+// signature does not hold or the number is not on record
+type CertificateVerification struct {
+	Valid       bool
+	Reason      string
+	Payload     CertificatePayload
+	Certificate *Certificate
+}
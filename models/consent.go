@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	CONSENT_GRANTED = "GRANTED"
+	CONSENT_REFUSED = "REFUSED"
+	CONSENT_PENDING = "PENDING"
+	// students with no consent row at all for a drive
+	CONSENT_NO_RESPONSE = "NO_RESPONSE"
+)
+
+// Consent is a guardian's decision for a student, given either for a single drive or for a vaccine across drives
+type Consent struct {
+	Id           int       `json:"id"`
+	StudentId    int       `json:"student_id"`
+	DriveId      int       `json:"drive_id"`
+	VaccineId    int       `json:"vaccine_id"`
+	Status       string    `json:"status"`
+	GuardianId   int       `json:"guardian_id"`
+	GuardianName string    `json:"guardian_name"`
+	ConsentDate  time.Time `json:"consent_date"`
+	FormPath     string    `json:"form_path"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ConsentInsertionRecord struct {
+	Record      Consent `json:"record"`
+	Status      bool    `json:"status"`
+	ErrorReason string  `json:"error_reason"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	EXEMPTION_MEDICAL   = "MEDICAL"
+	EXEMPTION_RELIGIOUS = "RELIGIOUS"
+)
+
+// Exemption excuses a student from one vaccine, until the expiry date when one is set
+type Exemption struct {
+	Id            int        `json:"id"`
+	StudentId     int        `json:"student_id"`
+	VaccineId     int        `json:"vaccine_id"`
+	VaccineName   string     `json:"vaccine_name,omitempty"`
+	ExemptionType string     `json:"exemption_type"`
+	Reason        string     `json:"reason"`
+	ExpiryDate    *time.Time `json:"expiry_date"`
+	DocumentPath  string     `json:"document_path"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (e Exemption) IsActive(on time.Time) bool {
+	return e.ExpiryDate == nil || !e.ExpiryDate.Before(on.UTC().Truncate(24*time.Hour))
+}
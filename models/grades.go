@@ -0,0 +1,47 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Grades is a list of classes such as "Grade 5", persisted as a JSON array in a text column
+type Grades []string
+
+func (g Grades) Value() (driver.Value, error) {
+	if g == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(g)
+	return string(b), err
+}
+
+func (g *Grades) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*g = Grades{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type %T for grades", src)
+	}
+	if len(raw) == 0 {
+		*g = Grades{}
+		return nil
+	}
+	return json.Unmarshal(raw, g)
+}
+
+func (g Grades) Contains(grade string) bool {
+	for _, j := range g {
+		if j == grade {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	RELATIONSHIP_MOTHER      = "MOTHER"
+	RELATIONSHIP_FATHER      = "FATHER"
+	RELATIONSHIP_GUARDIAN    = "GUARDIAN"
+	RELATIONSHIP_GRANDPARENT = "GRANDPARENT"
+	RELATIONSHIP_OTHER       = "OTHER"
+)
+
+// Guardian is a parent or carer who can be contacted for any of the students linked to them
+type Guardian struct {
+	Id                int       `json:"id"`
+	Name              string    `json:"name"`
+	Relationship      string    `json:"relationship"`
+	PhonePrimary      string    `json:"phone_primary"`
+	PhoneSecondary    string    `json:"phone_secondary"`
+	Email             string    `json:"email"`
+	PreferredLanguage string    `json:"preferred_language"`
+	NotificationOptIn bool      `json:"notification_opt_in"`
+	StudentIds        []int     `json:"student_ids" gorm:"-"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// LogValue keeps the guardian's phone numbers and email out of logs
+func (g Guardian) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("id", g.Id), slog.String("relationship", g.Relationship), slog.Any("student_ids", g.StudentIds))
+}
+
+type StudentGuardian struct {
+	StudentId  int       `json:"student_id"`
+	GuardianId int       `json:"guardian_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type GuardianInsertionRecord struct {
+	Record      Guardian `json:"record"`
+	Status      bool     `json:"status"`
+	ErrorReason string   `json:"error_reason"`
+}
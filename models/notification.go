@@ -0,0 +1,69 @@
+package models
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	NOTIFY_DRIVE_REMINDER           = "DRIVE_REMINDER"
+	NOTIFY_VACCINATION_CONFIRMATION = "VACCINATION_CONFIRMATION"
+	NOTIFY_MISSED_DRIVE             = "MISSED_DRIVE"
+)
+
+const (
+	CHANNEL_SMS   = "SMS"
+	CHANNEL_EMAIL = "EMAIL"
+)
+
+const (
+	NOTIFICATION_QUEUED = "QUEUED"
+	NOTIFICATION_SENT   = "SENT"
+	NOTIFICATION_FAILED = "FAILED"
+)
+
+// Notification is one message to one guardian over one channel, rendered when queued and kept as its delivery record
+type Notification struct {
+	Id               int        `json:"id"`
+	NotificationType string     `json:"notification_type"`
+	Channel          string     `json:"channel"`
+	GuardianId       int        `json:"guardian_id"`
+	StudentId        int        `json:"student_id"`
+	DriveId          int        `json:"drive_id"`
+	RecordId         int        `json:"record_id"`
+	Recipient        string     `json:"recipient"`
+	Language         string     `json:"language"`
+	Subject          string     `json:"subject"`
+	Body             string     `json:"body"`
+	Status           string     `json:"status"`
+	Attempts         int        `json:"attempts"`
+	ErrorMessage     string     `json:"error_message"`
+	SentAt           *time.Time `json:"sent_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// LogValue leaves out the recipient address and the rendered message
+func (n Notification) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", n.Id),
+		slog.String("notification_type", n.NotificationType),
+		slog.String("channel", n.Channel),
+		slog.Int("guardian_id", n.GuardianId),
+		slog.String("status", n.Status),
+	)
+}
+
+// GuardianContact is an opted in guardian of a student, as needed to address a notification
+type GuardianContact struct {
+	StudentId         int    `json:"student_id"`
+	GuardianId        int    `json:"guardian_id"`
+	Name              string `json:"name"`
+	PhonePrimary      string `json:"phone_primary"`
+	Email             string `json:"email"`
+	PreferredLanguage string `json:"preferred_language"`
+}
+
+func (g GuardianContact) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("student_id", g.StudentId), slog.Int("guardian_id", g.GuardianId))
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"log/slog"
+	"time"
+)
 
 type StudentManagement struct {
 	Id         int        `json:"id"`
@@ -12,6 +15,12 @@ type StudentManagement struct {
 	UpdatedAt  *time.Time `json:"update_at"`
 	PhoneNo    string     `json:"phone_no"`
 }
+
+// LogValue keeps the phone number out of logs
+func (s StudentManagement) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("id", s.Id), slog.String("class", s.Class), slog.String("roll_number", s.RollNumber))
+}
+
 type DBInsertionRecord struct {
 	Record      StudentManagement `json:"record"`
 	Status      bool              `json:"status"`
@@ -28,14 +37,61 @@ type GetStudentCompleteDetails struct {
 	Vaccination bool   `json:"vaccination"`
 	VaccineName string `json:"vaccine_name,omitempty"`
 	VaccineDate string `json:"vaccine_date,omitempty"`
+	DosesTaken  int    `json:"doses_received,omitempty"`
+	SeriesDoses int    `json:"doses_in_series,omitempty"`
+	Protection  string `json:"protection_status,omitempty"`
+	Exempt      bool   `json:"exempt,omitempty"`
+	ExemptFrom  string `json:"exempt_from,omitempty"`
 }
 
+func (s GetStudentCompleteDetails) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("id", s.Id), slog.String("class", s.Class), slog.String("roll_no", s.RollNo))
+}
+
+const (
+	FULLY_PROTECTED     = "FULLY_PROTECTED"
+	PARTIALLY_PROTECTED = "PARTIALLY_PROTECTED"
+	EXEMPT              = "EXEMPT"
+)
+
 type StudentVaccineRecord struct {
-	Id        int        `json:"id"`
-	StudentId int        `json:"student_id"`
-	DriveId   int        `json:"drive_id"`
-	CreatedAt *time.Time `json:"created_at"`
+	Id             int        `json:"id"`
+	StudentId      int        `json:"student_id"`
+	DriveId        int        `json:"drive_id"`
+	VaccineId      int        `json:"vaccine_id"`
+	DoseNumber     int        `json:"dose_number"`
+	AdministeredAt *time.Time `json:"administered_at"`
+	LotNumber      string     `json:"lot_number"`
+	ExpiryDate     *time.Time `json:"expiry_date"`
+	InjectionSite  string     `json:"injection_site"`
+	AdministeredBy string     `json:"administered_by"`
+	Status         string     `json:"status"`
+	StatusReason   string     `json:"status_reason,omitempty"`
+	StatusChangeAt *time.Time `json:"status_changed_at,omitempty" gorm:"column:status_changed_at"`
+	SupersededBy   int        `json:"superseded_by,omitempty"`
+	CorrectsRecord int        `json:"corrects_record_id,omitempty" gorm:"column:corrects_record_id"`
+	CreatedAt      *time.Time `json:"created_at"`
 }
+
+const (
+	RECORD_ACTIVE     = "ACTIVE"
+	RECORD_VOIDED     = "VOIDED"
+	RECORD_SUPERSEDED = "SUPERSEDED"
+)
+
+// StudentDoseHistory is a dose already given to a student
+type StudentDoseHistory struct {
+	Id             int       `json:"id"`
+	DriveId        int       `json:"drive_id"`
+	DoseNumber     int       `json:"dose_number"`
+	AdministeredAt time.Time `json:"administered_at"`
+}
+
+const (
+	DUPLICATE_POLICY_REJECT  = "REJECT"
+	DUPLICATE_POLICY_BOOSTER = "BOOSTER"
+)
+
 type VaccineInsertionDBRecord struct {
 	Record      StudentVaccineRecord `json:"record"`
 	Status      bool                 `json:"status"`
@@ -51,3 +107,7 @@ type StudentVaccinationDetail struct {
 	PhoneNo    string `json:"phone_no"`
 	DriveId    int    `json:"drive_id"`
 }
+
+func (s StudentVaccinationDetail) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("id", s.Id), slog.String("class", s.Class), slog.Int("drive_id", s.DriveId))
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	ROLE_ADMIN = "ADMIN"
+	ROLE_STAFF = "STAFF"
+)
+
+// User is a portal operator account, only the bcrypt hash of the password is stored
+type User struct {
+	Id           int       `json:"id"`
+	Username     string    `json:"username"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type Vaccine struct {
+	Id              int       `json:"id"`
+	Name            string    `json:"name"`
+	CvxCode         string    `json:"cvx_code"`
+	Manufacturer    string    `json:"manufacturer"`
+	DosesInSeries   int       `json:"doses_in_series"`
+	MinIntervalDays int       `json:"min_interval_days"`
+	EligibleGrades  Grades    `json:"eligible_grades" gorm:"type:text"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
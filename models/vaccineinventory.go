@@ -2,12 +2,39 @@ package models
 
 import "time"
 
+const (
+	DRIVE_SCHEDULED   = "SCHEDULED"
+	DRIVE_IN_PROGRESS = "IN_PROGRESS"
+	DRIVE_COMPLETED   = "COMPLETED"
+	DRIVE_CANCELLED   = "CANCELLED"
+	DRIVE_POSTPONED   = "POSTPONED"
+)
+
+// DriveStatusTransitions lists the states a drive may move to from its current state
+var DriveStatusTransitions = map[string][]string{
+	DRIVE_SCHEDULED:   {DRIVE_IN_PROGRESS, DRIVE_POSTPONED, DRIVE_CANCELLED},
+	DRIVE_POSTPONED:   {DRIVE_SCHEDULED, DRIVE_CANCELLED},
+	DRIVE_IN_PROGRESS: {DRIVE_COMPLETED, DRIVE_CANCELLED},
+	DRIVE_COMPLETED:   {},
+	DRIVE_CANCELLED:   {},
+}
+
 type VaccineInventory struct {
-	Id          int       `json:"id"`
-	VaccineName string    `json:"vaccine_name"`
-	DriveDate   time.Time `json:"drive_date"`
-	Doses       int       `json:"doses"`
-	Classes     string    `gorm:"type:text"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Id                 int       `json:"id"`
+	VaccineId          int       `json:"vaccine_id"`
+	VaccineName        string    `json:"vaccine_name"`
+	DriveDate          time.Time `json:"drive_date"`
+	Doses              int       `json:"doses"`
+	DosesUsed          int       `json:"doses_used"`
+	DosesWasted        int       `json:"doses_wasted"`
+	Classes            Grades    `json:"classes" gorm:"type:text"`
+	Status             string    `json:"status"`
+	CancellationReason string    `json:"cancellation_reason,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// RemainingDoses is the allocated stock less what has been administered or wasted
+func (v VaccineInventory) RemainingDoses() int {
+	return v.Doses - v.DosesUsed - v.DosesWasted
 }
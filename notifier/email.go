@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"school_vaccination_portal/config"
+	"strings"
+)
+
+// SMTPNotifier sends plain text mail through an SMTP relay
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func (s *SMTPNotifier) Send(msg Message) error {
+	headers := []string{
+		fmt.Sprintf("From: %s", s.from),
+		fmt.Sprintf("To: %s", msg.To),
+		fmt.Sprintf("Subject: %s", msg.Subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(body))
+}
+
+func NewSMTPNotifier(cfg config.SMTPConfig) Notifier {
+	notifier := &SMTPNotifier{
+		addr: net.JoinHostPort(cfg.Host, fmt.Sprint(cfg.Port)),
+		from: cfg.From,
+	}
+	if cfg.User != "" {
+		notifier.auth = smtp.PlainAuth("", cfg.User, cfg.Password, cfg.Host)
+	}
+	return notifier
+}
@@ -0,0 +1,34 @@
+package notifier
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileNotifier appends messages to a local file instead of sending them, for development and testing
+type FileNotifier struct {
+	channel string
+	path    string
+	mu      sync.Mutex
+	logger  *slog.Logger
+}
+
+func (f *FileNotifier) Send(msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logger.Info("notification written to file", "channel", f.channel, "recipient", msg.To, "subject", msg.Subject)
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "%s [%s] to: %s\nsubject: %s\n%s\n\n", time.Now().Format(time.RFC3339), f.channel, msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+func NewFileNotifier(channel, path string, logger *slog.Logger) Notifier {
+	return &FileNotifier{channel: channel, path: path, logger: logger}
+}
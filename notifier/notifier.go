@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/models"
+)
+
+// Message is a rendered notification ready to hand to a provider
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages over one channel
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// NewNotifiers picks a provider per channel from the configuration,
+// falling back to the file provider so nothing is sent by accident
+func NewNotifiers(cfg config.NotificationConfig, logger *slog.Logger) map[string]Notifier {
+	notifiers := map[string]Notifier{}
+	switch cfg.SMSProvider {
+	case "gateway":
+		notifiers[models.CHANNEL_SMS] = NewSMSGatewayNotifier(cfg.SMSGateway)
+	default:
+		notifiers[models.CHANNEL_SMS] = NewFileNotifier(models.CHANNEL_SMS, cfg.LogFile, logger)
+	}
+	switch cfg.EmailProvider {
+	case "smtp":
+		notifiers[models.CHANNEL_EMAIL] = NewSMTPNotifier(cfg.SMTP)
+	default:
+		notifiers[models.CHANNEL_EMAIL] = NewFileNotifier(models.CHANNEL_EMAIL, cfg.LogFile, logger)
+	}
+	logger.Info("notification providers", "sms", fmt.Sprintf("%T", notifiers[models.CHANNEL_SMS]), "email", fmt.Sprintf("%T", notifiers[models.CHANNEL_EMAIL]))
+	return notifiers
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"school_vaccination_portal/config"
+	"time"
+)
+
+// SMSGatewayNotifier posts messages to an HTTP SMS gateway
+type SMSGatewayNotifier struct {
+	url    string
+	apiKey string
+	sender string
+	client *http.Client
+}
+
+func (s *SMSGatewayNotifier) Send(msg Message) error {
+	body, _ := json.Marshal(map[string]string{
+		"to":      msg.To,
+		"from":    s.sender,
+		"message": msg.Body,
+	})
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms gateway responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func NewSMSGatewayNotifier(cfg config.SMSGateway) Notifier {
+	return &SMSGatewayNotifier{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		sender: cfg.SenderId,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"school_vaccination_portal/models"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*/*.tmpl
+var templateFiles embed.FS
+
+const DEFAULT_LANGUAGE = "en"
+
+// TemplateData is what every notification template can refer to
+type TemplateData struct {
+	SchoolName     string
+	GuardianName   string
+	StudentName    string
+	VaccineName    string
+	DriveDate      string
+	DoseNumber     int
+	AdministeredAt string
+	LotNumber      string
+}
+
+// Render fills the template for the notification type in the guardian's language,
+// using English when there is no variant for that language
+func Render(notificationType, language, channel string, data TemplateData) (string, string, error) {
+	name := strings.ToLower(notificationType) + ".tmpl"
+	content, err := templateFiles.ReadFile(fmt.Sprintf("templates/%s/%s", strings.ToLower(language), name))
+	if err != nil {
+		content, err = templateFiles.ReadFile(fmt.Sprintf("templates/%s/%s", DEFAULT_LANGUAGE, name))
+		if err != nil {
+			return "", "", fmt.Errorf("no template for %s", notificationType)
+		}
+	}
+	tmpl, err := template.New(name).Parse(string(content))
+	if err != nil {
+		return "", "", err
+	}
+	subject := new(bytes.Buffer)
+	if err = tmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return "", "", err
+	}
+	bodyTemplate := "email"
+	if channel == models.CHANNEL_SMS {
+		bodyTemplate = "sms"
+	}
+	body := new(bytes.Buffer)
+	if err = tmpl.ExecuteTemplate(body, bodyTemplate, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
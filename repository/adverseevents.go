@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type AdverseEventRepositoryHandler interface {
+	CreateAdverseEvent(ctx context.Context, event *models.AdverseEvent) error
+	GetAdverseEvents(ctx context.Context, filter string, pagination requests.Pagination) ([]models.AdverseEvent, error)
+	GetAdverseEventCount(ctx context.Context, filter string) (int, error)
+	GetAdverseEventSummary(ctx context.Context, filter string) ([]models.AdverseEventSummary, error)
+	GetDriveDoses(ctx context.Context, driveIds []int, lotNumber string) ([]models.AdverseEventSummary, error)
+}
+
+type AdverseEventRepository struct {
+	DB *mysql.MysqlConnect
+}
+
+func (r *AdverseEventRepository) CreateAdverseEvent(ctx context.Context, event *models.AdverseEvent) error {
+	return r.DB.WithContext(ctx).Table("adverse_events").Create(event).Error
+}
+
+func (r *AdverseEventRepository) GetAdverseEvents(ctx context.Context, filter string, pagination requests.Pagination) ([]models.AdverseEvent, error) {
+	events := []models.AdverseEvent{}
+	query := r.DB.WithContext(ctx).Table("adverse_events a").Order("a.id ASC")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	if pagination.Limit != 0 {
+		query = query.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+	return events, query.Find(&events).Error
+}
+
+func (r *AdverseEventRepository) GetAdverseEventCount(ctx context.Context, filter string) (int, error) {
+	count := 0
+	query := r.DB.WithContext(ctx).Table("adverse_events a")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return count, query.Count(&count).Error
+}
+
+// groups events by drive and lot, alongside the active doses given from that lot so rates can be compared
+func (r *AdverseEventRepository) GetAdverseEventSummary(ctx context.Context, filter string) ([]models.AdverseEventSummary, error) {
+	summary := []models.AdverseEventSummary{}
+	query := r.DB.WithContext(ctx).Table("adverse_events a").
+		Select(`a.drive_id, d.vaccine_name, a.lot_number,
+			(SELECT COUNT(*) FROM student_vaccination_records r WHERE r.drive_id = a.drive_id AND r.lot_number = a.lot_number AND r.status = 'ACTIVE') AS doses_administered,
+			COUNT(*) AS total_events,
+			SUM(a.severity = 'MILD') AS mild,
+			SUM(a.severity = 'MODERATE') AS moderate,
+			SUM(a.severity = 'SEVERE') AS severe,
+			SUM(a.severity = 'SERIOUS') AS serious`).
+		Joins("INNER JOIN vaccination_inventory d ON d.id = a.drive_id")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return summary, query.Group("a.drive_id, d.vaccine_name, a.lot_number").
+		Order("a.drive_id ASC, a.lot_number ASC").
+		Scan(&summary).Error
+}
+
+// counts the active doses given at each drive over all its lots, whether or not events were reported against them,
+// or over one lot when a lot number is given
+func (r *AdverseEventRepository) GetDriveDoses(ctx context.Context, driveIds []int, lotNumber string) ([]models.AdverseEventSummary, error) {
+	doses := []models.AdverseEventSummary{}
+	if len(driveIds) == 0 {
+		return doses, nil
+	}
+	query := r.DB.WithContext(ctx).Table("student_vaccination_records r").
+		Select("r.drive_id, COUNT(*) AS doses_administered").
+		Where("r.drive_id IN (?) AND r.status = ?", driveIds, models.RECORD_ACTIVE)
+	if lotNumber != "" {
+		query = query.Where("r.lot_number = ?", lotNumber)
+	}
+	return doses, query.Group("r.drive_id").
+		Scan(&doses).Error
+}
+
+func NewAdverseEventRepositoryHandler(db *mysql.MysqlConnect) AdverseEventRepositoryHandler {
+	return &AdverseEventRepository{
+		DB: db,
+	}
+}
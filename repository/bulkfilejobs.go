@@ -5,76 +5,97 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"school_vaccination_portal/databases/mysql"
 	"school_vaccination_portal/databases/rabbitmq"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
+	"school_vaccination_portal/tracing"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/streadway/amqp"
 )
 
 type BulkFileJobsRepositoryHandler interface {
-	UploadFileToMinio(filePath, bucketName, root, uniqueId string) (string, error)
-	CreateFileUpload(model *models.BulkFileJobsModel) error
-	SubmitToRabbitMQ(rmqData *models.BulkFileJobsModel, queueName string) error
-	UpdateFileUpload(model *models.BulkFileJobsModel) error
-	GetFileFromActiveServer(bucketName, fileLocation string) (string, error)
-	GetBulkFileJobs(requestId string, pagination requests.Pagination) ([]models.BulkFileJobsModel, error)
-	GetBulkFileJobCounts(requestId string, pagination requests.Pagination) (int, error)
+	UploadFileToMinio(ctx context.Context, filePath, bucketName, root, uniqueId string) (string, error)
+	RemoveFileFromMinio(ctx context.Context, bucketName, objectName string) error
+	CreateFileUpload(ctx context.Context, model *models.BulkFileJobsModel) error
+	SubmitToRabbitMQ(ctx context.Context, rmqData *models.BulkFileJobsModel) error
+	UpdateFileUpload(ctx context.Context, model *models.BulkFileJobsModel) error
+	GetFileFromActiveServer(ctx context.Context, bucketName, fileLocation string) (string, error)
+	GetBulkFileJobs(ctx context.Context, requestId string, pagination requests.Pagination) ([]models.BulkFileJobsModel, error)
+	GetBulkFileJobCounts(ctx context.Context, requestId string, pagination requests.Pagination) (int, error)
+	GetBulkFileJobStatusCounts(ctx context.Context) ([]models.BulkFileJobCount, error)
 }
 
 type BulkFileJobsRepository struct {
 	MinIoConn *minio.Client
 	DB        *mysql.MysqlConnect
 	Rabbit    *rabbitmq.RabbitChannel
+	Queue     string
+	Logger    *slog.Logger
 }
 
-func (b *BulkFileJobsRepository) UploadFileToMinio(filePath, bucketName, root, uniqueId string) (string, error) {
-	uploadInfo, err := b.MinIoConn.FPutObject(context.Background(), bucketName, fmt.Sprintf("%s%s/%s", root, uniqueId, filepath.Base(filePath)), filePath, minio.PutObjectOptions{})
+func (b *BulkFileJobsRepository) UploadFileToMinio(ctx context.Context, filePath, bucketName, root, uniqueId string) (string, error) {
+	uploadInfo, err := b.MinIoConn.FPutObject(ctx, bucketName, fmt.Sprintf("%s%s/%s", root, uniqueId, filepath.Base(filePath)), filePath, minio.PutObjectOptions{})
 	os.Remove(filePath)
 	return uploadInfo.Key, err
 }
-func (b *BulkFileJobsRepository) GetFileFromActiveServer(bucketName, fileLocation string) (string, error) {
-	object, err := b.MinIoConn.GetObject(context.Background(), bucketName, fileLocation, minio.GetObjectOptions{})
+
+// RemoveFileFromMinio deletes an uploaded object whose records could not be saved
+func (b *BulkFileJobsRepository) RemoveFileFromMinio(ctx context.Context, bucketName, objectName string) error {
+	return b.MinIoConn.RemoveObject(ctx, bucketName, objectName, minio.RemoveObjectOptions{})
+}
+
+func (b *BulkFileJobsRepository) GetFileFromActiveServer(ctx context.Context, bucketName, fileLocation string) (string, error) {
+	object, err := b.MinIoConn.GetObject(ctx, bucketName, fileLocation, minio.GetObjectOptions{})
 	if err != nil {
-		log.Println("Error in fetching object from server", err.Error())
+		b.Logger.Error("error fetching object from minio", "object", fileLocation, logging.Err(err))
 		return "", err
 	}
 	defer object.Close()
 	//create localFile
 	tempFile, err := os.CreateTemp("", "school-vaccine-bulk-*")
-	defer tempFile.Close()
 	if err != nil {
-		log.Println("error Creating temporary file for processing bulk request", err.Error())
+		b.Logger.Error("error creating temporary file for processing bulk request", logging.Err(err))
 		return "", err
 	}
+	defer tempFile.Close()
 	_, err = io.Copy(tempFile, object)
 	if err != nil {
-		log.Println("error copying  temporary file for processing bulk request", err.Error())
+		b.Logger.Error("error copying temporary file for processing bulk request", logging.Err(err))
+		os.Remove(tempFile.Name())
 		return "", err
 	}
+	//the caller removes the file once it has read it
 	return tempFile.Name(), nil
 }
-func (b *BulkFileJobsRepository) CreateFileUpload(model *models.BulkFileJobsModel) error {
-	return b.DB.Table("bulk_file_jobs").Create(model).Error
+func (b *BulkFileJobsRepository) CreateFileUpload(ctx context.Context, model *models.BulkFileJobsModel) error {
+	return b.DB.WithContext(ctx).Table("bulk_file_jobs").Create(model).Error
 }
 
-func (b *BulkFileJobsRepository) SubmitToRabbitMQ(rmqData *models.BulkFileJobsModel, queueName string) error {
+func (b *BulkFileJobsRepository) SubmitToRabbitMQ(ctx context.Context, rmqData *models.BulkFileJobsModel) error {
 	body, _ := json.Marshal(rmqData)
-	return b.Rabbit.Publish(
-		"", "async-file-processing-queue", false, false, amqp.Publishing{
-			DeliveryMode: 2,
-			ContentType:  "text/plain",
-			Body:         body,
+	headers := amqp.Table{logging.HEADER_REQUEST_ID: rmqData.CorrelationId}
+	_, span := tracing.StartPublish(ctx, b.Queue, headers)
+	defer span.End()
+	err := b.Rabbit.Publish(
+		"", b.Queue, false, false, amqp.Publishing{
+			DeliveryMode:  2,
+			ContentType:   "text/plain",
+			CorrelationId: rmqData.CorrelationId,
+			Headers:       headers,
+			Body:          body,
 		},
 	)
+	tracing.RecordError(span, err)
+	return err
 }
 
-func (b *BulkFileJobsRepository) UpdateFileUpload(model *models.BulkFileJobsModel) error {
+func (b *BulkFileJobsRepository) UpdateFileUpload(ctx context.Context, model *models.BulkFileJobsModel) error {
 	updates := map[string]interface{}{}
 	if model.Status != "" {
 		updates["status"] = model.Status
@@ -91,21 +112,19 @@ func (b *BulkFileJobsRepository) UpdateFileUpload(model *models.BulkFileJobsMode
 	if model.ErrorMessage != "" {
 		updates["error_message"] = model.ErrorMessage
 	}
-	return b.DB.Table("bulk_file_jobs").Updates(updates).Where("id = ?", model.Id).Error
+	return b.DB.WithContext(ctx).Table("bulk_file_jobs").Where("id = ?", model.Id).Updates(updates).Error
 }
-func (b *BulkFileJobsRepository) GetBulkFileJobs(requestId string, pagination requests.Pagination) ([]models.BulkFileJobsModel, error) {
+func (b *BulkFileJobsRepository) GetBulkFileJobs(ctx context.Context, requestId string, pagination requests.Pagination) ([]models.BulkFileJobsModel, error) {
 	result := []models.BulkFileJobsModel{}
 	var err error
 	if requestId == "" {
-		log.Println("fetching bulkupload status requestes with no requestId")
-		err = b.DB.Table("bulk_file_jobs").
+		err = b.DB.WithContext(ctx).Table("bulk_file_jobs").
 			Order("id ASC").
 			Limit(pagination.Limit).
 			Offset(pagination.Offset).
 			Find(&result).Error
 	} else {
-		log.Println("fetching bulkupload status requestes with requestId", requestId)
-		err = b.DB.Table("bulk_file_jobs").
+		err = b.DB.WithContext(ctx).Table("bulk_file_jobs").
 			Order("id ASC").
 			Where("request_id =?", requestId).
 			Limit(pagination.Limit).
@@ -115,27 +134,36 @@ func (b *BulkFileJobsRepository) GetBulkFileJobs(requestId string, pagination re
 	return result, err
 }
 
-func (b *BulkFileJobsRepository) GetBulkFileJobCounts(requestId string, pagination requests.Pagination) (int, error) {
+func (b *BulkFileJobsRepository) GetBulkFileJobCounts(ctx context.Context, requestId string, pagination requests.Pagination) (int, error) {
 	var result int
 	var err error
 	if requestId == "" {
-		log.Println("fetching bulkupload status request count with no requestId")
-		err = b.DB.Table("bulk_file_jobs").
+		err = b.DB.WithContext(ctx).Table("bulk_file_jobs").
 			Count(&result).Error
 	} else {
-		log.Println("fetching bulkupload status request count with no requestId")
-
-		err = b.DB.Table("bulk_file_jobs").
+		err = b.DB.WithContext(ctx).Table("bulk_file_jobs").
 			Where("request_id = ?", requestId).
 			Count(&result).Error
 	}
 	return result, err
 }
 
-func NewBulkFileJobsRepositoryHandler(DB *mysql.MysqlConnect, MinIO *minio.Client, rabbit *rabbitmq.RabbitChannel) BulkFileJobsRepositoryHandler {
+func (b *BulkFileJobsRepository) GetBulkFileJobStatusCounts(ctx context.Context) ([]models.BulkFileJobCount, error) {
+	result := []models.BulkFileJobCount{}
+	err := b.DB.WithContext(ctx).Table("bulk_file_jobs").
+		Select("request_type, status, COUNT(*) AS count").
+		Group("request_type, status").
+		Scan(&result).Error
+	return result, err
+}
+
+// queue is where bulk jobs wait for the bulk worker
+func NewBulkFileJobsRepositoryHandler(DB *mysql.MysqlConnect, MinIO *minio.Client, rabbit *rabbitmq.RabbitChannel, queue string, logger *slog.Logger) BulkFileJobsRepositoryHandler {
 	return &BulkFileJobsRepository{
 		DB:        DB,
 		MinIoConn: MinIO,
 		Rabbit:    rabbit,
+		Queue:     queue,
+		Logger:    logger,
 	}
 }
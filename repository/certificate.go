@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/models"
+	"time"
+)
+
+type CertificateRepositoryHandler interface {
+	CreateCertificate(ctx context.Context, certificate *models.Certificate) error
+	UpdateCertificateFile(ctx context.Context, id int, filePath string) error
+	RevokeCertificate(ctx context.Context, id int, reason string) error
+	GetCertificates(ctx context.Context, filter string) ([]models.Certificate, error)
+	GetCertificateDoses(ctx context.Context, studentIds []int) ([]models.CertificateDose, error)
+}
+
+type CertificateRepository struct {
+	DB *mysql.MysqlConnect
+}
+
+func (r *CertificateRepository) CreateCertificate(ctx context.Context, certificate *models.Certificate) error {
+	return r.DB.WithContext(ctx).Table("certificates").Create(certificate).Error
+}
+
+func (r *CertificateRepository) UpdateCertificateFile(ctx context.Context, id int, filePath string) error {
+	return r.DB.WithContext(ctx).Table("certificates").Where(fmt.Sprintf("id = %d", id)).Update("file_path", filePath).Error
+}
+
+func (r *CertificateRepository) RevokeCertificate(ctx context.Context, id int, reason string) error {
+	return r.DB.WithContext(ctx).Table("certificates").Where(fmt.Sprintf("id = %d", id)).Updates(map[string]interface{}{
+		"status":         models.CERTIFICATE_REVOKED,
+		"revoked_at":     time.Now(),
+		"revoked_reason": reason,
+		"updated_at":     time.Now(),
+	}).Error
+}
+
+func (r *CertificateRepository) GetCertificates(ctx context.Context, filter string) ([]models.Certificate, error) {
+	certificates := []models.Certificate{}
+	query := r.DB.WithContext(ctx).Table("certificates").Order("id DESC")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return certificates, query.Find(&certificates).Error
+}
+
+// every active dose of the students, with the vaccine name from the catalog and the drive date for records without an administration time
+func (r *CertificateRepository) GetCertificateDoses(ctx context.Context, studentIds []int) ([]models.CertificateDose, error) {
+	doses := []models.CertificateDose{}
+	if len(studentIds) == 0 {
+		return doses, nil
+	}
+	return doses, r.DB.WithContext(ctx).Table("student_vaccination_records v").
+		Select("v.student_id, v.id AS record_id, COALESCE(vac.name, d.vaccine_name) AS vaccine_name, v.dose_number, COALESCE(v.administered_at, d.drive_date) AS administered_at, v.lot_number, v.drive_id").
+		Joins("INNER JOIN vaccination_inventory d ON d.id = v.drive_id").
+		Joins("LEFT JOIN vaccines vac ON vac.id = v.vaccine_id").
+		Where("v.status = ? AND v.student_id IN (?)", models.RECORD_ACTIVE, studentIds).
+		Order("v.student_id ASC, vaccine_name ASC, v.dose_number ASC").
+		Find(&doses).Error
+}
+
+func NewCertificateRepositoryHandler(db *mysql.MysqlConnect) CertificateRepositoryHandler {
+	return &CertificateRepository{
+		DB: db,
+	}
+}
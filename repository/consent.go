@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type ConsentRepositoryHandler interface {
+	CreateConsent(ctx context.Context, consent *models.Consent) error
+	UpdateConsent(ctx context.Context, consent *requests.ConsentUpdateRequest) error
+	GetConsents(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Consent, error)
+	GetConsentCount(ctx context.Context, filter string) (int, error)
+	GetEffectiveConsent(ctx context.Context, studentId, driveId, vaccineId int) ([]models.Consent, error)
+}
+
+type ConsentRepository struct {
+	DB *mysql.MysqlConnect
+}
+
+func (r *ConsentRepository) CreateConsent(ctx context.Context, consent *models.Consent) error {
+	return r.DB.WithContext(ctx).Table("consents").Create(consent).Error
+}
+
+func (r *ConsentRepository) UpdateConsent(ctx context.Context, consent *requests.ConsentUpdateRequest) error {
+	updateMap := map[string]interface{}{}
+	if consent.Status != nil {
+		updateMap["status"] = consent.Status
+	}
+	if consent.GuardianName != nil {
+		updateMap["guardian_name"] = consent.GuardianName
+	}
+	if consent.ConsentDate != nil {
+		updateMap["consent_date"] = consent.ConsentDate
+	}
+	if consent.FormPath != "" {
+		updateMap["form_path"] = consent.FormPath
+	}
+	return r.DB.WithContext(ctx).Table("consents").Where(fmt.Sprintf("id = %d", consent.Id)).Updates(updateMap).Error
+}
+
+func (r *ConsentRepository) GetConsents(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Consent, error) {
+	consents := []models.Consent{}
+	query := r.DB.WithContext(ctx).Table("consents").Order("id ASC")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	if pagination.Limit != 0 {
+		query = query.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+	return consents, query.Find(&consents).Error
+}
+
+func (r *ConsentRepository) GetConsentCount(ctx context.Context, filter string) (int, error) {
+	count := 0
+	query := r.DB.WithContext(ctx).Table("consents")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return count, query.Count(&count).Error
+}
+
+// the latest consent that applies to the drive, a drive specific consent wins over one given for the vaccine
+func (r *ConsentRepository) GetEffectiveConsent(ctx context.Context, studentId, driveId, vaccineId int) ([]models.Consent, error) {
+	consents := []models.Consent{}
+	return consents, r.DB.WithContext(ctx).Table("consents").
+		Where("student_id = ? AND (drive_id = ? OR (drive_id = 0 AND vaccine_id = ?))", studentId, driveId, vaccineId).
+		Order(fmt.Sprintf("drive_id = %d DESC, consent_date DESC, id DESC", driveId)).
+		Limit(1).
+		Find(&consents).Error
+}
+
+func NewConsentRepositoryHandler(db *mysql.MysqlConnect) ConsentRepositoryHandler {
+	return &ConsentRepository{
+		DB: db,
+	}
+}
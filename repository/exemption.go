@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+// ACTIVE_EXEMPTION_FILTER matches exemptions that have not expired, against the exemptions table aliased e
+const ACTIVE_EXEMPTION_FILTER = "(e.expiry_date IS NULL OR e.expiry_date >= CURDATE())"
+
+type ExemptionRepositoryHandler interface {
+	CreateExemption(ctx context.Context, exemption *models.Exemption) error
+	GetExemptions(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Exemption, error)
+	GetExemptionCount(ctx context.Context, filter string) (int, error)
+	DeleteExemption(ctx context.Context, id int) error
+}
+
+type ExemptionRepository struct {
+	DB *mysql.MysqlConnect
+}
+
+func (r *ExemptionRepository) CreateExemption(ctx context.Context, exemption *models.Exemption) error {
+	return r.DB.WithContext(ctx).Table("exemptions").Omit("vaccine_name").Create(exemption).Error
+}
+
+func (r *ExemptionRepository) GetExemptions(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Exemption, error) {
+	exemptions := []models.Exemption{}
+	query := r.DB.WithContext(ctx).Table("exemptions e").
+		Select("e.*, vac.name AS vaccine_name").
+		Joins("LEFT JOIN vaccines vac ON vac.id = e.vaccine_id").
+		Order("e.id ASC")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	if pagination.Limit != 0 {
+		query = query.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+	return exemptions, query.Find(&exemptions).Error
+}
+
+func (r *ExemptionRepository) GetExemptionCount(ctx context.Context, filter string) (int, error) {
+	count := 0
+	query := r.DB.WithContext(ctx).Table("exemptions e")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return count, query.Count(&count).Error
+}
+
+func (r *ExemptionRepository) DeleteExemption(ctx context.Context, id int) error {
+	return r.DB.WithContext(ctx).Table("exemptions").Where(fmt.Sprintf("id = %d", id)).Delete(&models.Exemption{}).Error
+}
+
+func NewExemptionRepositoryHandler(db *mysql.MysqlConnect) ExemptionRepositoryHandler {
+	return &ExemptionRepository{
+		DB: db,
+	}
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type GuardianRepositoryHandler interface {
+	CreateGuardian(ctx context.Context, guardian *models.Guardian) error
+	UpdateGuardian(ctx context.Context, guardian *requests.GuardianUpdateRequest) error
+	GetGuardians(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Guardian, error)
+	GetGuardianCount(ctx context.Context, filter string) (int, error)
+	DeleteGuardian(ctx context.Context, id int) error
+	LinkStudents(ctx context.Context, guardianId int, studentIds []int) error
+	UnlinkStudent(ctx context.Context, guardianId, studentId int) error
+	GetLinkedStudents(ctx context.Context, guardianIds []int) ([]models.StudentGuardian, error)
+	GetGuardianContacts(ctx context.Context, studentIds []int) ([]models.GuardianContact, error)
+}
+
+type GuardianRepository struct {
+	DB *mysql.MysqlConnect
+}
+
+// the guardian and its student links are written together, a guardian is never left half linked
+func (r *GuardianRepository) CreateGuardian(ctx context.Context, guardian *models.Guardian) error {
+	return r.DB.Transaction(ctx, func(ctx context.Context) error {
+		tx := r.DB.WithContext(ctx)
+		if err := tx.Table("guardians").Create(guardian).Error; err != nil {
+			return err
+		}
+		return linkStudents(tx, guardian.Id, guardian.StudentIds)
+	})
+}
+
+func (r *GuardianRepository) UpdateGuardian(ctx context.Context, guardian *requests.GuardianUpdateRequest) error {
+	updateMap := map[string]interface{}{}
+	if guardian.Name != nil {
+		updateMap["name"] = guardian.Name
+	}
+	if guardian.Relationship != nil {
+		updateMap["relationship"] = guardian.Relationship
+	}
+	if guardian.PhonePrimary != nil {
+		updateMap["phone_primary"] = guardian.PhonePrimary
+	}
+	if guardian.PhoneSecondary != nil {
+		updateMap["phone_secondary"] = guardian.PhoneSecondary
+	}
+	if guardian.Email != nil {
+		updateMap["email"] = guardian.Email
+	}
+	if guardian.PreferredLanguage != nil {
+		updateMap["preferred_language"] = guardian.PreferredLanguage
+	}
+	if guardian.NotificationOptIn != nil {
+		updateMap["notification_opt_in"] = guardian.NotificationOptIn
+	}
+	return r.DB.WithContext(ctx).Table("guardians").Where(fmt.Sprintf("id = %d", guardian.Id)).Updates(updateMap).Error
+}
+
+func (r *GuardianRepository) GetGuardians(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Guardian, error) {
+	guardians := []models.Guardian{}
+	query := r.DB.WithContext(ctx).Table("guardians g").Select("g.*").Order("g.id ASC")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	if pagination.Limit != 0 {
+		query = query.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+	return guardians, query.Find(&guardians).Error
+}
+
+func (r *GuardianRepository) GetGuardianCount(ctx context.Context, filter string) (int, error) {
+	count := 0
+	query := r.DB.WithContext(ctx).Table("guardians g")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return count, query.Count(&count).Error
+}
+
+func (r *GuardianRepository) DeleteGuardian(ctx context.Context, id int) error {
+	return r.DB.Transaction(ctx, func(ctx context.Context) error {
+		tx := r.DB.WithContext(ctx)
+		if err := tx.Exec("DELETE FROM student_guardians WHERE guardian_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM guardians WHERE id = ?", id).Error
+	})
+}
+
+func (r *GuardianRepository) LinkStudents(ctx context.Context, guardianId int, studentIds []int) error {
+	return linkStudents(r.DB.WithContext(ctx), guardianId, studentIds)
+}
+
+// links that already exist are left as they are
+func linkStudents(db *gorm.DB, guardianId int, studentIds []int) error {
+	for _, studentId := range studentIds {
+		if err := db.Exec("INSERT IGNORE INTO student_guardians (student_id, guardian_id, created_at) VALUES (?, ?, ?)", studentId, guardianId, time.Now()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *GuardianRepository) UnlinkStudent(ctx context.Context, guardianId, studentId int) error {
+	return r.DB.WithContext(ctx).Exec("DELETE FROM student_guardians WHERE guardian_id = ? AND student_id = ?", guardianId, studentId).Error
+}
+
+func (r *GuardianRepository) GetLinkedStudents(ctx context.Context, guardianIds []int) ([]models.StudentGuardian, error) {
+	links := []models.StudentGuardian{}
+	if len(guardianIds) == 0 {
+		return links, nil
+	}
+	return links, r.DB.WithContext(ctx).Table("student_guardians").Where("guardian_id IN (?)", guardianIds).Order("student_id ASC").Find(&links).Error
+}
+
+// guardians of the students who have opted in to notifications, one row per student and guardian
+func (r *GuardianRepository) GetGuardianContacts(ctx context.Context, studentIds []int) ([]models.GuardianContact, error) {
+	contacts := []models.GuardianContact{}
+	if len(studentIds) == 0 {
+		return contacts, nil
+	}
+	return contacts, r.DB.WithContext(ctx).Table("student_guardians sg").
+		Select("sg.student_id, g.id AS guardian_id, g.name, g.phone_primary, g.email, g.preferred_language").
+		Joins("INNER JOIN guardians g ON g.id = sg.guardian_id").
+		Where("sg.student_id IN (?) AND g.notification_opt_in = 1", studentIds).
+		Order("sg.student_id ASC, g.id ASC").
+		Find(&contacts).Error
+}
+
+func NewGuardianRepositoryHandler(db *mysql.MysqlConnect) GuardianRepositoryHandler {
+	return &GuardianRepository{
+		DB: db,
+	}
+}
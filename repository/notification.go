@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/databases/rabbitmq"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/tracing"
+	"time"
+
+	sqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/streadway/amqp"
+)
+
+type NotificationRepositoryHandler interface {
+	CreateNotification(ctx context.Context, notification *models.Notification) (bool, error)
+	GetNotifications(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Notification, error)
+	GetNotificationCount(ctx context.Context, filter string) (int, error)
+	UpdateNotificationStatus(ctx context.Context, id int, status, errorMessage string) error
+	PublishNotification(ctx context.Context, id int) error
+}
+
+type NotificationRepository struct {
+	DB     *mysql.MysqlConnect
+	Rabbit *rabbitmq.RabbitChannel
+	Queue  string
+	Logger *slog.Logger
+}
+
+// a notification already queued for the same guardian, student, drive and record is not created twice,
+// so reminders can be scheduled again safely
+func (r *NotificationRepository) CreateNotification(ctx context.Context, notification *models.Notification) (bool, error) {
+	if err := r.DB.WithContext(ctx).Table("notifications").Create(notification).Error; err != nil {
+		if dbErr, ok := err.(*sqldriver.MySQLError); ok && dbErr.Number == 1062 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *NotificationRepository) GetNotifications(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	query := r.DB.WithContext(ctx).Table("notifications").Order("id DESC")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	if pagination.Limit != 0 {
+		query = query.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+	return notifications, query.Find(&notifications).Error
+}
+
+func (r *NotificationRepository) GetNotificationCount(ctx context.Context, filter string) (int, error) {
+	count := 0
+	query := r.DB.WithContext(ctx).Table("notifications")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return count, query.Count(&count).Error
+}
+
+func (r *NotificationRepository) UpdateNotificationStatus(ctx context.Context, id int, status, errorMessage string) error {
+	updates := map[string]interface{}{
+		"status":        status,
+		"error_message": errorMessage,
+		"attempts":      gorm.Expr("attempts + 1"),
+		"updated_at":    time.Now(),
+	}
+	if status == models.NOTIFICATION_SENT {
+		updates["sent_at"] = time.Now()
+	}
+	return r.DB.WithContext(ctx).Table("notifications").Where(fmt.Sprintf("id = %d", id)).UpdateColumns(updates).Error
+}
+
+// inside a transaction the message waits for the commit, the processor would not find the notification before it.
+// A failure then can no longer reach the caller, the notification is marked FAILED so it can be retried.
+func (r *NotificationRepository) PublishNotification(ctx context.Context, id int) error {
+	if mysql.InTransaction(ctx) {
+		mysql.AfterCommit(ctx, func() {
+			ctx := mysql.WithoutTransaction(ctx)
+			if err := r.publish(ctx, id); err != nil {
+				r.Logger.Error("error publishing notification", "notification_id", id, logging.Err(err))
+				if err = r.UpdateNotificationStatus(ctx, id, models.NOTIFICATION_FAILED, "unable to queue for delivery"); err != nil {
+					r.Logger.Error("unable to mark notification failed", "notification_id", id, logging.Err(err))
+				}
+			}
+		})
+		return nil
+	}
+	return r.publish(ctx, id)
+}
+
+func (r *NotificationRepository) publish(ctx context.Context, id int) error {
+	body, _ := json.Marshal(map[string]int{"notification_id": id})
+	headers := amqp.Table{}
+	_, span := tracing.StartPublish(ctx, r.Queue, headers)
+	defer span.End()
+	err := r.Rabbit.Publish(
+		"", r.Queue, false, false, amqp.Publishing{
+			DeliveryMode: 2,
+			ContentType:  "application/json",
+			Headers:      headers,
+			Body:         body,
+		},
+	)
+	tracing.RecordError(span, err)
+	return err
+}
+
+// queue is where queued notifications wait for the notification processor
+func NewNotificationRepositoryHandler(db *mysql.MysqlConnect, rabbit *rabbitmq.RabbitChannel, queue string, logger *slog.Logger) NotificationRepositoryHandler {
+	//the queue must exist before publishing, messages sent to a missing queue are dropped
+	if _, err := rabbit.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		logger.Error("unable to declare notification queue", "queue", queue, logging.Err(err))
+	}
+	return &NotificationRepository{
+		DB:     db,
+		Rabbit: rabbit,
+		Queue:  queue,
+		Logger: logger,
+	}
+}
@@ -1,25 +1,26 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"school_vaccination_portal/databases/mysql"
 	"school_vaccination_portal/models"
 )
 
 type StudentManagementRepositoryHandler interface {
-	CreateStudentRecord(record *[]models.StudentManagement) []models.DBInsertionRecord
-	GetStudents(filter string) ([]models.StudentManagement, error)
-	UpdateStudents(student models.StudentManagement) error
+	CreateStudentRecord(ctx context.Context, record *[]models.StudentManagement) []models.DBInsertionRecord
+	GetStudents(ctx context.Context, filter string) ([]models.StudentManagement, error)
+	UpdateStudents(ctx context.Context, student models.StudentManagement) error
 }
 type StudentManagementRepository struct {
 	DB *mysql.MysqlConnect
 }
 
-func (r *StudentManagementRepository) CreateStudentRecord(record *[]models.StudentManagement) []models.DBInsertionRecord {
+func (r *StudentManagementRepository) CreateStudentRecord(ctx context.Context, record *[]models.StudentManagement) []models.DBInsertionRecord {
 	dataRecords := []models.DBInsertionRecord{}
 	for _, j := range *record {
 		dataRecord := models.DBInsertionRecord{}
-		err := r.DB.Table("student_management").Create(&j).Error
+		err := r.DB.WithContext(ctx).Table("student_management").Create(&j).Error
 		dataRecord.Record = j
 		dataRecord.Status = true
 		if err != nil {
@@ -30,18 +31,18 @@ func (r *StudentManagementRepository) CreateStudentRecord(record *[]models.Stude
 	}
 	return dataRecords
 }
-func (r *StudentManagementRepository) GetStudents(selectionString string) ([]models.StudentManagement, error) {
+func (r *StudentManagementRepository) GetStudents(ctx context.Context, selectionString string) ([]models.StudentManagement, error) {
 	dbResponse := []models.StudentManagement{}
 	var err error
 	if selectionString == "" {
-		err = r.DB.Table("student_management").Find(&dbResponse).Error
+		err = r.DB.WithContext(ctx).Table("student_management").Find(&dbResponse).Error
 		return dbResponse, err
 	} else {
-		err = r.DB.Table("student_management").Where(selectionString).Find(&dbResponse).Error
+		err = r.DB.WithContext(ctx).Table("student_management").Where(selectionString).Find(&dbResponse).Error
 		return dbResponse, err
 	}
 }
-func (r *StudentManagementRepository) UpdateStudents(student models.StudentManagement) error {
+func (r *StudentManagementRepository) UpdateStudents(ctx context.Context, student models.StudentManagement) error {
 	toupdate := map[string]interface{}{}
 
 	if student.Name != "" {
@@ -60,7 +61,7 @@ func (r *StudentManagementRepository) UpdateStudents(student models.StudentManag
 		toupdate["phone_no"] = student.PhoneNo
 	}
 	selectionstring := fmt.Sprintf("id = %d", student.Id)
-	return r.DB.Table("student_management").Where(selectionstring).Updates(toupdate).Error
+	return r.DB.WithContext(ctx).Table("student_management").Where(selectionstring).Updates(toupdate).Error
 }
 
 func NewStudentRepositoryHandler(DB *mysql.MysqlConnect) StudentManagementRepositoryHandler {
@@ -1,26 +1,38 @@
 package repository
 
 import (
+	"context"
+	"fmt"
 	"school_vaccination_portal/databases/mysql"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
+	"time"
+
+	sqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
 )
 
 type StudentVaccinationRecordRepositoryHandler interface {
-	CreateVaccinationRecord(record *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord
-	GetStudentVaccinationRecord(selectionString string, pagination requests.Pagination) ([]models.StudentVaccinationDetail, error)
-	GetStudentVaccinationRecordCount(selectionString, join string) (int, error)
+	CreateVaccinationRecord(ctx context.Context, record *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord
+	GetStudentVaccinationRecord(ctx context.Context, selectionString string, pagination requests.Pagination) ([]models.StudentVaccinationDetail, error)
+	GetStudentVaccinationRecordCount(ctx context.Context, selectionString, join string) (int, error)
+	GetStudentCount(ctx context.Context, selectionString, join string) (int, error)
+	GetStudentDoseCount(ctx context.Context, studentId, vaccineId int) (int, error)
+	GetStudentDoseHistory(ctx context.Context, studentId, vaccineId int) ([]models.StudentDoseHistory, error)
+	GetVaccinationRecords(ctx context.Context, selectionString string) ([]models.StudentVaccineRecord, error)
+	VoidVaccinationRecord(ctx context.Context, id int, reason string) error
+	SupersedeVaccinationRecord(ctx context.Context, id int, reason string, record *models.StudentVaccineRecord) error
 }
 
 type StudentVaccinationRecordReposiotry struct {
 	DB *mysql.MysqlConnect
 }
 
-func (r *StudentVaccinationRecordReposiotry) CreateVaccinationRecord(record *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord {
+func (r *StudentVaccinationRecordReposiotry) CreateVaccinationRecord(ctx context.Context, record *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord {
 	dataRecords := []models.VaccineInsertionDBRecord{}
 	for _, j := range *record {
 		dataRecord := models.VaccineInsertionDBRecord{}
-		err := r.DB.Table("student_vaccination_records").Create(&j).Error
+		err := r.insertWithDoseUsage(ctx, &j)
 		dataRecord.Record = j
 		dataRecord.Status = true
 		if err != nil {
@@ -31,53 +43,155 @@ func (r *StudentVaccinationRecordReposiotry) CreateVaccinationRecord(record *[]m
 	}
 	return dataRecords
 }
-func (r *StudentVaccinationRecordReposiotry) GetStudentVaccinationRecord(selectionString string, pagination requests.Pagination) ([]models.StudentVaccinationDetail, error) {
+
+// consumes one dose of the drive's stock and inserts the record in the same transaction,
+// so a record never exists without its dose being accounted for
+func (r *StudentVaccinationRecordReposiotry) insertWithDoseUsage(ctx context.Context, record *models.StudentVaccineRecord) error {
+	return r.DB.Transaction(ctx, func(ctx context.Context) error {
+		return consumeDoseAndInsert(r.DB.WithContext(ctx), record)
+	})
+}
+
+func consumeDoseAndInsert(tx *gorm.DB, record *models.StudentVaccineRecord) error {
+	consumed := tx.Exec("UPDATE vaccination_inventory SET doses_used = doses_used + 1 WHERE id = ? AND doses - doses_used - doses_wasted > 0", record.DriveId)
+	if consumed.Error != nil {
+		return consumed.Error
+	}
+	if consumed.RowsAffected == 0 {
+		return fmt.Errorf("no doses remaining for drive_id : %d", record.DriveId)
+	}
+	if record.Status == "" {
+		record.Status = models.RECORD_ACTIVE
+	}
+	if err := tx.Table("student_vaccination_records").Create(record).Error; err != nil {
+		if dbErr, ok := err.(*sqldriver.MySQLError); ok && dbErr.Number == 1062 {
+			return fmt.Errorf("student_id : %d already has dose %d of this vaccine or a record for drive_id : %d", record.StudentId, record.DoseNumber, record.DriveId)
+		}
+		return err
+	}
+	return nil
+}
+
+// takes an active record out of coverage and returns its dose to the drive's stock
+func releaseRecord(tx *gorm.DB, id int, status, reason string) error {
+	record := models.StudentVaccineRecord{}
+	if err := tx.Table("student_vaccination_records").Where("id = ? AND status = ?", id, models.RECORD_ACTIVE).First(&record).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return fmt.Errorf("no active vaccination record with id %d", id)
+		}
+		return err
+	}
+	if err := tx.Table("student_vaccination_records").Where("id = ?", id).Updates(map[string]interface{}{
+		"status":            status,
+		"status_reason":     reason,
+		"status_changed_at": time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Exec("UPDATE vaccination_inventory SET doses_used = doses_used - 1 WHERE id = ? AND doses_used > 0", record.DriveId).Error
+}
+
+func (r *StudentVaccinationRecordReposiotry) VoidVaccinationRecord(ctx context.Context, id int, reason string) error {
+	return r.DB.Transaction(ctx, func(ctx context.Context) error {
+		return releaseRecord(r.DB.WithContext(ctx), id, models.RECORD_VOIDED, reason)
+	})
+}
+
+// replaces a record with its correction in one transaction, linking both ways so the chain stays in the student's history
+func (r *StudentVaccinationRecordReposiotry) SupersedeVaccinationRecord(ctx context.Context, id int, reason string, record *models.StudentVaccineRecord) error {
+	return r.DB.Transaction(ctx, func(ctx context.Context) error {
+		tx := r.DB.WithContext(ctx)
+		if err := releaseRecord(tx, id, models.RECORD_SUPERSEDED, reason); err != nil {
+			return err
+		}
+		record.CorrectsRecord = id
+		if err := consumeDoseAndInsert(tx, record); err != nil {
+			return err
+		}
+		return tx.Table("student_vaccination_records").Where("id = ?", id).Update("superseded_by", record.Id).Error
+	})
+}
+
+func (r *StudentVaccinationRecordReposiotry) GetVaccinationRecords(ctx context.Context, selectionString string) ([]models.StudentVaccineRecord, error) {
+	records := []models.StudentVaccineRecord{}
+	return records, r.DB.WithContext(ctx).Table("student_vaccination_records").Where(selectionString).Order("id ASC").Find(&records).Error
+}
+func (r *StudentVaccinationRecordReposiotry) GetStudentVaccinationRecord(ctx context.Context, selectionString string, pagination requests.Pagination) ([]models.StudentVaccinationDetail, error) {
 	insertionDetails := []models.StudentVaccinationDetail{}
 	if selectionString == "" {
 		if pagination.Limit == 0 {
-			return insertionDetails, r.DB.Table("student_management s").
+			return insertionDetails, r.DB.WithContext(ctx).Table("student_management s").
 				Select("s.id AS id, s.name, s.class, s.roll_number as roll_number,s.gender,s.phone_no, v.drive_id as drive_id").
-				Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id").
+				Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id AND v.status = 'ACTIVE'").
 				Find(&insertionDetails).Error
 		}
-		return insertionDetails, r.DB.Table("student_management s").
+		return insertionDetails, r.DB.WithContext(ctx).Table("student_management s").
 			Select("s.id AS id, s.name, s.class, s.roll_number as roll_number,s.gender,s.phone_no, v.drive_id as drive_id").
 			Order("id ASC").
-			Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id").
+			Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id AND v.status = 'ACTIVE'").
 			Limit(pagination.Limit).
 			Offset(pagination.Offset).
 			Find(&insertionDetails).Error
 	}
 	if pagination.Limit == 0 {
-		return insertionDetails, r.DB.Table("student_management s").
+		return insertionDetails, r.DB.WithContext(ctx).Table("student_management s").
 			Select("s.id AS id, s.name, s.class, s.roll_number as roll_number,s.gender,s.phone_no, v.drive_id as drive_id").
-			Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id").
+			Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id AND v.status = 'ACTIVE'").
 			Where(selectionString).
 			Find(&insertionDetails).Error
 	}
-	return insertionDetails, r.DB.Table("student_management s").
+	return insertionDetails, r.DB.WithContext(ctx).Table("student_management s").
 		Select("s.id AS id, s.name, s.class, s.roll_number as roll_number,s.gender,s.phone_no, v.drive_id as drive_id").
 		Order("id ASC").
-		Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id").
+		Joins("LEFT JOIN student_vaccination_records v ON s.id = v.student_id AND v.status = 'ACTIVE'").
 		Where(selectionString).
 		Limit(pagination.Limit).
 		Offset(pagination.Offset).
 		Find(&insertionDetails).Error
 }
-func (r *StudentVaccinationRecordReposiotry) GetStudentVaccinationRecordCount(selectionString, join string) (int, error) {
+func (r *StudentVaccinationRecordReposiotry) GetStudentVaccinationRecordCount(ctx context.Context, selectionString, join string) (int, error) {
 	insertionDetails := 0
 	if selectionString == "" {
-		return insertionDetails, r.DB.Table("student_management s").
+		return insertionDetails, r.DB.WithContext(ctx).Table("student_management s").
 			Select("s.id AS id, s.name, s.class, s.roll_number,s.gender,s.phone_no, v.drive_id as drive_id").
 			Joins(join).
 			Count(&insertionDetails).Error
 	}
-	return insertionDetails, r.DB.Table("student_management s").
+	return insertionDetails, r.DB.WithContext(ctx).Table("student_management s").
 		Select("s.id AS id, s.name, s.class, s.roll_number,s.gender,s.phone_no, v.drive_id as drive_id").
 		Joins(join).
 		Where(selectionString).
 		Count(&insertionDetails).Error
 }
+
+// counts the students matching the join rather than the joined rows, a student with several doses counts once
+func (r *StudentVaccinationRecordReposiotry) GetStudentCount(ctx context.Context, selectionString, join string) (int, error) {
+	students := 0
+	query := r.DB.WithContext(ctx).Table("student_management s").Select("COUNT(DISTINCT s.id)").Joins(join)
+	if selectionString != "" {
+		query = query.Where(selectionString)
+	}
+	return students, query.Count(&students).Error
+}
+
+// counts the doses of a catalog vaccine a student has received across all drives
+func (r *StudentVaccinationRecordReposiotry) GetStudentDoseCount(ctx context.Context, studentId, vaccineId int) (int, error) {
+	count := 0
+	return count, r.DB.WithContext(ctx).Table("student_vaccination_records v").
+		Where("v.student_id = ? AND v.vaccine_id = ? AND v.status = ?", studentId, vaccineId, models.RECORD_ACTIVE).
+		Count(&count).Error
+}
+
+func (r *StudentVaccinationRecordReposiotry) GetStudentDoseHistory(ctx context.Context, studentId, vaccineId int) ([]models.StudentDoseHistory, error) {
+	history := []models.StudentDoseHistory{}
+	return history, r.DB.WithContext(ctx).Table("student_vaccination_records v").
+		Select("v.id, v.drive_id, v.dose_number, COALESCE(v.administered_at, d.drive_date) AS administered_at").
+		Joins("INNER JOIN vaccination_inventory d ON d.id = v.drive_id").
+		Where("v.student_id = ? AND v.vaccine_id = ? AND v.status = ?", studentId, vaccineId, models.RECORD_ACTIVE).
+		Order("v.dose_number ASC").
+		Find(&history).Error
+}
+
 func NewVaccineRecordRepositoryHandler(DB *mysql.MysqlConnect) StudentVaccinationRecordRepositoryHandler {
 	return &StudentVaccinationRecordReposiotry{
 		DB: DB,
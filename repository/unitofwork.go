@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"school_vaccination_portal/databases/mysql"
+)
+
+// UnitOfWorkHandler runs calls to several repositories as one transaction. The calls join it by being made with
+// the context Do passes to fn, a Do inside another only undoes its own writes when it fails.
+type UnitOfWorkHandler interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type UnitOfWork struct {
+	DB *mysql.MysqlConnect
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.DB.Transaction(ctx, fn)
+}
+
+func NewUnitOfWorkHandler(db *mysql.MysqlConnect) UnitOfWorkHandler {
+	return &UnitOfWork{
+		DB: db,
+	}
+}
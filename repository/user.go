@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/models"
+)
+
+type UserRepositoryHandler interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByUsername(ctx context.Context, username string) ([]models.User, error)
+}
+
+type UserRepository struct {
+	DB *mysql.MysqlConnect
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	return r.DB.WithContext(ctx).Table("users").Create(user).Error
+}
+
+func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) ([]models.User, error) {
+	users := []models.User{}
+	return users, r.DB.WithContext(ctx).Table("users").Where("username = ?", username).Find(&users).Error
+}
+
+func NewUserRepositoryHandler(db *mysql.MysqlConnect) UserRepositoryHandler {
+	return &UserRepository{
+		DB: db,
+	}
+}
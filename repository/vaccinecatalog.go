@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"strings"
+)
+
+type VaccineCatalogRepositoryHandler interface {
+	CreateVaccine(ctx context.Context, vaccine *models.Vaccine) error
+	GetVaccines(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Vaccine, error)
+	GetVaccineCount(ctx context.Context, filter string) (int, error)
+	GetVaccineByName(ctx context.Context, name string) ([]models.Vaccine, error)
+	UpdateVaccine(ctx context.Context, vaccine *requests.VaccineUpdateRequest) error
+	DeleteVaccine(ctx context.Context, id int) error
+}
+
+type VaccineCatalogRepository struct {
+	DB     *mysql.MysqlConnect
+	Logger *slog.Logger
+}
+
+func (v *VaccineCatalogRepository) CreateVaccine(ctx context.Context, vaccine *models.Vaccine) error {
+	return v.DB.WithContext(ctx).Table("vaccines").Create(vaccine).Error
+}
+
+func (v *VaccineCatalogRepository) GetVaccines(ctx context.Context, filter string, pagination requests.Pagination) ([]models.Vaccine, error) {
+	vaccines := []models.Vaccine{}
+	query := v.DB.WithContext(ctx).Table("vaccines").Order("id ASC")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	if pagination.Limit != 0 {
+		query = query.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+	if err := query.Find(&vaccines).Error; err != nil {
+		v.Logger.Error("error in fetching vaccines", logging.Err(err))
+		return vaccines, err
+	}
+	return vaccines, nil
+}
+
+func (v *VaccineCatalogRepository) GetVaccineCount(ctx context.Context, filter string) (int, error) {
+	count := 0
+	query := v.DB.WithContext(ctx).Table("vaccines")
+	if filter != "" {
+		query = query.Where(filter)
+	}
+	return count, query.Count(&count).Error
+}
+
+// names are matched trimmed and with the column's case-insensitive collation, so "HPV" and "hpv " are the same vaccine
+func (v *VaccineCatalogRepository) GetVaccineByName(ctx context.Context, name string) ([]models.Vaccine, error) {
+	vaccines := []models.Vaccine{}
+	return vaccines, v.DB.WithContext(ctx).Table("vaccines").Where("name = ?", strings.TrimSpace(name)).Find(&vaccines).Error
+}
+
+func (v *VaccineCatalogRepository) UpdateVaccine(ctx context.Context, vaccine *requests.VaccineUpdateRequest) error {
+	updateMap := map[string]interface{}{}
+	if vaccine.Name != nil {
+		updateMap["name"] = strings.TrimSpace(*vaccine.Name)
+	}
+	if vaccine.CvxCode != nil {
+		updateMap["cvx_code"] = vaccine.CvxCode
+	}
+	if vaccine.Manufacturer != nil {
+		updateMap["manufacturer"] = vaccine.Manufacturer
+	}
+	if vaccine.DosesInSeries != nil {
+		updateMap["doses_in_series"] = vaccine.DosesInSeries
+	}
+	if vaccine.MinIntervalDays != nil {
+		updateMap["min_interval_days"] = vaccine.MinIntervalDays
+	}
+	if vaccine.EligibleGrades != nil {
+		updateMap["eligible_grades"] = models.Grades(vaccine.EligibleGrades)
+	}
+	//drives keep a copy of the vaccine name, a rename reaches them in the same transaction
+	return v.DB.Transaction(ctx, func(ctx context.Context) error {
+		if err := v.DB.WithContext(ctx).Table("vaccines").Where(fmt.Sprintf("id = %d", vaccine.Id)).Updates(updateMap).Error; err != nil {
+			return err
+		}
+		if vaccine.Name == nil {
+			return nil
+		}
+		return v.DB.WithContext(ctx).Table("vaccination_inventory").Where(fmt.Sprintf("vaccine_id = %d", vaccine.Id)).UpdateColumn("vaccine_name", updateMap["name"]).Error
+	})
+}
+
+func (v *VaccineCatalogRepository) DeleteVaccine(ctx context.Context, id int) error {
+	return v.DB.WithContext(ctx).Table("vaccines").Where(fmt.Sprintf("id = %d", id)).Delete(&models.Vaccine{}).Error
+}
+
+func NewVaccineCatalogRepositoryHandler(db *mysql.MysqlConnect, logger *slog.Logger) VaccineCatalogRepositoryHandler {
+	return &VaccineCatalogRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
@@ -1,66 +1,91 @@
 package repository
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"school_vaccination_portal/databases/mysql"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
+
+	"github.com/jinzhu/gorm"
 )
 
 type VaccineInventoryHandler interface {
-	GetVaccineInventory(filter string) ([]models.VaccineInventory, error)
-	CreateInventory(drive *models.VaccineInventory) error
-	UpdateVaccineInventory(drive *requests.VaccineInventoryUpdateRequest) error
+	GetVaccineInventory(ctx context.Context, filter string, args ...interface{}) ([]models.VaccineInventory, error)
+	CreateInventory(ctx context.Context, drive *models.VaccineInventory) error
+	UpdateVaccineInventory(ctx context.Context, drive *requests.VaccineInventoryUpdateRequest) error
+	UpdateDriveStatus(ctx context.Context, id int, status, reason string) error
 }
 
 type Vacci struct {
-	DB *mysql.MysqlConnect
+	DB     *mysql.MysqlConnect
+	Logger *slog.Logger
 }
 
-func (v *Vacci) GetVaccineInventory(filter string) ([]models.VaccineInventory, error) {
+// args are bound to the ? placeholders of filter, values a caller was given go there rather than into filter
+func (v *Vacci) GetVaccineInventory(ctx context.Context, filter string, args ...interface{}) ([]models.VaccineInventory, error) {
 	drives := []models.VaccineInventory{}
 	var err error
 	if filter == "" {
-		err = v.DB.Table("vaccination_inventory").Order("drive_date ASC").Find(&drives).Error
+		err = v.DB.WithContext(ctx).Table("vaccination_inventory").Order("drive_date ASC").Find(&drives).Error
 		if err != nil {
-			log.Println("error in fetching drives", err.Error())
+			v.Logger.Error("error in fetching drives", logging.Err(err))
 			return drives, err
 		}
 		return drives, nil
 	}
-	fmt.Println("Filter being applied", filter)
-	err = v.DB.Table("vaccination_inventory").Where(filter).Order("drive_date ASC").Find(&drives).Error
+	v.Logger.Debug("fetching drives", "filter", filter)
+	err = v.DB.WithContext(ctx).Table("vaccination_inventory").Where(filter, args...).Order("drive_date ASC").Find(&drives).Error
 	if err != nil {
-		log.Println("error in fetching drives", err.Error())
+		v.Logger.Error("error in fetching drives", "filter", filter, logging.Err(err))
 		return drives, err
 	}
 	return drives, nil
 }
-func (v *Vacci) CreateInventory(drive *models.VaccineInventory) error {
-	return v.DB.Table("vaccination_inventory").Create(drive).Error
+func (v *Vacci) CreateInventory(ctx context.Context, drive *models.VaccineInventory) error {
+	return v.DB.WithContext(ctx).Table("vaccination_inventory").Create(drive).Error
 }
 
-func (v *Vacci) UpdateVaccineInventory(drive *requests.VaccineInventoryUpdateRequest) error {
+func (v *Vacci) UpdateVaccineInventory(ctx context.Context, drive *requests.VaccineInventoryUpdateRequest) error {
 	updateMap := map[string]interface{}{}
 
 	if drive.DriveDate != nil {
 		updateMap["drive_date"] = drive.DriveDate
 	}
-	if drive.VaccineName != nil {
-		updateMap["vaccine_name"] = drive.VaccineName
+	if drive.VaccineId != nil {
+		updateMap["vaccine_id"] = drive.VaccineId
+		updateMap["vaccine_name"] = gorm.Expr("(SELECT name FROM vaccines WHERE id = ?)", *drive.VaccineId)
 	}
 	if drive.Doses != nil {
 		updateMap["doses"] = drive.Doses
 	}
+	if drive.DosesWasted != nil {
+		updateMap["doses_wasted"] = drive.DosesWasted
+	}
 	if drive.Classes != nil {
-		updateMap["classes"] = drive.Classes
+		updateMap["classes"] = models.Grades(drive.Classes)
+	}
+	if drive.Status != nil {
+		updateMap["status"] = drive.Status
+	}
+	return v.DB.WithContext(ctx).Table("vaccination_inventory").Where(fmt.Sprintf("id = %d", drive.Id)).Updates(updateMap).Error
+}
+
+func (v *Vacci) UpdateDriveStatus(ctx context.Context, id int, status, reason string) error {
+	updateMap := map[string]interface{}{
+		"status": status,
+	}
+	if reason != "" {
+		updateMap["cancellation_reason"] = reason
 	}
-	return v.DB.Table("vaccination_inventory").Where(fmt.Sprintf("id = %d", drive.Id)).Updates(updateMap).Error
+	return v.DB.WithContext(ctx).Table("vaccination_inventory").Where(fmt.Sprintf("id = %d", id)).Updates(updateMap).Error
 }
 
-func NewVaccineInventoryHandler(db *mysql.MysqlConnect) VaccineInventoryHandler {
+func NewVaccineInventoryHandler(db *mysql.MysqlConnect, logger *slog.Logger) VaccineInventoryHandler {
 	return &Vacci{
-		DB: db,
+		DB:     db,
+		Logger: logger,
 	}
 }
@@ -0,0 +1,79 @@
+package requests
+
+import (
+	"fmt"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AdverseEventRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.AdverseEvent) error
+}
+
+type AdverseEventRequest struct{}
+
+type AdverseEventCreateRequest struct {
+	VaccinationRecordId int       `json:"vaccination_record_id" validate:"required"`
+	Severity            string    `json:"severity" validate:"required,oneof=MILD MODERATE SEVERE SERIOUS"`
+	OnsetAt             time.Time `json:"onset_at" validate:"required"`
+	Symptoms            string    `json:"symptoms" validate:"required"`
+	ActionsTaken        string    `json:"actions_taken"`
+	Outcome             string    `json:"outcome" validate:"required,oneof=RECOVERED RECOVERING NOT_RECOVERED HOSPITALISED REFERRED UNKNOWN"`
+	ReportedBy          string    `json:"reported_by" validate:"required"`
+}
+
+type GetAdverseEventRequest struct {
+	Id                  int    `param:"id"`
+	VaccinationRecordId int    `query:"vaccination_record_id"`
+	StudentId           int    `query:"student_id"`
+	DriveId             int    `query:"drive_id"`
+	LotNumber           string `query:"lot_number"`
+	Severity            string `query:"severity" validate:"omitempty,oneof=MILD MODERATE SEVERE SERIOUS"`
+	Pagination          Pagination
+}
+
+type AdverseEventReportRequest struct {
+	DriveId   int    `query:"drive_id"`
+	LotNumber string `query:"lot_number"`
+	RequestId string
+}
+
+func (r AdverseEventRequest) Bind(c echo.Context, req interface{}, model *models.AdverseEvent) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *AdverseEventCreateRequest:
+		model.VaccinationRecordId = req.(*AdverseEventCreateRequest).VaccinationRecordId
+		model.Severity = req.(*AdverseEventCreateRequest).Severity
+		model.OnsetAt = req.(*AdverseEventCreateRequest).OnsetAt.UTC()
+		model.Symptoms = req.(*AdverseEventCreateRequest).Symptoms
+		model.ActionsTaken = req.(*AdverseEventCreateRequest).ActionsTaken
+		model.Outcome = req.(*AdverseEventCreateRequest).Outcome
+		model.ReportedBy = req.(*AdverseEventCreateRequest).ReportedBy
+	case *GetAdverseEventRequest:
+		model.Id = req.(*GetAdverseEventRequest).Id
+		req.(*GetAdverseEventRequest).Pagination = GetPagination(req.(*GetAdverseEventRequest).Pagination)
+	case *AdverseEventReportRequest:
+		req.(*AdverseEventReportRequest).RequestId = uuid.NewString()
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+func NewAdverseEventRequestHandler() AdverseEventRequestHandler {
+	return AdverseEventRequest{}
+}
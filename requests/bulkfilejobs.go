@@ -3,11 +3,9 @@ package requests
 import (
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"os"
-	"path/filepath"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -28,31 +26,34 @@ type GetBulkFileRequest struct {
 func (b BulkFileJobRequest) Bind(c echo.Context, request interface{}, model *models.BulkFileJobsModel) error {
 	switch request.(type) {
 	case *BulkFileJobRequest:
+		allOrNothing := false
+		if value := c.FormValue("all_or_nothing"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid all_or_nothing %q, use true or false", value)
+			}
+			allOrNothing = parsed
+		}
 		fileHeader, err := c.FormFile("file")
 		if err != nil {
 			return errors.New("file not received")
 		}
-		src, err := fileHeader.Open()
-		if err != nil {
-			return fmt.Errorf("invalid file %s", err.Error())
-		}
-		defer src.Close()
-		tmpPath := filepath.Join(os.TempDir(), fileHeader.Filename)
-		dst, err := os.Create(tmpPath)
+		//a temp file of its own so uploads of the same name do not overwrite each other, staging removes it
+		tmpPath, err := saveUploadedFile(fileHeader)
 		if err != nil {
-			return fmt.Errorf("unable to create temp file %s", err.Error())
+			return err
 		}
-		defer dst.Close()
-		io.Copy(dst, src)
-		request.(*BulkFileJobRequest).FilePath = dst.Name()
+		request.(*BulkFileJobRequest).FilePath = tmpPath
 		model.RequestId = uuid.NewString()
+		model.CorrelationId = logging.RequestID(c)
 		model.FileName = fileHeader.Filename
-		model.FilePath = dst.Name()
+		model.FilePath = tmpPath
 		model.Status = "PENDING"
+		model.AllOrNothing = allOrNothing
 	case *GetBulkFileRequest:
 		err := c.Bind(request)
 		if err != nil {
-			log.Printf("error in binding Get Bulk Upload Request")
+			logging.From(c).Warn("error in binding get bulk upload request", logging.Err(err))
 			return err
 		}
 		request.(*GetBulkFileRequest).Pagination = GetPagination(request.(*GetBulkFileRequest).Pagination)
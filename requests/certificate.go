@@ -0,0 +1,67 @@
+package requests
+
+import (
+	"fmt"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type CertificateRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.Certificate) error
+}
+
+type CertificateRequest struct {
+	Id int `param:"id" validate:"required"`
+}
+
+type ClassCertificateRequest struct {
+	Class     string `query:"class" validate:"required"`
+	RequestId string
+}
+
+// the token is the one carried in the certificate QR code, the whole scanned link is accepted as well
+type VerifyCertificateRequest struct {
+	Token string `query:"token" json:"token" validate:"required"`
+}
+
+type RevokeCertificateRequest struct {
+	Id     int    `param:"id" validate:"required"`
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type GetRevokedCertificatesRequest struct{}
+
+type CertificateRequestProcessor struct{}
+
+func (r CertificateRequestProcessor) Bind(c echo.Context, req interface{}, model *models.Certificate) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *CertificateRequest:
+		model.StudentId = req.(*CertificateRequest).Id
+	case *RevokeCertificateRequest:
+		model.Id = req.(*RevokeCertificateRequest).Id
+		model.RevokedReason = req.(*RevokeCertificateRequest).Reason
+	case *ClassCertificateRequest:
+		req.(*ClassCertificateRequest).RequestId = uuid.NewString()
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+func NewCertificateRequestHandler() CertificateRequestHandler {
+	return CertificateRequestProcessor{}
+}
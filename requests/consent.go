@@ -0,0 +1,126 @@
+package requests
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type ConsentRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.Consent) error
+}
+
+type ConsentRequest struct{}
+
+// consent can be posted as JSON, or as multipart form data with the scanned form in the "form" field
+type ConsentCreateRequest struct {
+	StudentId    int    `json:"student_id" form:"student_id" validate:"required"`
+	DriveId      int    `json:"drive_id" form:"drive_id" validate:"required_without=VaccineId"`
+	VaccineId    int    `json:"vaccine_id" form:"vaccine_id" validate:"required_without=DriveId"`
+	Status       string `json:"status" form:"status" validate:"required,oneof=GRANTED REFUSED PENDING"`
+	GuardianId   int    `json:"guardian_id,omitempty" form:"guardian_id"`
+	GuardianName string `json:"guardian_name" form:"guardian_name" validate:"required_without=GuardianId"`
+	ConsentDate  string `json:"consent_date" form:"consent_date" validate:"required,datetime=2006-01-02"`
+}
+
+type ConsentUpdateRequest struct {
+	Id           int     `json:"id" form:"id" validate:"required"`
+	Status       *string `json:"status,omitempty" form:"status" validate:"omitempty,oneof=GRANTED REFUSED PENDING"`
+	GuardianName *string `json:"guardian_name,omitempty" form:"guardian_name"`
+	ConsentDate  *string `json:"consent_date,omitempty" form:"consent_date" validate:"omitempty,datetime=2006-01-02"`
+	FormPath     string  `json:"-" form:"-"`
+}
+
+type GetConsentRequest struct {
+	Id         int    `param:"id"`
+	StudentId  int    `query:"student_id"`
+	DriveId    int    `query:"drive_id"`
+	VaccineId  int    `query:"vaccine_id"`
+	Status     string `query:"status" validate:"omitempty,oneof=GRANTED REFUSED PENDING"`
+	Pagination Pagination
+}
+
+type PendingConsentReportRequest struct {
+	DriveId   int `query:"drive_id" validate:"required"`
+	RequestId string
+}
+
+func (r ConsentRequest) Bind(c echo.Context, req interface{}, model *models.Consent) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *ConsentCreateRequest:
+		model.StudentId = req.(*ConsentCreateRequest).StudentId
+		model.DriveId = req.(*ConsentCreateRequest).DriveId
+		model.VaccineId = req.(*ConsentCreateRequest).VaccineId
+		model.Status = req.(*ConsentCreateRequest).Status
+		model.GuardianId = req.(*ConsentCreateRequest).GuardianId
+		model.GuardianName = req.(*ConsentCreateRequest).GuardianName
+		model.ConsentDate, _ = time.Parse("2006-01-02", req.(*ConsentCreateRequest).ConsentDate)
+		if model.FormPath, err = saveConsentForm(c); err != nil {
+			return err
+		}
+	case *ConsentUpdateRequest:
+		model.Id = req.(*ConsentUpdateRequest).Id
+		if model.FormPath, err = saveConsentForm(c); err != nil {
+			return err
+		}
+		req.(*ConsentUpdateRequest).FormPath = model.FormPath
+	case *GetConsentRequest:
+		model.Id = req.(*GetConsentRequest).Id
+		req.(*GetConsentRequest).Pagination = GetPagination(req.(*GetConsentRequest).Pagination)
+	case *PendingConsentReportRequest:
+		req.(*PendingConsentReportRequest).RequestId = uuid.NewString()
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+// keeps the optional scanned consent form in a temp file until it is uploaded, JSON requests carry no form
+func saveConsentForm(c echo.Context) (string, error) {
+	fileHeader, err := c.FormFile("form")
+	if err != nil {
+		return "", nil
+	}
+	return saveUploadedFile(fileHeader)
+}
+
+func saveUploadedFile(fileHeader *multipart.FileHeader) (string, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("invalid file %s", err.Error())
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp("", "*-"+filepath.Base(fileHeader.Filename))
+	if err != nil {
+		return "", fmt.Errorf("unable to create temp file %s", err.Error())
+	}
+	defer dst.Close()
+	if _, err = io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("unable to save file %s", err.Error())
+	}
+	return dst.Name(), nil
+}
+
+func NewConsentRequestHandler() ConsentRequestHandler {
+	return ConsentRequest{}
+}
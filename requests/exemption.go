@@ -0,0 +1,80 @@
+package requests
+
+import (
+	"fmt"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ExemptionRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.Exemption) error
+}
+
+type ExemptionRequest struct{}
+
+// exemptions can be posted as JSON, or as multipart form data with the supporting document in the "document" field
+type ExemptionCreateRequest struct {
+	StudentId     int    `json:"student_id" form:"student_id" validate:"required"`
+	VaccineId     int    `json:"vaccine_id" form:"vaccine_id" validate:"required"`
+	ExemptionType string `json:"exemption_type" form:"exemption_type" validate:"required,oneof=MEDICAL RELIGIOUS"`
+	Reason        string `json:"reason" form:"reason" validate:"required"`
+	ExpiryDate    string `json:"expiry_date,omitempty" form:"expiry_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type GetExemptionRequest struct {
+	Id            int    `param:"id"`
+	StudentId     int    `query:"student_id"`
+	VaccineId     int    `query:"vaccine_id"`
+	ExemptionType string `query:"exemption_type" validate:"omitempty,oneof=MEDICAL RELIGIOUS"`
+	ActiveOnly    bool   `query:"active_only"`
+	Pagination    Pagination
+}
+
+type DeleteExemptionRequest struct {
+	Id int `param:"id" validate:"required"`
+}
+
+func (r ExemptionRequest) Bind(c echo.Context, req interface{}, model *models.Exemption) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *ExemptionCreateRequest:
+		model.StudentId = req.(*ExemptionCreateRequest).StudentId
+		model.VaccineId = req.(*ExemptionCreateRequest).VaccineId
+		model.ExemptionType = req.(*ExemptionCreateRequest).ExemptionType
+		model.Reason = req.(*ExemptionCreateRequest).Reason
+		if req.(*ExemptionCreateRequest).ExpiryDate != "" {
+			expiry, _ := time.Parse("2006-01-02", req.(*ExemptionCreateRequest).ExpiryDate)
+			model.ExpiryDate = &expiry
+		}
+		if fileHeader, err := c.FormFile("document"); err == nil {
+			if model.DocumentPath, err = saveUploadedFile(fileHeader); err != nil {
+				return err
+			}
+		}
+	case *GetExemptionRequest:
+		model.Id = req.(*GetExemptionRequest).Id
+		req.(*GetExemptionRequest).Pagination = GetPagination(req.(*GetExemptionRequest).Pagination)
+	case *DeleteExemptionRequest:
+		model.Id = req.(*DeleteExemptionRequest).Id
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+func NewExemptionRequestHandler() ExemptionRequestHandler {
+	return ExemptionRequest{}
+}
@@ -0,0 +1,55 @@
+package requests
+
+import (
+	"fmt"
+	"school_vaccination_portal/logging"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type FHIRExportRequestHandler interface {
+	Bind(c echo.Context, request interface{}) error
+}
+
+// the filters narrow the immunizations exported, patients are limited to the students those reference
+// whenever a drive or vaccine filter is given
+type FHIRExportRequest struct {
+	Format      string `query:"format" validate:"omitempty,oneof=bundle ndjson"`
+	Class       string `query:"class"`
+	StudentId   int    `query:"student_id"`
+	DriveId     int    `query:"drive_id"`
+	VaccineId   int    `query:"vaccine_id"`
+	VaccineName string `query:"vaccine_name"`
+	RequestId   string
+}
+
+type FHIRExportRequestProcessor struct{}
+
+func (r FHIRExportRequestProcessor) Bind(c echo.Context, req interface{}) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *FHIRExportRequest:
+		if req.(*FHIRExportRequest).Format == "" {
+			req.(*FHIRExportRequest).Format = "bundle"
+		}
+		req.(*FHIRExportRequest).RequestId = uuid.NewString()
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+func NewFHIRExportRequestHandler() FHIRExportRequestHandler {
+	return FHIRExportRequestProcessor{}
+}
@@ -0,0 +1,118 @@
+package requests
+
+import (
+	"fmt"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GuardianRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.Guardian) error
+}
+
+type GuardianRequest struct{}
+
+type GuardianCreateRequest struct {
+	Name              string `json:"name" validate:"required,max=255"`
+	Relationship      string `json:"relationship" validate:"required,oneof=MOTHER FATHER GUARDIAN GRANDPARENT OTHER"`
+	PhonePrimary      string `json:"phone_primary" validate:"required,max=20"`
+	PhoneSecondary    string `json:"phone_secondary,omitempty" validate:"omitempty,max=20"`
+	Email             string `json:"email,omitempty" validate:"omitempty,email"`
+	PreferredLanguage string `json:"preferred_language,omitempty" validate:"omitempty,max=10"`
+	NotificationOptIn *bool  `json:"notification_opt_in,omitempty"`
+	StudentIds        []int  `json:"student_ids" validate:"dive,min=1"`
+}
+
+type GuardianUpdateRequest struct {
+	Id                int     `json:"id" validate:"required"`
+	Name              *string `json:"name,omitempty" validate:"omitempty,max=255"`
+	Relationship      *string `json:"relationship,omitempty" validate:"omitempty,oneof=MOTHER FATHER GUARDIAN GRANDPARENT OTHER"`
+	PhonePrimary      *string `json:"phone_primary,omitempty" validate:"omitempty,max=20"`
+	PhoneSecondary    *string `json:"phone_secondary,omitempty" validate:"omitempty,max=20"`
+	Email             *string `json:"email,omitempty" validate:"omitempty,email"`
+	PreferredLanguage *string `json:"preferred_language,omitempty" validate:"omitempty,max=10"`
+	NotificationOptIn *bool   `json:"notification_opt_in,omitempty"`
+}
+
+type GetGuardianRequest struct {
+	Id         int    `param:"id"`
+	StudentId  int    `query:"student_id"`
+	Name       string `query:"name"`
+	Phone      string `query:"phone"`
+	Pagination Pagination
+}
+
+type DeleteGuardianRequest struct {
+	Id int `param:"id" validate:"required"`
+}
+
+type GuardianLinkRequest struct {
+	Id         int   `param:"id" validate:"required"`
+	StudentIds []int `json:"student_ids" validate:"required,min=1,dive,min=1"`
+}
+
+type GuardianUnlinkRequest struct {
+	Id        int `param:"id" validate:"required"`
+	StudentId int `param:"student_id" validate:"required"`
+}
+
+func (r GuardianRequest) Bind(c echo.Context, req interface{}, model *models.Guardian) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *GuardianCreateRequest:
+		*model = GuardianFromRequest(req.(*GuardianCreateRequest))
+	case *GuardianUpdateRequest:
+		model.Id = req.(*GuardianUpdateRequest).Id
+	case *GetGuardianRequest:
+		model.Id = req.(*GetGuardianRequest).Id
+		req.(*GetGuardianRequest).Pagination = GetPagination(req.(*GetGuardianRequest).Pagination)
+	case *DeleteGuardianRequest:
+		model.Id = req.(*DeleteGuardianRequest).Id
+	case *GuardianLinkRequest:
+		model.Id = req.(*GuardianLinkRequest).Id
+		model.StudentIds = req.(*GuardianLinkRequest).StudentIds
+	case *GuardianUnlinkRequest:
+		model.Id = req.(*GuardianUnlinkRequest).Id
+		model.StudentIds = []int{req.(*GuardianUnlinkRequest).StudentId}
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+// shared with the bulk import so both paths default the language and opt-in the same way
+func GuardianFromRequest(req *GuardianCreateRequest) models.Guardian {
+	guardian := models.Guardian{
+		Name:              req.Name,
+		Relationship:      req.Relationship,
+		PhonePrimary:      req.PhonePrimary,
+		PhoneSecondary:    req.PhoneSecondary,
+		Email:             req.Email,
+		PreferredLanguage: req.PreferredLanguage,
+		NotificationOptIn: true,
+		StudentIds:        req.StudentIds,
+	}
+	if guardian.PreferredLanguage == "" {
+		guardian.PreferredLanguage = "en"
+	}
+	if req.NotificationOptIn != nil {
+		guardian.NotificationOptIn = *req.NotificationOptIn
+	}
+	return guardian
+}
+
+func NewGuardianRequestHandler() GuardianRequestHandler {
+	return GuardianRequest{}
+}
@@ -0,0 +1,63 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type HL7ExportRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.BulkFileJobsModel) error
+}
+
+// an export job runs in the bulk worker, the request is kept on the job as its parameters.
+// Batch wraps the messages in one FHS/BHS file, otherwise each VXU is its own file in a zip.
+type HL7ExportRequest struct {
+	Class       string `query:"class" json:"class,omitempty"`
+	StudentId   int    `query:"student_id" json:"student_id,omitempty"`
+	DriveId     int    `query:"drive_id" json:"drive_id,omitempty"`
+	VaccineId   int    `query:"vaccine_id" json:"vaccine_id,omitempty"`
+	VaccineName string `query:"vaccine_name" json:"vaccine_name,omitempty"`
+	Batch       bool   `query:"batch" json:"batch"`
+	RequestId   string `json:"-"`
+}
+
+type HL7ExportRequestProcessor struct{}
+
+func (r HL7ExportRequestProcessor) Bind(c echo.Context, req interface{}, model *models.BulkFileJobsModel) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *HL7ExportRequest:
+		req.(*HL7ExportRequest).RequestId = uuid.NewString()
+		parameters, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		model.RequestId = req.(*HL7ExportRequest).RequestId
+		model.CorrelationId = logging.RequestID(c)
+		model.FileName = "VXU export"
+		model.Parameters = string(parameters)
+		model.Status = "PENDING"
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+func NewHL7ExportRequestHandler() HL7ExportRequestHandler {
+	return HL7ExportRequestProcessor{}
+}
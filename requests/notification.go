@@ -0,0 +1,64 @@
+package requests
+
+import (
+	"fmt"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.Notification) error
+}
+
+type NotificationRequest struct{}
+
+type GetNotificationRequest struct {
+	Id         int    `param:"id"`
+	StudentId  int    `query:"student_id"`
+	GuardianId int    `query:"guardian_id"`
+	DriveId    int    `query:"drive_id"`
+	Type       string `query:"notification_type" validate:"omitempty,oneof=DRIVE_REMINDER VACCINATION_CONFIRMATION MISSED_DRIVE"`
+	Channel    string `query:"channel" validate:"omitempty,oneof=SMS EMAIL"`
+	Status     string `query:"status" validate:"omitempty,oneof=QUEUED SENT FAILED"`
+	Pagination Pagination
+}
+
+// Days is how far ahead of a drive reminders go out, or how long after it follow ups do, the configured default when left out
+type NotificationScheduleRequest struct {
+	Days *int `json:"days,omitempty" validate:"omitempty,min=0,max=60"`
+}
+
+type RetryNotificationRequest struct {
+	Id int `param:"id" validate:"required"`
+}
+
+func (r NotificationRequest) Bind(c echo.Context, req interface{}, model *models.Notification) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *GetNotificationRequest:
+		model.Id = req.(*GetNotificationRequest).Id
+		req.(*GetNotificationRequest).Pagination = GetPagination(req.(*GetNotificationRequest).Pagination)
+	case *NotificationScheduleRequest:
+	case *RetryNotificationRequest:
+		model.Id = req.(*RetryNotificationRequest).Id
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+func NewNotificationRequestHandler() NotificationRequestHandler {
+	return NotificationRequest{}
+}
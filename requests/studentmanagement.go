@@ -1,8 +1,10 @@
 package requests
 
 import (
-	"log"
+	"fmt"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -28,32 +30,57 @@ type StudentManagementUpdateRequest struct {
 	PhoneNo string `json:"phone_no,omitempty"`
 }
 type StudentVaccinationRecordCreateRequest struct {
-	StudentId int `json:"student_id" validate:"required"`
-	DriveId   int `json:"drive_id" validate:"required"`
+	StudentId      int        `json:"student_id" validate:"required"`
+	DriveId        int        `json:"drive_id" validate:"required"`
+	AdministeredAt *time.Time `json:"administered_at,omitempty"`
+	DoseNumber     int        `json:"dose_number,omitempty" validate:"omitempty,min=1"`
+	LotNumber      string     `json:"lot_number" validate:"required,max=100"`
+	ExpiryDate     *time.Time `json:"expiry_date" validate:"required"`
+	InjectionSite  string     `json:"injection_site,omitempty" validate:"omitempty,oneof=LEFT_ARM RIGHT_ARM LEFT_THIGH RIGHT_THIGH ORAL NASAL"`
+	AdministeredBy string     `json:"administered_by" validate:"required,max=255"`
+}
+type VaccinationRecordVoidRequest struct {
+	Id     int    `param:"id" validate:"required"`
+	Reason string `json:"reason" validate:"required"`
+}
+type VaccinationRecordCorrectRequest struct {
+	Id     int    `param:"id" validate:"required"`
+	Reason string `json:"reason" validate:"required"`
+	StudentVaccinationRecordCreateRequest
+}
+type GetStudentVaccinationHistoryRequest struct {
+	Id int `param:"id" validate:"required"`
 }
 type GetStudentVaccinationRecordRequest struct {
-	Id          int    `param:"id"`
-	RollNo      string `query:"roll_no"`
-	VaccineName string `query:"vaccine_name"`
-	Class       string `query:"class" validate:"omitempty,checkValidGradeUpdate"`
-	Name        string `query:"name"`
-	Pagination  Pagination
+	Id            int    `param:"id"`
+	RollNo        string `query:"roll_no"`
+	VaccineName   string `query:"vaccine_name"`
+	Class         string `query:"class" validate:"omitempty,checkValidGradeUpdate"`
+	Name          string `query:"name"`
+	ExcludeExempt bool   `query:"exclude_exempt"`
+	Pagination    Pagination
 }
 type GenerateReportRequest struct {
-	Class       string `query:"class" validate:"omitempty,checkValidGradeUpdate"`
-	VaccineName string `query:"vaccine_name"`
-	RequestId   string
+	Class         string `query:"class" validate:"omitempty,checkValidGradeUpdate"`
+	VaccineName   string `query:"vaccine_name"`
+	ExcludeExempt bool   `query:"exclude_exempt"`
+	Format        string `query:"format" validate:"omitempty,oneof=xlsx fhir hl7"`
+	RequestId     string
+}
+type VaccinationDashboardRequest struct {
+	VaccineName   string `query:"vaccine_name"`
+	ExcludeExempt bool   `query:"exclude_exempt"`
 }
 
 func (r StudentManagementRequest) Bind(c echo.Context, req interface{}, model interface{}) error {
 	var err error
 
 	if err = c.Bind(req); err != nil {
-		log.Println("Error in reading request", err.Error())
+		logging.From(c).Warn("error in reading request", logging.Err(err))
 		return err
 	}
 	if err = c.Validate(req); err != nil {
-		log.Println("error in validating request", err.Error())
+		logging.From(c).Warn("error in validating request", logging.Err(err))
 		return err
 	}
 	switch v := req.(type) {
@@ -74,11 +101,16 @@ func (r StudentManagementRequest) Bind(c echo.Context, req interface{}, model in
 		model.(*models.StudentManagement).RollNumber = req.(*StudentManagementUpdateRequest).RollNo
 		model.(*models.StudentManagement).PhoneNo = req.(*StudentManagementUpdateRequest).PhoneNo
 	case *StudentVaccinationRecordCreateRequest:
-		data := models.StudentVaccineRecord{}
-		data.StudentId = req.(*StudentVaccinationRecordCreateRequest).StudentId
-		data.DriveId = req.(*StudentVaccinationRecordCreateRequest).DriveId
 		modelptr := model.(*[]models.StudentVaccineRecord)
-		*modelptr = append(*modelptr, data)
+		*modelptr = append(*modelptr, vaccineRecordFromRequest(req.(*StudentVaccinationRecordCreateRequest)))
+	case *VaccinationRecordCorrectRequest:
+		modelptr := model.(*[]models.StudentVaccineRecord)
+		*modelptr = append(*modelptr, vaccineRecordFromRequest(&req.(*VaccinationRecordCorrectRequest).StudentVaccinationRecordCreateRequest))
+	case *VaccinationRecordVoidRequest:
+		model.(*models.StudentVaccineRecord).Id = req.(*VaccinationRecordVoidRequest).Id
+		model.(*models.StudentVaccineRecord).StatusReason = req.(*VaccinationRecordVoidRequest).Reason
+	case *GetStudentVaccinationHistoryRequest:
+		model.(*models.StudentManagement).Id = req.(*GetStudentVaccinationHistoryRequest).Id
 
 	case *GetStudentVaccinationRecordRequest:
 		req.(*GetStudentVaccinationRecordRequest).Pagination = GetPagination(req.(*GetStudentVaccinationRecordRequest).Pagination)
@@ -86,12 +118,25 @@ func (r StudentManagementRequest) Bind(c echo.Context, req interface{}, model in
 		req.(*GenerateReportRequest).RequestId = uuid.NewString()
 
 	default:
-		log.Println("request type Unknown for transformation", v)
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
 	}
 
 	return nil
 }
 
+func vaccineRecordFromRequest(req *StudentVaccinationRecordCreateRequest) models.StudentVaccineRecord {
+	return models.StudentVaccineRecord{
+		StudentId:      req.StudentId,
+		DriveId:        req.DriveId,
+		AdministeredAt: req.AdministeredAt,
+		DoseNumber:     req.DoseNumber,
+		LotNumber:      req.LotNumber,
+		ExpiryDate:     req.ExpiryDate,
+		InjectionSite:  req.InjectionSite,
+		AdministeredBy: req.AdministeredBy,
+	}
+}
+
 func NewStudentManagementRequestHandler() StudentManagementRequestHandler {
 	return StudentManagementRequest{}
 }
@@ -0,0 +1,83 @@
+package requests
+
+import (
+	"fmt"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type VaccineCatalogRequestHandler interface {
+	Bind(c echo.Context, request interface{}, model *models.Vaccine) error
+}
+
+type VaccineCatalogRequest struct{}
+
+type VaccineCreateRequest struct {
+	Name            string   `json:"name" validate:"required"`
+	CvxCode         string   `json:"cvx_code" validate:"omitempty,numeric,max=3"`
+	Manufacturer    string   `json:"manufacturer" validate:"required"`
+	DosesInSeries   int      `json:"doses_in_series" validate:"required,min=1"`
+	MinIntervalDays int      `json:"min_interval_days" validate:"min=0"`
+	EligibleGrades  []string `json:"eligible_grades" validate:"required,min=1,dive,checkValidGrade"`
+}
+
+type VaccineUpdateRequest struct {
+	Id              int      `json:"id" validate:"required"`
+	Name            *string  `json:"name,omitempty"`
+	CvxCode         *string  `json:"cvx_code,omitempty" validate:"omitempty,numeric,max=3"`
+	Manufacturer    *string  `json:"manufacturer,omitempty"`
+	DosesInSeries   *int     `json:"doses_in_series,omitempty" validate:"omitempty,min=1"`
+	MinIntervalDays *int     `json:"min_interval_days,omitempty" validate:"omitempty,min=0"`
+	EligibleGrades  []string `json:"eligible_grades,omitempty" validate:"omitempty,min=1,dive,checkValidGrade"`
+}
+
+type GetVaccineRequest struct {
+	Id         int    `param:"id"`
+	Name       string `query:"name"`
+	Pagination Pagination
+}
+
+type DeleteVaccineRequest struct {
+	Id int `param:"id" validate:"required"`
+}
+
+func (r VaccineCatalogRequest) Bind(c echo.Context, req interface{}, model *models.Vaccine) error {
+	var err error
+
+	if err = c.Bind(req); err != nil {
+		logging.From(c).Warn("error in reading request", logging.Err(err))
+		return err
+	}
+	if err = c.Validate(req); err != nil {
+		logging.From(c).Warn("error in validating request", logging.Err(err))
+		return err
+	}
+	switch v := req.(type) {
+	case *VaccineCreateRequest:
+		model.Name = strings.TrimSpace(req.(*VaccineCreateRequest).Name)
+		model.CvxCode = req.(*VaccineCreateRequest).CvxCode
+		model.Manufacturer = req.(*VaccineCreateRequest).Manufacturer
+		model.DosesInSeries = req.(*VaccineCreateRequest).DosesInSeries
+		model.MinIntervalDays = req.(*VaccineCreateRequest).MinIntervalDays
+		model.EligibleGrades = req.(*VaccineCreateRequest).EligibleGrades
+	case *VaccineUpdateRequest:
+		model.Id = req.(*VaccineUpdateRequest).Id
+	case *GetVaccineRequest:
+		model.Id = req.(*GetVaccineRequest).Id
+		model.Name = strings.TrimSpace(req.(*GetVaccineRequest).Name)
+		req.(*GetVaccineRequest).Pagination = GetPagination(req.(*GetVaccineRequest).Pagination)
+	case *DeleteVaccineRequest:
+		model.Id = req.(*DeleteVaccineRequest).Id
+	default:
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
+	}
+
+	return nil
+}
+
+func NewVaccineCatalogRequestHandler() VaccineCatalogRequestHandler {
+	return VaccineCatalogRequest{}
+}
@@ -1,7 +1,8 @@
 package requests
 
 import (
-	"log"
+	"fmt"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"time"
 
@@ -23,45 +24,56 @@ type GetVaccineInventoryRequest struct {
 }
 
 type VaccineInventoryCreateRequest struct {
-	DriveDate   time.Time `json:"drive_date" validate:"checkValidDriveDate"`
-	VaccineName string    `json:"vaccine_name" validate:"required"`
-	Doses       int       `json:"doses" validate:"required"`
-	Classes     string    `json:"classes" validate:"required"`
+	DriveDate time.Time `json:"drive_date" validate:"checkValidDriveDate"`
+	VaccineId int       `json:"vaccine_id" validate:"required"`
+	Doses     int       `json:"doses" validate:"required,min=1"`
+	Classes   []string  `json:"classes" validate:"required,min=1,dive,checkValidGrade"`
 }
 
 type VaccineInventoryUpdateRequest struct {
 	Id          int        `json:"id" validate:"required"`
 	DriveDate   *time.Time `json:"drive_date,omitempty"`
-	VaccineName *string    `json:"vaccine_name,omitempty"`
-	Doses       *int       `json:"doses,omitempty"`
-	Classes     *string    `json:"classes,omitempty"`
+	VaccineId   *int       `json:"vaccine_id,omitempty"`
+	Doses       *int       `json:"doses,omitempty" validate:"omitempty,min=1"`
+	DosesWasted *int       `json:"doses_wasted,omitempty" validate:"omitempty,min=0"`
+	Classes     []string   `json:"classes,omitempty" validate:"omitempty,min=1,dive,checkValidGrade"`
+	Status      *string    `json:"status,omitempty" validate:"omitempty,oneof=SCHEDULED IN_PROGRESS COMPLETED POSTPONED"`
+}
+
+type VaccineInventoryCancelRequest struct {
+	Id     int    `param:"id" validate:"required"`
+	Reason string `json:"reason" validate:"required"`
 }
 
 func (r VaccineInventoryRequest) Bind(c echo.Context, req interface{}, model *models.VaccineInventory) error {
 	var err error
 
 	if err = c.Bind(req); err != nil {
-		log.Println("Error in reading request", err.Error())
+		logging.From(c).Warn("error in reading request", logging.Err(err))
 		return err
 	}
 	if err = c.Validate(req); err != nil {
-		log.Println("error in validating request", err.Error())
+		logging.From(c).Warn("error in validating request", logging.Err(err))
 		return err
 	}
 	switch v := req.(type) {
 	case *VaccineInventoryCreateRequest:
 		model.DriveDate = req.(*VaccineInventoryCreateRequest).DriveDate.UTC()
-		model.VaccineName = req.(*VaccineInventoryCreateRequest).VaccineName
+		model.VaccineId = req.(*VaccineInventoryCreateRequest).VaccineId
 		model.Doses = req.(*VaccineInventoryCreateRequest).Doses
 		model.Classes = req.(*VaccineInventoryCreateRequest).Classes
+		model.Status = models.DRIVE_SCHEDULED
 	case *GetVaccineInventoryRequest:
 		model.Id = req.(*GetVaccineInventoryRequest).Id
 		model.VaccineName = req.(*GetVaccineInventoryRequest).Name
-		log.Println("req is ", model)
 	case **VaccineInventoryUpdateRequest:
 		model.Id = req.(*VaccineInventoryUpdateRequest).Id
+	case *VaccineInventoryCancelRequest:
+		model.Id = req.(*VaccineInventoryCancelRequest).Id
+		model.Status = models.DRIVE_CANCELLED
+		model.CancellationReason = req.(*VaccineInventoryCancelRequest).Reason
 	default:
-		log.Println("request type Unknown for transformation", v)
+		logging.From(c).Error("request type unknown for transformation", "type", fmt.Sprintf("%T", v))
 	}
 
 	return nil
@@ -0,0 +1,78 @@
+package response
+
+import (
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type AdverseEventResponseHandler interface {
+	ProcessAdverseEventResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
+}
+
+type AdverseEventResponse struct {
+	Id                  int         `json:"id"`
+	VaccinationRecordId int         `json:"vaccination_record_id"`
+	StudentId           int         `json:"student_id"`
+	DriveId             int         `json:"drive_id"`
+	LotNumber           string      `json:"lot_number"`
+	Severity            string      `json:"severity"`
+	OnsetAt             string      `json:"onset_at"`
+	Symptoms            string      `json:"symptoms"`
+	ActionsTaken        string      `json:"actions_taken,omitempty"`
+	Outcome             string      `json:"outcome"`
+	ReportedBy          string      `json:"reported_by"`
+	CreatedAt           string      `json:"created_at"`
+	Links               interface{} `json:"_links,omitempty"`
+}
+
+type AdverseEventResponseProcessor struct{}
+
+func (r AdverseEventResponseProcessor) ProcessAdverseEventResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne {
+	resp := StudentManagementConsolidatedResposne{}
+	switch req.(type) {
+	case *requests.AdverseEventCreateRequest:
+		resp.Message = "Adverse event reported successfully"
+		resp.Data = processAdverseEvent(link, *data.(*models.AdverseEvent))
+	case *requests.GetAdverseEventRequest:
+		events := []AdverseEventResponse{}
+		for _, j := range data.([]models.AdverseEvent) {
+			events = append(events, processAdverseEvent(link, j))
+		}
+		resp.Message = "adverse events fetched successfully"
+		resp.Data = events
+		if req.(*requests.GetAdverseEventRequest).Id != 0 && len(events) == 1 {
+			resp.Data = events[0]
+		}
+		resp.Limit = req.(*requests.GetAdverseEventRequest).Pagination.Limit
+		resp.Offset = req.(*requests.GetAdverseEventRequest).Pagination.Offset
+	}
+	return resp
+}
+
+func processAdverseEvent(link links.Builder, event models.AdverseEvent) AdverseEventResponse {
+	return AdverseEventResponse{
+		Id:                  event.Id,
+		VaccinationRecordId: event.VaccinationRecordId,
+		StudentId:           event.StudentId,
+		DriveId:             event.DriveId,
+		LotNumber:           event.LotNumber,
+		Severity:            event.Severity,
+		OnsetAt:             event.OnsetAt.Format("2006-01-02 15:04:05"),
+		Symptoms:            event.Symptoms,
+		ActionsTaken:        event.ActionsTaken,
+		Outcome:             event.Outcome,
+		ReportedBy:          event.ReportedBy,
+		CreatedAt:           event.CreatedAt.Format("2006-01-02 15:04:05"),
+		Links: map[string]interface{}{
+			"self": map[string]string{
+				"href":   link.Href("adverse-events/%d", event.Id),
+				"method": "GET",
+			},
+		},
+	}
+}
+
+func NewAdverseEventResponseHandler() AdverseEventResponseHandler {
+	return AdverseEventResponseProcessor{}
+}
@@ -0,0 +1,66 @@
+package response
+
+import (
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type BulkFileJobResponseHandler interface {
+	ProcessBulkFileJobResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
+}
+
+type BulkFileJobAcceptedResponse struct {
+	RequestId string      `json:"request_id"`
+	Status    string      `json:"status"`
+	Links     interface{} `json:"_links,omitempty"`
+}
+
+type BulkFileJobResponse struct {
+	models.BulkFileJobsModel
+	Links interface{} `json:"_links,omitempty"`
+}
+
+type BulkFileJobResponseProcessor struct{}
+
+func (r BulkFileJobResponseProcessor) ProcessBulkFileJobResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne {
+	resp := StudentManagementConsolidatedResposne{}
+	switch req.(type) {
+	case *requests.BulkFileJobRequest, *requests.HL7ExportRequest:
+		job := data.(*models.BulkFileJobsModel)
+		resp.Message = "Request Accepted! Please check after sometime"
+		resp.Data = BulkFileJobAcceptedResponse{
+			RequestId: job.RequestId,
+			Status:    job.Status,
+			Links:     geneRateHateOasForBulkJob(link, *job),
+		}
+	case *requests.GetBulkFileRequest:
+		jobs := []BulkFileJobResponse{}
+		for _, j := range data.([]models.BulkFileJobsModel) {
+			jobs = append(jobs, BulkFileJobResponse{BulkFileJobsModel: j, Links: geneRateHateOasForBulkJob(link, j)})
+		}
+		resp.Message = "bulk job details fetched successfully"
+		resp.Data = jobs
+		resp.Limit = req.(*requests.GetBulkFileRequest).Pagination.Limit
+		resp.Offset = req.(*requests.GetBulkFileRequest).Pagination.Offset
+	}
+	return resp
+}
+
+func geneRateHateOasForBulkJob(link links.Builder, job models.BulkFileJobsModel) interface{} {
+	hateOas := map[string]interface{}{
+		"self": link.Link("GET", "bulk-upload/%s", job.RequestId),
+	}
+	//processed jobs keep the download url of their report or export in file_path
+	if job.Status == "PROCESSED" && job.FilePath != "" {
+		hateOas["file"] = map[string]string{
+			"href":   job.FilePath,
+			"method": "GET",
+		}
+	}
+	return hateOas
+}
+
+func NewBulkFileJobResponseHandler() BulkFileJobResponseHandler {
+	return BulkFileJobResponseProcessor{}
+}
@@ -0,0 +1,104 @@
+package response
+
+import (
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type CertificateResponseHandler interface {
+	ProcessCertificateResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
+}
+
+type CertificateResponse struct {
+	Id                int         `json:"id"`
+	CertificateNumber string      `json:"certificate_number"`
+	StudentId         int         `json:"student_id"`
+	IssuedAt          string      `json:"issued_at"`
+	Status            string      `json:"status"`
+	RevokedAt         string      `json:"revoked_at,omitempty"`
+	RevokedReason     string      `json:"revoked_reason,omitempty"`
+	Links             interface{} `json:"_links,omitempty"`
+}
+
+type CertificateVerificationResponse struct {
+	Valid         bool                       `json:"valid"`
+	Revoked       bool                       `json:"revoked"`
+	Reason        string                     `json:"reason,omitempty"`
+	Status        string                     `json:"status,omitempty"`
+	RevokedAt     string                     `json:"revoked_at,omitempty"`
+	RevokedReason string                     `json:"revoked_reason,omitempty"`
+	Certificate   *models.CertificatePayload `json:"certificate,omitempty"`
+}
+
+// RevokedCertificateResponse is one entry of the revocation list, the verify-certificate command reads these back
+type RevokedCertificateResponse struct {
+	CertificateNumber string `json:"certificate_number"`
+	RevokedAt         string `json:"revoked_at"`
+	RevokedReason     string `json:"revoked_reason"`
+}
+
+type CertificateResponseProcessor struct{}
+
+func (r CertificateResponseProcessor) ProcessCertificateResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne {
+	resp := StudentManagementConsolidatedResposne{}
+	switch req.(type) {
+	case *requests.VerifyCertificateRequest:
+		verification := data.(models.CertificateVerification)
+		result := CertificateVerificationResponse{Valid: verification.Valid, Reason: verification.Reason}
+		if verification.Valid {
+			result.Certificate = &verification.Payload
+		}
+		if verification.Certificate != nil {
+			result.Status = verification.Certificate.Status
+			result.Revoked = verification.Certificate.Status == models.CERTIFICATE_REVOKED
+			result.RevokedReason = verification.Certificate.RevokedReason
+			if verification.Certificate.RevokedAt != nil {
+				result.RevokedAt = verification.Certificate.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+		}
+		resp.Message = "certificate verified"
+		resp.Data = result
+	case *requests.RevokeCertificateRequest:
+		resp.Message = "Certificate revoked successfully"
+		resp.Data = processCertificate(link, data.(models.Certificate))
+	case *requests.GetRevokedCertificatesRequest:
+		revoked := []RevokedCertificateResponse{}
+		for _, j := range data.([]models.Certificate) {
+			entry := RevokedCertificateResponse{CertificateNumber: j.CertificateNumber, RevokedReason: j.RevokedReason}
+			if j.RevokedAt != nil {
+				entry.RevokedAt = j.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+			revoked = append(revoked, entry)
+		}
+		resp.Message = "revoked certificates fetched successfully"
+		resp.Data = revoked
+		resp.Total = len(revoked)
+	}
+	return resp
+}
+
+func processCertificate(link links.Builder, certificate models.Certificate) CertificateResponse {
+	resp := CertificateResponse{
+		Id:                certificate.Id,
+		CertificateNumber: certificate.CertificateNumber,
+		StudentId:         certificate.StudentId,
+		IssuedAt:          certificate.IssuedAt.Format("2006-01-02 15:04:05"),
+		Status:            certificate.Status,
+		RevokedReason:     certificate.RevokedReason,
+		Links: map[string]interface{}{
+			"student_certificate": map[string]string{
+				"href":   link.Href("student-management/students/%d/certificate", certificate.StudentId),
+				"method": "GET",
+			},
+		},
+	}
+	if certificate.RevokedAt != nil {
+		resp.RevokedAt = certificate.RevokedAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
+
+func NewCertificateResponseHandler() CertificateResponseHandler {
+	return CertificateResponseProcessor{}
+}
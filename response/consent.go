@@ -0,0 +1,91 @@
+package response
+
+import (
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type ConsentResponseHandler interface {
+	ProcessConsentResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
+}
+
+type ConsentResponse struct {
+	Id           int         `json:"id"`
+	StudentId    int         `json:"student_id"`
+	DriveId      int         `json:"drive_id,omitempty"`
+	VaccineId    int         `json:"vaccine_id"`
+	Status       string      `json:"status"`
+	GuardianId   int         `json:"guardian_id,omitempty"`
+	GuardianName string      `json:"guardian_name"`
+	ConsentDate  string      `json:"consent_date"`
+	FormUrl      string      `json:"form_url,omitempty"`
+	CreatedAt    string      `json:"created_at"`
+	UpdatedAt    string      `json:"updated_at,omitempty"`
+	Links        interface{} `json:"_links,omitempty"`
+}
+
+type ConsentResponseProcessor struct {
+	storage config.MinioConfig
+}
+
+func (r ConsentResponseProcessor) ProcessConsentResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne {
+	resp := StudentManagementConsolidatedResposne{}
+	switch req.(type) {
+	case *requests.ConsentCreateRequest:
+		resp.Message = "Consent recorded successfully"
+		resp.Data = r.processConsent(link, *data.(*models.Consent))
+	case *requests.ConsentUpdateRequest:
+		resp.Message = "Consent updated successfully"
+		resp.Data = r.processConsent(link, data.(models.Consent))
+	case *requests.GetConsentRequest:
+		consents := []ConsentResponse{}
+		for _, j := range data.([]models.Consent) {
+			consents = append(consents, r.processConsent(link, j))
+		}
+		resp.Message = "consents fetched successfully"
+		resp.Data = consents
+		if req.(*requests.GetConsentRequest).Id != 0 && len(consents) == 1 {
+			resp.Data = consents[0]
+		}
+		resp.Limit = req.(*requests.GetConsentRequest).Pagination.Limit
+		resp.Offset = req.(*requests.GetConsentRequest).Pagination.Offset
+	}
+	return resp
+}
+
+func (r ConsentResponseProcessor) processConsent(link links.Builder, consent models.Consent) ConsentResponse {
+	resp := ConsentResponse{
+		Id:           consent.Id,
+		StudentId:    consent.StudentId,
+		DriveId:      consent.DriveId,
+		VaccineId:    consent.VaccineId,
+		Status:       consent.Status,
+		GuardianId:   consent.GuardianId,
+		GuardianName: consent.GuardianName,
+		ConsentDate:  consent.ConsentDate.Format("2006-01-02"),
+		CreatedAt:    consent.CreatedAt.Format("2006-01-02 15:04:05"),
+		Links: map[string]interface{}{
+			"self": map[string]string{
+				"href":   link.Href("consents/%d", consent.Id),
+				"method": "GET",
+			},
+			"edit": map[string]string{
+				"href":   link.Href("consents"),
+				"method": "PATCH",
+			},
+		},
+	}
+	if consent.FormPath != "" {
+		resp.FormUrl = r.storage.ObjectURL(consent.FormPath)
+	}
+	if !consent.UpdatedAt.IsZero() {
+		resp.UpdatedAt = consent.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
+
+func NewConsentResponseHandler(storage config.MinioConfig) ConsentResponseHandler {
+	return ConsentResponseProcessor{storage: storage}
+}
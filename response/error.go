@@ -1,7 +1,8 @@
 package response
 
 import (
-	"log"
+	"context"
+	"errors"
 	"school_vaccination_portal/utils/validator"
 
 	"github.com/labstack/echo/v4"
@@ -9,14 +10,18 @@ import (
 
 func ProcessErrorResponse(err error) interface{} {
 	resp := StudentManagementConsolidatedResposne{}
-	switch v := err.(type) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		resp.Message = "request timed out"
+		resp.Data = []string{}
+		resp.Error = "the request took longer than allowed, try again or narrow it down"
+		return resp
+	}
+	switch err.(type) {
 	case *validator.ValidationError:
-		log.Println("error type", v)
 		resp.Message = "Invalid Input"
 		resp.Data = []string{}
 		resp.Error = err.(*validator.ValidationError).Fields
 	case *echo.HTTPError:
-		log.Println("error type", v)
 		if err.(*echo.HTTPError).Code == 415 {
 			resp.Message = "Invalid Request"
 			resp.Data = []string{}
@@ -25,7 +30,6 @@ func ProcessErrorResponse(err error) interface{} {
 			}
 		}
 	default:
-		log.Println("error type", v)
 		resp.Message = "request processing unavailable"
 		resp.Data = []string{}
 		resp.Error = err.Error()
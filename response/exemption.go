@@ -0,0 +1,89 @@
+package response
+
+import (
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"time"
+)
+
+type ExemptionResponseHandler interface {
+	ProcessExemptionResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
+}
+
+type ExemptionResponse struct {
+	Id            int         `json:"id"`
+	StudentId     int         `json:"student_id"`
+	VaccineId     int         `json:"vaccine_id"`
+	VaccineName   string      `json:"vaccine_name"`
+	ExemptionType string      `json:"exemption_type"`
+	Reason        string      `json:"reason"`
+	ExpiryDate    string      `json:"expiry_date,omitempty"`
+	Active        bool        `json:"active"`
+	DocumentUrl   string      `json:"document_url,omitempty"`
+	CreatedAt     string      `json:"created_at"`
+	Links         interface{} `json:"_links,omitempty"`
+}
+
+type ExemptionResponseProcessor struct {
+	storage config.MinioConfig
+}
+
+func (r ExemptionResponseProcessor) ProcessExemptionResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne {
+	resp := StudentManagementConsolidatedResposne{}
+	switch req.(type) {
+	case *requests.ExemptionCreateRequest:
+		resp.Message = "Exemption recorded successfully"
+		resp.Data = r.processExemption(link, *data.(*models.Exemption))
+	case *requests.GetExemptionRequest:
+		exemptions := []ExemptionResponse{}
+		for _, j := range data.([]models.Exemption) {
+			exemptions = append(exemptions, r.processExemption(link, j))
+		}
+		resp.Message = "exemptions fetched successfully"
+		resp.Data = exemptions
+		if req.(*requests.GetExemptionRequest).Id != 0 && len(exemptions) == 1 {
+			resp.Data = exemptions[0]
+		}
+		resp.Limit = req.(*requests.GetExemptionRequest).Pagination.Limit
+		resp.Offset = req.(*requests.GetExemptionRequest).Pagination.Offset
+	case *requests.DeleteExemptionRequest:
+		resp.Message = "Exemption deleted successfully"
+	}
+	return resp
+}
+
+func (r ExemptionResponseProcessor) processExemption(link links.Builder, exemption models.Exemption) ExemptionResponse {
+	resp := ExemptionResponse{
+		Id:            exemption.Id,
+		StudentId:     exemption.StudentId,
+		VaccineId:     exemption.VaccineId,
+		VaccineName:   exemption.VaccineName,
+		ExemptionType: exemption.ExemptionType,
+		Reason:        exemption.Reason,
+		Active:        exemption.IsActive(time.Now()),
+		CreatedAt:     exemption.CreatedAt.Format("2006-01-02 15:04:05"),
+		Links: map[string]interface{}{
+			"self": map[string]string{
+				"href":   link.Href("exemptions/%d", exemption.Id),
+				"method": "GET",
+			},
+			"delete": map[string]string{
+				"href":   link.Href("exemptions/%d", exemption.Id),
+				"method": "DELETE",
+			},
+		},
+	}
+	if exemption.ExpiryDate != nil {
+		resp.ExpiryDate = exemption.ExpiryDate.Format("2006-01-02")
+	}
+	if exemption.DocumentPath != "" {
+		resp.DocumentUrl = r.storage.ObjectURL(exemption.DocumentPath)
+	}
+	return resp
+}
+
+func NewExemptionResponseHandler(storage config.MinioConfig) ExemptionResponseHandler {
+	return ExemptionResponseProcessor{storage: storage}
+}
@@ -0,0 +1,105 @@
+package response
+
+import (
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type GuardianResponseHandler interface {
+	ProcessGuardianResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
+}
+
+type GuardianResponse struct {
+	Id                int         `json:"id"`
+	Name              string      `json:"name"`
+	Relationship      string      `json:"relationship"`
+	PhonePrimary      string      `json:"phone_primary"`
+	PhoneSecondary    string      `json:"phone_secondary,omitempty"`
+	Email             string      `json:"email,omitempty"`
+	PreferredLanguage string      `json:"preferred_language"`
+	NotificationOptIn bool        `json:"notification_opt_in"`
+	StudentIds        []int       `json:"student_ids"`
+	CreatedAt         string      `json:"created_at"`
+	UpdatedAt         string      `json:"updated_at,omitempty"`
+	Links             interface{} `json:"_links,omitempty"`
+}
+
+type GuardianResponseProcessor struct{}
+
+func (r GuardianResponseProcessor) ProcessGuardianResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne {
+	resp := StudentManagementConsolidatedResposne{}
+	switch req.(type) {
+	case *requests.GuardianCreateRequest:
+		resp.Message = "Guardian created successfully"
+		resp.Data = processGuardian(link, *data.(*models.Guardian))
+	case *requests.GuardianUpdateRequest:
+		resp.Message = "Guardian updated successfully"
+		resp.Data = processGuardian(link, data.(models.Guardian))
+	case *requests.GuardianLinkRequest:
+		resp.Message = "Students linked successfully"
+		resp.Data = processGuardian(link, data.(models.Guardian))
+	case *requests.GuardianUnlinkRequest:
+		resp.Message = "Student unlinked successfully"
+		resp.Data = processGuardian(link, data.(models.Guardian))
+	case *requests.DeleteGuardianRequest:
+		resp.Message = "Guardian deleted successfully"
+	case *requests.GetGuardianRequest:
+		guardians := []GuardianResponse{}
+		for _, j := range data.([]models.Guardian) {
+			guardians = append(guardians, processGuardian(link, j))
+		}
+		resp.Message = "guardians fetched successfully"
+		resp.Data = guardians
+		if req.(*requests.GetGuardianRequest).Id != 0 && len(guardians) == 1 {
+			resp.Data = guardians[0]
+		}
+		resp.Limit = req.(*requests.GetGuardianRequest).Pagination.Limit
+		resp.Offset = req.(*requests.GetGuardianRequest).Pagination.Offset
+	}
+	return resp
+}
+
+func processGuardian(link links.Builder, guardian models.Guardian) GuardianResponse {
+	resp := GuardianResponse{
+		Id:                guardian.Id,
+		Name:              guardian.Name,
+		Relationship:      guardian.Relationship,
+		PhonePrimary:      guardian.PhonePrimary,
+		PhoneSecondary:    guardian.PhoneSecondary,
+		Email:             guardian.Email,
+		PreferredLanguage: guardian.PreferredLanguage,
+		NotificationOptIn: guardian.NotificationOptIn,
+		StudentIds:        guardian.StudentIds,
+		CreatedAt:         guardian.CreatedAt.Format("2006-01-02 15:04:05"),
+		Links: map[string]interface{}{
+			"self": map[string]string{
+				"href":   link.Href("guardians/%d", guardian.Id),
+				"method": "GET",
+			},
+			"edit": map[string]string{
+				"href":   link.Href("guardians"),
+				"method": "PATCH",
+			},
+			"link_students": map[string]string{
+				"href":   link.Href("guardians/%d/students", guardian.Id),
+				"method": "POST",
+			},
+			"delete": map[string]string{
+				"href":   link.Href("guardians/%d", guardian.Id),
+				"method": "DELETE",
+			},
+		},
+	}
+	if resp.StudentIds == nil {
+		resp.StudentIds = []int{}
+	}
+	if !guardian.UpdatedAt.IsZero() {
+		resp.UpdatedAt = guardian.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
+
+func NewGuardianResponseHandler() GuardianResponseHandler {
+	return GuardianResponseProcessor{}
+}
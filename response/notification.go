@@ -0,0 +1,99 @@
+package response
+
+import (
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+)
+
+type NotificationResponseHandler interface {
+	ProcessNotificationResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
+}
+
+type NotificationResponse struct {
+	Id               int         `json:"id"`
+	NotificationType string      `json:"notification_type"`
+	Channel          string      `json:"channel"`
+	GuardianId       int         `json:"guardian_id"`
+	StudentId        int         `json:"student_id"`
+	DriveId          int         `json:"drive_id,omitempty"`
+	RecordId         int         `json:"record_id,omitempty"`
+	Recipient        string      `json:"recipient"`
+	Language         string      `json:"language"`
+	Subject          string      `json:"subject"`
+	Body             string      `json:"body"`
+	Status           string      `json:"status"`
+	Attempts         int         `json:"attempts"`
+	ErrorMessage     string      `json:"error_message,omitempty"`
+	SentAt           string      `json:"sent_at,omitempty"`
+	CreatedAt        string      `json:"created_at"`
+	Links            interface{} `json:"_links,omitempty"`
+}
+
+type NotificationResponseProcessor struct{}
+
+func (r NotificationResponseProcessor) ProcessNotificationResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne {
+	resp := StudentManagementConsolidatedResposne{}
+	switch req.(type) {
+	case *requests.GetNotificationRequest:
+		notifications := []NotificationResponse{}
+		for _, j := range data.([]models.Notification) {
+			notifications = append(notifications, processNotification(link, j))
+		}
+		resp.Message = "notifications fetched successfully"
+		resp.Data = notifications
+		if req.(*requests.GetNotificationRequest).Id != 0 && len(notifications) == 1 {
+			resp.Data = notifications[0]
+		}
+		resp.Limit = req.(*requests.GetNotificationRequest).Pagination.Limit
+		resp.Offset = req.(*requests.GetNotificationRequest).Pagination.Offset
+	case *requests.NotificationScheduleRequest:
+		resp.Message = "Notifications queued successfully"
+		resp.Data = map[string]int{"queued": data.(int)}
+	case *requests.RetryNotificationRequest:
+		resp.Message = "Notification queued for retry"
+		resp.Data = processNotification(link, data.(models.Notification))
+	}
+	return resp
+}
+
+func processNotification(link links.Builder, notification models.Notification) NotificationResponse {
+	resp := NotificationResponse{
+		Id:               notification.Id,
+		NotificationType: notification.NotificationType,
+		Channel:          notification.Channel,
+		GuardianId:       notification.GuardianId,
+		StudentId:        notification.StudentId,
+		DriveId:          notification.DriveId,
+		RecordId:         notification.RecordId,
+		Recipient:        notification.Recipient,
+		Language:         notification.Language,
+		Subject:          notification.Subject,
+		Body:             notification.Body,
+		Status:           notification.Status,
+		Attempts:         notification.Attempts,
+		ErrorMessage:     notification.ErrorMessage,
+		CreatedAt:        notification.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	links := map[string]interface{}{
+		"self": map[string]string{
+			"href":   link.Href("notifications/%d", notification.Id),
+			"method": "GET",
+		},
+	}
+	if notification.Status == models.NOTIFICATION_FAILED {
+		links["retry"] = map[string]string{
+			"href":   link.Href("notifications/%d/retry", notification.Id),
+			"method": "POST",
+		}
+	}
+	resp.Links = links
+	if notification.SentAt != nil {
+		resp.SentAt = notification.SentAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
+
+func NewNotificationResponseHandler() NotificationResponseHandler {
+	return NotificationResponseProcessor{}
+}
@@ -1,14 +1,14 @@
 package response
 
 import (
-	"fmt"
+	"school_vaccination_portal/links"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
 	"time"
 )
 
 type StudentManagementResponseHandler interface {
-	ProcessResponse(req interface{}, data interface{}) StudentManagementConsolidatedResposne
+	ProcessResponse(link links.Builder, req interface{}, data interface{}) StudentManagementConsolidatedResposne
 }
 type StudentManagementResponse struct {
 	Id        int         `json:"id,omitempty"`
@@ -32,13 +32,31 @@ type StudentManagementConsolidatedResposne struct {
 }
 
 type VaccineRecordResponse struct {
-	Id        int         `json:"id,omitempty"`
-	StudentId int         `json:"student_id"`
-	DriveId   int         `json:"drive_id"`
-	Links     interface{} `json:"_links,omitempty"`
+	Id             int         `json:"id,omitempty"`
+	StudentId      int         `json:"student_id"`
+	DriveId        int         `json:"drive_id"`
+	VaccineId      int         `json:"vaccine_id,omitempty"`
+	DoseNumber     int         `json:"dose_number,omitempty"`
+	AdministeredAt *time.Time  `json:"administered_at,omitempty"`
+	LotNumber      string      `json:"lot_number"`
+	ExpiryDate     *time.Time  `json:"expiry_date,omitempty"`
+	InjectionSite  string      `json:"injection_site,omitempty"`
+	AdministeredBy string      `json:"administered_by"`
+	Status         string      `json:"status,omitempty"`
+	StatusReason   string      `json:"status_reason,omitempty"`
+	SupersededBy   int         `json:"superseded_by,omitempty"`
+	CorrectsRecord int         `json:"corrects_record_id,omitempty"`
+	CreatedAt      *time.Time  `json:"created_at,omitempty"`
+	Links          interface{} `json:"_links,omitempty"`
 }
 
-func (r StudentManagementConsolidatedResposne) ProcessResponse(req interface{}, result interface{}) StudentManagementConsolidatedResposne {
+// StudentVaccinationStatusResponse is one row of the vaccination records listing with links to the student's records
+type StudentVaccinationStatusResponse struct {
+	models.GetStudentCompleteDetails
+	Links interface{} `json:"_links,omitempty"`
+}
+
+func (r StudentManagementConsolidatedResposne) ProcessResponse(link links.Builder, req interface{}, result interface{}) StudentManagementConsolidatedResposne {
 	resp := StudentManagementConsolidatedResposne{}
 	switch req.(type) {
 	case *requests.StudentManagementCreateRequest:
@@ -52,7 +70,7 @@ func (r StudentManagementConsolidatedResposne) ProcessResponse(req interface{},
 		data.UpdatedAt = result.([]models.DBInsertionRecord)[0].Record.UpdatedAt
 		data.PhoneNo = result.([]models.DBInsertionRecord)[0].Record.PhoneNo
 		if result.([]models.DBInsertionRecord)[0].Status {
-			data.Links = geneRateHateOasForStudent(result.([]models.DBInsertionRecord)[0])
+			data.Links = geneRateHateOasForStudent(link, result.([]models.DBInsertionRecord)[0])
 			resp.Message = "Student Onboarding succesful"
 		} else {
 			resp.Error = result.([]models.DBInsertionRecord)[0].ErrorReason
@@ -69,15 +87,11 @@ func (r StudentManagementConsolidatedResposne) ProcessResponse(req interface{},
 		data.CreatedAt = result.(models.StudentManagement).CreatedAt
 		data.UpdatedAt = result.(models.StudentManagement).UpdatedAt
 		data.PhoneNo = result.(models.StudentManagement).PhoneNo
-		data.Links = geneRateHateOasForStudent(models.DBInsertionRecord{Record: result.(models.StudentManagement)})
+		data.Links = geneRateHateOasForStudent(link, models.DBInsertionRecord{Record: result.(models.StudentManagement)})
 		resp.Message = "Student Successfully Updated"
 		resp.Data = data
 	case *requests.StudentVaccinationRecordCreateRequest:
-		v := VaccineRecordResponse{
-			Id:        result.([]models.VaccineInsertionDBRecord)[0].Record.Id,
-			StudentId: result.([]models.VaccineInsertionDBRecord)[0].Record.StudentId,
-			DriveId:   result.([]models.VaccineInsertionDBRecord)[0].Record.DriveId,
-		}
+		v := processVaccineRecord(link, result.([]models.VaccineInsertionDBRecord)[0].Record)
 		if result.([]models.VaccineInsertionDBRecord)[0].Status {
 			resp.Message = "Vaccination Record added Successfully"
 		} else {
@@ -85,9 +99,33 @@ func (r StudentManagementConsolidatedResposne) ProcessResponse(req interface{},
 			resp.Error = result.([]models.VaccineInsertionDBRecord)[0].ErrorReason
 		}
 		resp.Data = v
+	case *requests.VaccinationRecordCorrectRequest:
+		insertion := result.(models.VaccineInsertionDBRecord)
+		if insertion.Status {
+			resp.Message = "Vaccination Record corrected Successfully"
+		} else {
+			resp.Message = "Vaccination Record correction failed"
+			resp.Error = insertion.ErrorReason
+		}
+		resp.Data = processVaccineRecord(link, insertion.Record)
+	case *requests.VaccinationRecordVoidRequest:
+		resp.Message = "Vaccination Record voided Successfully"
+		resp.Data = processVaccineRecord(link, result.(models.StudentVaccineRecord))
+	case *requests.GetStudentVaccinationHistoryRequest:
+		history := []VaccineRecordResponse{}
+		for _, j := range result.([]models.StudentVaccineRecord) {
+			history = append(history, processVaccineRecord(link, j))
+		}
+		resp.Message = "student vaccination history fetched successfully"
+		resp.Data = history
+		resp.Total = len(history)
 	case *requests.GetStudentVaccinationRecordRequest:
+		records := []StudentVaccinationStatusResponse{}
+		for _, j := range result.([]models.GetStudentCompleteDetails) {
+			records = append(records, StudentVaccinationStatusResponse{GetStudentCompleteDetails: j, Links: geneRateHateOasForVaccinationStatus(link, j)})
+		}
 		resp.Message = "student record fetched successfully"
-		resp.Data = result
+		resp.Data = records
 		resp.Limit = req.(*requests.GetStudentVaccinationRecordRequest).Pagination.Limit
 		resp.Offset = req.(*requests.GetStudentVaccinationRecordRequest).Pagination.Offset
 
@@ -95,20 +133,61 @@ func (r StudentManagementConsolidatedResposne) ProcessResponse(req interface{},
 
 	return resp
 }
-func geneRateHateOasForStudent(data models.DBInsertionRecord) interface{} {
+func processVaccineRecord(link links.Builder, record models.StudentVaccineRecord) VaccineRecordResponse {
+	v := VaccineRecordResponse{
+		Id:             record.Id,
+		StudentId:      record.StudentId,
+		DriveId:        record.DriveId,
+		VaccineId:      record.VaccineId,
+		DoseNumber:     record.DoseNumber,
+		AdministeredAt: record.AdministeredAt,
+		LotNumber:      record.LotNumber,
+		ExpiryDate:     record.ExpiryDate,
+		InjectionSite:  record.InjectionSite,
+		AdministeredBy: record.AdministeredBy,
+		Status:         record.Status,
+		StatusReason:   record.StatusReason,
+		SupersededBy:   record.SupersededBy,
+		CorrectsRecord: record.CorrectsRecord,
+		CreatedAt:      record.CreatedAt,
+	}
+	if record.Id != 0 && record.Status == models.RECORD_ACTIVE {
+		v.Links = map[string]interface{}{
+			"void": map[string]string{
+				"href":   link.Href("student-management/vaccine-records/%d/void", record.Id),
+				"method": "POST",
+			},
+			"correct": map[string]string{
+				"href":   link.Href("student-management/vaccine-records/%d/correct", record.Id),
+				"method": "POST",
+			},
+		}
+	}
+	return v
+}
+
+func geneRateHateOasForStudent(link links.Builder, data models.DBInsertionRecord) interface{} {
 	hateOas := map[string]interface{}{}
 	hateOas["self"] = map[string]string{
-		"href":   fmt.Sprintf("http://localhost:8080/school-vaccine-portal/student-management/students/%d", data.Record.Id),
+		"href":   link.Href("student-management/students/%d", data.Record.Id),
 		"method": "GET",
 	}
 	hateOas["edit"] = map[string]string{
-		"href":   fmt.Sprintf("http://localhost:8080/school-vaccine-portal/student-management/students/%d", data.Record.Id),
+		"href":   link.Href("student-management/students/%d", data.Record.Id),
 		"method": "PATCH",
 	}
 
 	return hateOas
 }
 
+func geneRateHateOasForVaccinationStatus(link links.Builder, data models.GetStudentCompleteDetails) interface{} {
+	return map[string]interface{}{
+		"self":        link.Link("GET", "student-management/vaccine-records/students/%d", data.Id),
+		"history":     link.Link("GET", "student-management/vaccine-records/students/%d/history", data.Id),
+		"certificate": link.Link("GET", "student-management/students/%d/certificate", data.Id),
+	}
+}
+
 func NewStudentManagementResponseHandler() StudentManagementResponseHandler {
 	return StudentManagementConsolidatedResposne{}
 }
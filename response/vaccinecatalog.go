@@ -0,0 +1,125 @@
+package response
+
+import (
+	"fmt"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/requests"
+	"school_vaccination_portal/utils/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+type VaccineCatalogResponseHandler interface {
+	ProcessVaccineCatalogResponse(link links.Builder, req interface{}, data interface{}) interface{}
+	ProcessErrorResponse(err error) interface{}
+}
+
+type VaccineCatalogResponse struct {
+	Message string      `json:"message_string"`
+	Data    interface{} `json:"data"`
+	Error   interface{} `json:"error_string,omitempty"`
+	Total   int         `json:"total,omitempty"`
+	Limit   int         `json:"limit,omitempty"`
+	Offset  int         `json:"offset,omitempty"`
+}
+
+type VaccineGetResponse struct {
+	Id              int         `json:"id"`
+	Name            string      `json:"name"`
+	CvxCode         string      `json:"cvx_code"`
+	Manufacturer    string      `json:"manufacturer"`
+	DosesInSeries   int         `json:"doses_in_series"`
+	MinIntervalDays int         `json:"min_interval_days"`
+	EligibleGrades  []string    `json:"eligible_grades"`
+	CreatedAt       string      `json:"created_at"`
+	UpdatedAt       string      `json:"updated_at,omitempty"`
+	Links           interface{} `json:"_links,omitempty"`
+}
+
+func (r VaccineCatalogResponse) ProcessErrorResponse(err error) interface{} {
+	resp := VaccineCatalogResponse{}
+	switch err.(type) {
+	case *validator.ValidationError:
+		resp.Message = "Invalid Input"
+		resp.Data = []string{}
+		resp.Error = err.(*validator.ValidationError).Fields
+	case *echo.HTTPError:
+		resp.Message = "Invalid Request"
+		resp.Data = []string{}
+		resp.Error = map[string]string{
+			"error": fmt.Sprintf("%v", err.(*echo.HTTPError).Message),
+		}
+	default:
+		resp.Message = "vaccine catalog request failed"
+		resp.Data = []string{}
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+func (r VaccineCatalogResponse) ProcessVaccineCatalogResponse(link links.Builder, req, data interface{}) interface{} {
+	resp := VaccineCatalogResponse{}
+	switch req.(type) {
+	case *requests.VaccineCreateRequest:
+		resp.Message = "Vaccine added to catalog"
+		resp.Data = processVaccine(link, *data.(*models.Vaccine))
+	case *requests.VaccineUpdateRequest:
+		resp.Message = "Vaccine updated successfully"
+		resp.Data = processVaccine(link, data.(models.Vaccine))
+	case *requests.DeleteVaccineRequest:
+		resp.Message = "Vaccine removed from catalog"
+		resp.Data = []string{}
+	case *requests.GetVaccineRequest:
+		collectionData := []VaccineGetResponse{}
+		for _, j := range data.([]models.Vaccine) {
+			collectionData = append(collectionData, processVaccine(link, j))
+		}
+		resp.Message = "Vaccines fetched successfully"
+		resp.Data = collectionData
+		if req.(*requests.GetVaccineRequest).Id != 0 {
+			if len(collectionData) == 0 {
+				resp.Message = "No vaccine found"
+				resp.Data = []string{}
+			} else {
+				resp.Data = collectionData[0]
+			}
+		}
+		resp.Limit = req.(*requests.GetVaccineRequest).Pagination.Limit
+		resp.Offset = req.(*requests.GetVaccineRequest).Pagination.Offset
+	}
+	return resp
+}
+
+func processVaccine(link links.Builder, vaccine models.Vaccine) VaccineGetResponse {
+	data := VaccineGetResponse{
+		Id:              vaccine.Id,
+		Name:            vaccine.Name,
+		CvxCode:         vaccine.CvxCode,
+		Manufacturer:    vaccine.Manufacturer,
+		DosesInSeries:   vaccine.DosesInSeries,
+		MinIntervalDays: vaccine.MinIntervalDays,
+		EligibleGrades:  vaccine.EligibleGrades,
+		CreatedAt:       vaccine.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:       vaccine.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	data.Links = map[string]interface{}{
+		"self": map[string]string{
+			"href":   link.Href("vaccines/%d", vaccine.Id),
+			"method": "GET",
+		},
+		"edit": map[string]string{
+			"href":   link.Href("vaccines"),
+			"method": "PATCH",
+		},
+		"delete": map[string]string{
+			"href":   link.Href("vaccines/%d", vaccine.Id),
+			"method": "DELETE",
+		},
+	}
+	return data
+}
+
+func NewVaccineCatalogResponseHandler() VaccineCatalogResponseHandler {
+	return VaccineCatalogResponse{}
+}
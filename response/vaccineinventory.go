@@ -1,8 +1,7 @@
 package response
 
 import (
-	"fmt"
-	"log"
+	"school_vaccination_portal/links"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/requests"
 	"school_vaccination_portal/utils/validator"
@@ -12,7 +11,7 @@ import (
 )
 
 type VacinneInventoryResponseHandler interface {
-	ProcessVaccineInventoryResponse(req interface{}, data interface{}) interface{}
+	ProcessVaccineInventoryResponse(link links.Builder, req interface{}, data interface{}) interface{}
 	ProcessErrorResponse(err error) interface{}
 }
 
@@ -24,10 +23,16 @@ type VaccineInventoryResponse struct {
 }
 type VaccineInventoryGetResponse struct {
 	Id        int         `json:"id"`
+	VaccineId int         `json:"vaccine_id"`
 	Vaccine   string      `json:"vaccine_name"`
 	DriveDate string      `json:"drive_date"`
 	Doses     int         `json:"doses"`
-	Classes   string      `json:"classes"`
+	Used      int         `json:"doses_used"`
+	Wasted    int         `json:"doses_wasted"`
+	Remaining int         `json:"doses_remaining"`
+	Classes   []string    `json:"classes"`
+	Status    string      `json:"status"`
+	Reason    string      `json:"cancellation_reason,omitempty"`
 	CreatedAt string      `json:"created_at"`
 	UpdatedAt string      `json:"updated_at,omitempty"`
 	Links     interface{} `json:"_links,omitempty"`
@@ -35,14 +40,12 @@ type VaccineInventoryGetResponse struct {
 
 func (r VaccineInventoryResponse) ProcessErrorResponse(err error) interface{} {
 	resp := VaccineInventoryResponse{}
-	switch v := err.(type) {
+	switch err.(type) {
 	case *validator.ValidationError:
-		log.Println("error type", v)
 		resp.Message = "Invalid Input"
 		resp.Data = []string{}
 		resp.Error = err.(*validator.ValidationError).Fields
 	case *echo.HTTPError:
-		log.Println("error type", v)
 		if err.(*echo.HTTPError).Code == 415 {
 			resp.Message = "Invalid Request"
 			resp.Data = []string{}
@@ -52,7 +55,6 @@ func (r VaccineInventoryResponse) ProcessErrorResponse(err error) interface{} {
 		}
 
 	default:
-		log.Println("error type", v)
 		resp.Message = "unable to schedule vaccination drive"
 		resp.Data = []string{}
 		resp.Error = err.Error()
@@ -60,7 +62,7 @@ func (r VaccineInventoryResponse) ProcessErrorResponse(err error) interface{} {
 	return resp
 }
 
-func (resp VaccineInventoryResponse) ProcessVaccineInventoryResponse(req, data interface{}) interface{} {
+func (resp VaccineInventoryResponse) ProcessVaccineInventoryResponse(link links.Builder, req, data interface{}) interface{} {
 	r := VaccineInventoryResponse{}
 	switch req.(type) {
 	case *requests.VaccineInventoryCreateRequest:
@@ -68,15 +70,15 @@ func (resp VaccineInventoryResponse) ProcessVaccineInventoryResponse(req, data i
 		r.Data = data
 		r.Links = map[string]interface{}{
 			"self": map[string]string{
-				"href":   fmt.Sprintf("http://localhost:8080/school-vaccine-portal/vaccine-inventory/drives/%d", data.(*models.VaccineInventory).Id),
+				"href":   link.Href("vaccine-inventory/drives/%d", data.(*models.VaccineInventory).Id),
 				"method": "GET",
 			},
 			"edit": map[string]string{
-				"href":   fmt.Sprintf("http://localhost:8080/school-vaccine-portal/vaccine-inventory/drives/%d", data.(*models.VaccineInventory).Id),
+				"href":   link.Href("vaccine-inventory/drives/%d", data.(*models.VaccineInventory).Id),
 				"method": "PATCH",
 			},
 		}
-	case *requests.GetVaccineInventoryRequest, *requests.VaccineInventoryUpdateRequest:
+	case *requests.GetVaccineInventoryRequest, *requests.VaccineInventoryUpdateRequest, *requests.VaccineInventoryCancelRequest:
 		if len(data.([]models.VaccineInventory)) < 1 {
 			r.Message = "No Upcoming Drives in 30 days"
 			r.Data = []string{}
@@ -86,36 +88,52 @@ func (resp VaccineInventoryResponse) ProcessVaccineInventoryResponse(req, data i
 			for _, j := range data.([]models.VaccineInventory) {
 				vaccineDriveResponse := VaccineInventoryGetResponse{}
 				vaccineDriveResponse.Id = j.Id
+				vaccineDriveResponse.VaccineId = j.VaccineId
 				vaccineDriveResponse.Vaccine = j.VaccineName
 				vaccineDriveResponse.DriveDate = j.DriveDate.Format("2006-01-02")
 				vaccineDriveResponse.Doses = j.Doses
+				vaccineDriveResponse.Used = j.DosesUsed
+				vaccineDriveResponse.Wasted = j.DosesWasted
+				vaccineDriveResponse.Remaining = j.RemainingDoses()
 				vaccineDriveResponse.Classes = j.Classes
+				vaccineDriveResponse.Status = j.Status
+				vaccineDriveResponse.Reason = j.CancellationReason
 				vaccineDriveResponse.CreatedAt = j.CreatedAt.Format("2006-01-02 15:04:05")
 				vaccineDriveResponse.UpdatedAt = j.UpdatedAt.Format("2006-01-02 15:04:05")
-				vaccineDriveResponse.Links = geneRateHateOasForVaccination(j)
+				vaccineDriveResponse.Links = geneRateHateOasForVaccination(link, j)
 				collectionData = append(collectionData, vaccineDriveResponse)
 			}
 			r.Data = collectionData
 			if len(collectionData) == 1 {
 				r.Data = collectionData[0]
 			}
+			if _, ok := req.(*requests.VaccineInventoryCancelRequest); ok {
+				r.Message = "Drive Cancelled Successfully"
+			}
 		}
 
 	}
 	return r
 }
-func geneRateHateOasForVaccination(data models.VaccineInventory) interface{} {
+func geneRateHateOasForVaccination(link links.Builder, data models.VaccineInventory) interface{} {
 	hateOas := map[string]interface{}{}
 	hateOas["self"] = map[string]string{
-		"href":   fmt.Sprintf("http://localhost:8080/school-vaccine-portal/vaccine-inventory/drives/%d", data.Id),
+		"href":   link.Href("vaccine-inventory/drives/%d", data.Id),
 		"method": "GET",
 	}
+	if data.Status == models.DRIVE_COMPLETED || data.Status == models.DRIVE_CANCELLED {
+		return hateOas
+	}
 	if time.Until(data.DriveDate) > 0 {
 		hateOas["edit"] = map[string]string{
-			"href":   fmt.Sprintf("http://localhost:8080/school-vaccine-portal/vaccine-inventory/drives/%d", data.Id),
+			"href":   link.Href("vaccine-inventory/drives/%d", data.Id),
 			"method": "PATCH",
 		}
 	}
+	hateOas["cancel"] = map[string]string{
+		"href":   link.Href("vaccine-inventory/drives/%d/cancel", data.Id),
+		"method": "POST",
+	}
 	return hateOas
 }
 
@@ -1,14 +1,21 @@
 package server
 
 import (
-	"log"
+	"log/slog"
+	"school_vaccination_portal/config"
 	"school_vaccination_portal/controller"
 	"school_vaccination_portal/databases/minio"
 	"school_vaccination_portal/databases/mysql"
 	"school_vaccination_portal/databases/rabbitmq"
+	"school_vaccination_portal/health"
+	"school_vaccination_portal/links"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/metrics"
+	"school_vaccination_portal/notifier"
 	"school_vaccination_portal/repository"
 	"school_vaccination_portal/requests"
 	"school_vaccination_portal/response"
+	"school_vaccination_portal/tracing"
 	"school_vaccination_portal/usecase"
 	"school_vaccination_portal/utils/validator"
 
@@ -16,7 +23,7 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
-func newRouter() *echo.Echo {
+func newRouter(cfg *config.Config, logger *slog.Logger) *echo.Echo {
 	e := echo.New()
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
@@ -24,6 +31,7 @@ func newRouter() *echo.Echo {
 			echo.GET,
 			echo.PATCH,
 			echo.POST,
+			echo.DELETE,
 			echo.OPTIONS,
 		},
 		AllowHeaders: []string{
@@ -31,36 +39,109 @@ func newRouter() *echo.Echo {
 			echo.HeaderContentType,
 			echo.HeaderAccept,
 			echo.HeaderAuthorization,
+			logging.HEADER_REQUEST_ID,
 		},
+		ExposeHeaders: []string{logging.HEADER_REQUEST_ID},
 	}))
-	e.Use(middleware.Logger())
+	unobserved := func(c echo.Context) bool {
+		return health.IsProbe(c) || metrics.IsScrape(c)
+	}
+	e.Use(logging.Middleware(logger, unobserved))
+	e.Use(tracing.Middleware(unobserved))
+	e.Use(metrics.Middleware())
+	e.Use(timeoutMiddleware(cfg.Timeouts))
+	e.Use(links.Middleware(links.NewResolver(cfg.Server)))
 	e.Validator = validator.NewValidator()
-	dbConn, err := mysql.GetMySQLConnect()
+	dbConn, err := mysql.GetMySQLConnect(cfg.Database, logger)
+	if err != nil {
+		logging.Fatal(logger, "error in connecting to db", logging.Err(err))
+	}
+	minIo, err := minio.GetMinIOClient(cfg.Minio, logger)
 	if err != nil {
-		log.Fatalln("error in connecting to db", err.Error())
+		logging.Fatal(logger, "error creating min IO Client", logging.Err(err))
 	}
-	minIo, err := minio.GetMinIOClient()
+	rabb, err := rabbitmq.GetRabbitConn(cfg.Rabbit, logger)
 	if err != nil {
-		log.Fatalln("error creating min IO Client", err.Error())
+		logging.Fatal(logger, "error connecting to rabbitmq", logging.Err(err))
 	}
-	rabb := rabbitmq.GetRabbitConn()
+	checker := health.NewChecker(cfg.Health.Timeout(), logger)
+	checker.Add("mysql", health.MySQL(dbConn))
+	checker.Add("rabbitmq", health.RabbitMQ(rabb))
+	checker.Add("minio", health.MinIO(minIo, cfg.Minio.Bucket))
+	checker.Register(e)
+	metrics.Register(e)
+
+	vaccineCatalogRequest := requests.NewVaccineCatalogRequestHandler()
+	vaccineCatalogRepository := repository.NewVaccineCatalogRepositoryHandler(dbConn, logger)
+	vaccineDriveReqpository := repository.NewVaccineInventoryHandler(dbConn, logger)
+	consentRepo := repository.NewConsentRepositoryHandler(dbConn)
+	exemptionRepo := repository.NewExemptionRepositoryHandler(dbConn)
+	vaccineCatalogUsecase := usecase.NewVaccineCatalogUsecaseHandler(vaccineCatalogRepository, vaccineDriveReqpository, consentRepo, exemptionRepo, logger)
+	vaccineCatalogResponse := response.NewVaccineCatalogResponseHandler()
+	controller.NewVaccineCatalogServiceController(e, vaccineCatalogRequest, vaccineCatalogUsecase, vaccineCatalogResponse)
 
 	vaccineDriveRequest := requests.NewVaccineDriveRequestHandler()
-	vaccineDriveReqpository := repository.NewVaccineInventoryHandler(dbConn)
-	vaccineDriveUsecase := usecase.NewVaccineInventoryUsecaseHandler(vaccineDriveReqpository)
+	vaccineDriveUsecase := usecase.NewVaccineInventoryUsecaseHandler(vaccineDriveReqpository, vaccineCatalogRepository, logger)
 	vaccineResponse := response.NewVacinneInventoryResponseHandler()
 	controller.NewVaccineInventoryServiceController(e, vaccineDriveRequest, vaccineDriveUsecase, vaccineResponse)
 
 	studentmanagementRequest := requests.NewStudentManagementRequestHandler()
-	bulkfilejobrepo := repository.NewBulkFileJobsRepositoryHandler(dbConn, minIo, rabb)
+	unitOfWork := repository.NewUnitOfWorkHandler(dbConn)
+	bulkfilejobrepo := repository.NewBulkFileJobsRepositoryHandler(dbConn, minIo, rabb, cfg.Rabbit.BulkQueue, logger)
 	studentManagementRepo := repository.NewStudentRepositoryHandler(dbConn)
 	studentvaccinationrecordrepo := repository.NewVaccineRecordRepositoryHandler(dbConn)
-	studentmanagementusecase := usecase.NewStudentManagementUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, bulkfilejobrepo, vaccineDriveReqpository)
+	guardianRepo := repository.NewGuardianRepositoryHandler(dbConn)
+	notificationRepo := repository.NewNotificationRepositoryHandler(dbConn, rabb, cfg.Rabbit.NotificationQueue, logger)
+	notificationUsecase := usecase.NewNotificationUsecaseHandler(notificationRepo, guardianRepo, studentManagementRepo, studentvaccinationrecordrepo, vaccineDriveReqpository, exemptionRepo, notifier.NewNotifiers(cfg.Notification, logger), cfg, logger)
+	studentmanagementusecase := usecase.NewStudentManagementUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, bulkfilejobrepo, vaccineDriveReqpository, vaccineCatalogRepository, consentRepo, exemptionRepo, notificationUsecase, unitOfWork, cfg, logger)
 	studentmanagementresponse := response.NewStudentManagementResponseHandler()
-	controller.NewStudentManagementServiceController(e, studentmanagementRequest, studentmanagementusecase, studentmanagementresponse)
+	fhirExportRequest := requests.NewFHIRExportRequestHandler()
+	fhirExportUsecase := usecase.NewFHIRExportUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, vaccineDriveReqpository, vaccineCatalogRepository, bulkfilejobrepo, cfg, logger)
+	hl7ExportRequest := requests.NewHL7ExportRequestHandler()
+	bulkjobResponse := response.NewBulkFileJobResponseHandler()
+	hl7ExportUsecase := usecase.NewHL7ExportUsecaseHandler(studentManagementRepo, studentvaccinationrecordrepo, vaccineDriveReqpository, vaccineCatalogRepository, bulkfilejobrepo, cfg, logger)
+	metrics.Registry.MustRegister(
+		metrics.NewDomainCollector(studentmanagementusecase, vaccineDriveReqpository, bulkfilejobrepo, logger),
+		metrics.NewQueueCollector(logger, rabb, cfg.Rabbit.BulkQueue, cfg.Rabbit.NotificationQueue),
+	)
+	controller.NewStudentManagementServiceController(e, studentmanagementRequest, studentmanagementusecase, studentmanagementresponse, fhirExportUsecase, hl7ExportUsecase)
+	controller.NewFHIRExportController(e, fhirExportRequest, fhirExportUsecase)
+	controller.NewHL7ExportController(e, hl7ExportRequest, hl7ExportUsecase, bulkjobResponse)
+
+	guardianRequest := requests.NewGuardianRequestHandler()
+	guardianUsecase := usecase.NewGuardianUsecaseHandler(guardianRepo, studentManagementRepo, logger)
+	guardianResponse := response.NewGuardianResponseHandler()
+	controller.NewGuardianController(e, guardianRequest, guardianUsecase, guardianResponse)
+
+	consentRequest := requests.NewConsentRequestHandler()
+	consentUsecase := usecase.NewConsentUsecaseHandler(consentRepo, guardianRepo, studentManagementRepo, vaccineDriveReqpository, vaccineCatalogRepository, bulkfilejobrepo, cfg, logger)
+	consentResponse := response.NewConsentResponseHandler(cfg.Minio)
+	controller.NewConsentController(e, consentRequest, consentUsecase, consentResponse)
+
+	exemptionRequest := requests.NewExemptionRequestHandler()
+	exemptionUsecase := usecase.NewExemptionUsecaseHandler(exemptionRepo, studentManagementRepo, vaccineCatalogRepository, bulkfilejobrepo, cfg, logger)
+	exemptionResponse := response.NewExemptionResponseHandler(cfg.Minio)
+	controller.NewExemptionController(e, exemptionRequest, exemptionUsecase, exemptionResponse)
+
+	notificationRequest := requests.NewNotificationRequestHandler()
+	notificationResponse := response.NewNotificationResponseHandler()
+	controller.NewNotificationController(e, notificationRequest, notificationUsecase, notificationResponse, cfg.Notification)
+
 	bulkjobsRequest := requests.NewBulkUploadRequestHandler()
-	bulkjobUc := usecase.NewBulkFileJobUsecaseHandler(studentmanagementusecase, bulkfilejobrepo)
-	controller.NewBulkUploadController(e, bulkjobsRequest, bulkjobUc)
+	bulkjobUc := usecase.NewBulkFileJobUsecaseHandler(studentmanagementusecase, consentUsecase, guardianUsecase, bulkfilejobrepo, unitOfWork, cfg, logger)
+	controller.NewBulkUploadController(e, bulkjobsRequest, bulkjobUc, bulkjobResponse)
+
+	adverseEventRequest := requests.NewAdverseEventRequestHandler()
+	adverseEventRepo := repository.NewAdverseEventRepositoryHandler(dbConn)
+	adverseEventUsecase := usecase.NewAdverseEventUsecaseHandler(adverseEventRepo, studentvaccinationrecordrepo, bulkfilejobrepo, cfg, logger)
+	adverseEventResponse := response.NewAdverseEventResponseHandler()
+	controller.NewAdverseEventController(e, adverseEventRequest, adverseEventUsecase, adverseEventResponse)
+
+	certificateRequest := requests.NewCertificateRequestHandler()
+	certificateRepo := repository.NewCertificateRepositoryHandler(dbConn)
+	certificateUsecase := usecase.NewCertificateUsecaseHandler(certificateRepo, studentManagementRepo, bulkfilejobrepo, unitOfWork, cfg, logger)
+	certificateResponse := response.NewCertificateResponseHandler()
+	controller.NewCertificateController(e, certificateRequest, certificateUsecase, certificateResponse)
 
 	return e
 }
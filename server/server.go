@@ -1,17 +1,19 @@
 package server
 
 import (
-	"log"
+	"log/slog"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-func Start() {
-	router := newRouter()
+func Start(cfg *config.Config, logger *slog.Logger) {
+	router := newRouter(cfg, logger)
 
 	if router == nil {
-		log.Println("Router Not Initialized")
+		logger.Error("router not initialized")
 		return
 	}
 	e := echo.New()
@@ -22,5 +24,8 @@ func Start() {
 		router.ServeHTTP(resp, req)
 		return
 	})
-	e.Logger.Fatal(e.Start(":8080"))
+	e.HideBanner = true
+	e.HidePort = true
+	logger.Info("api listening", "address", cfg.Server.Address())
+	logging.Fatal(logger, "api server stopped", logging.Err(e.Start(cfg.Server.Address())))
 }
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"school_vaccination_portal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// reportRoutes build a whole file before they answer, they get the report deadline instead of the request one
+var reportRoutes = map[string]bool{
+	"/school-vaccine-portal/student-management/vaccine-records/genrate-report": true,
+	"/school-vaccine-portal/student-management/students/certificates":          true,
+	"/school-vaccine-portal/adverse-events/summary-report":                     true,
+	"/school-vaccine-portal/consents/pending-report":                           true,
+	"/school-vaccine-portal/exports/fhir":                                      true,
+}
+
+// timeoutMiddleware puts a deadline on the request context, so the queries and MinIO calls made for the request
+// are cancelled once it passes or the client goes away. A request that failed because its deadline passed is
+// answered 504 whatever status the handler picked.
+func timeoutMiddleware(cfg config.TimeoutConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout := cfg.Request()
+			if reportRoutes[c.Path()] {
+				timeout = cfg.Report()
+			}
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			c.Response().Before(func() {
+				if c.Response().Status >= http.StatusBadRequest && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					c.Response().Status = http.StatusGatewayTimeout
+				}
+			})
+			return next(c)
+		}
+	}
+}
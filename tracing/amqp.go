@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier lets the propagator read and write trace context in message headers
+type headerCarrier amqp.Table
+
+func (h headerCarrier) Get(key string) string {
+	value, _ := h[key].(string)
+	return value
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h[key] = value
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// StartPublish starts the span of publishing to queue and writes its trace context into headers,
+// which must then be sent with the message
+func StartPublish(ctx context.Context, queue string, headers amqp.Table) (context.Context, trace.Span) {
+	ctx, span := Tracer().Start(ctx, queue+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitMQ,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(queue),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+	return ctx, span
+}
+
+// StartConsume starts the span of handling a message taken from queue, in the trace it was published under
+func StartConsume(queue string, delivery amqp.Delivery) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(delivery.Headers))
+	return Tracer().Start(ctx, queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitMQ,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(queue),
+			semconv.MessagingMessageConversationID(delivery.CorrelationId),
+		),
+	)
+}
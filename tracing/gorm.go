@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	GORM_CONTEXT_KEY = "tracing:context"
+	gormSpanKey      = "tracing:span"
+)
+
+var instrumentGorm sync.Once
+
+// InstrumentGorm records a client span for every create, query, update and delete gorm runs. The callbacks go on
+// gorm's defaults so every handle opened on the connection pool has them. Queries join the trace of the context
+// WithContext attached, without one each query starts its own trace.
+func InstrumentGorm() {
+	instrumentGorm.Do(registerCallbacks)
+}
+
+func registerCallbacks() {
+	callbacks := gorm.DefaultCallback
+	callbacks.Create().Before("gorm:create").Register("tracing:before_create", startQuery("INSERT"))
+	callbacks.Create().After("gorm:create").Register("tracing:after_create", endQuery)
+	callbacks.Query().Before("gorm:query").Register("tracing:before_query", startQuery("SELECT"))
+	callbacks.Query().After("gorm:query").Register("tracing:after_query", endQuery)
+	callbacks.RowQuery().Before("gorm:row_query").Register("tracing:before_row_query", startQuery("SELECT"))
+	callbacks.RowQuery().After("gorm:row_query").Register("tracing:after_row_query", endQuery)
+	callbacks.Update().Before("gorm:update").Register("tracing:before_update", startQuery("UPDATE"))
+	callbacks.Update().After("gorm:update").Register("tracing:after_update", endQuery)
+	callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", startQuery("DELETE"))
+	callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", endQuery)
+}
+
+// WithContext makes the queries run on the returned db children of the span in ctx
+func WithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db.Set(GORM_CONTEXT_KEY, ctx)
+}
+
+func startQuery(operation string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		ctx := context.Background()
+		if value, ok := scope.Get(GORM_CONTEXT_KEY); ok {
+			if parent, ok := value.(context.Context); ok {
+				ctx = parent
+			}
+		}
+		table := scope.TableName()
+		_, span := Tracer().Start(ctx, operation+" "+table,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameMySQL,
+				semconv.DBOperationName(operation),
+				semconv.DBCollectionName(table),
+			),
+		)
+		scope.Set(gormSpanKey, span)
+	}
+}
+
+func endQuery(scope *gorm.Scope) {
+	value, ok := scope.Get(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+	//most filters are formatted into the statement, its literals can hold names and phone numbers
+	span.SetAttributes(semconv.DBQueryText(sanitizeQuery(scope.SQL)))
+	if err := scope.DB().Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// sanitizeQuery replaces the string and number literals of a statement with ?, leaving its shape to trace by.
+// Quoted identifiers are kept, a number that is part of a name such as sp_1 is not a literal.
+func sanitizeQuery(query string) string {
+	var out strings.Builder
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			for i++; i < len(query); i++ {
+				if query[i] == '\\' {
+					i++
+				} else if query[i] == c {
+					//a doubled quote is an escaped quote inside the literal
+					if i+1 < len(query) && query[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			out.WriteByte('?')
+		case c == '`':
+			end := strings.IndexByte(query[i+1:], '`')
+			if end < 0 {
+				out.WriteString(query[i:])
+				return out.String()
+			}
+			out.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c >= '0' && c <= '9' && (i == 0 || !isWordByte(query[i-1])):
+			for i+1 < len(query) && (isWordByte(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			out.WriteByte('?')
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,23 @@
+package tracing
+
+import "testing"
+
+func TestSanitizeQuery(t *testing.T) {
+	cases := []struct {
+		query     string
+		sanitized string
+	}{
+		{"SELECT * FROM `guardians` WHERE (phone = '9876543210')", "SELECT * FROM `guardians` WHERE (phone = ?)"},
+		{`SELECT * FROM student_management WHERE name LIKE "%Asha%" AND class = "5"`, "SELECT * FROM student_management WHERE name LIKE ? AND class = ?"},
+		{"SELECT * FROM students WHERE name = 'O''Neil' OR name = 'D\\'Souza'", "SELECT * FROM students WHERE name = ? OR name = ?"},
+		{"UPDATE vaccination_inventory SET doses_used = doses_used + 1 WHERE id = 42", "UPDATE vaccination_inventory SET doses_used = doses_used + ? WHERE id = ?"},
+		{"SELECT * FROM `table_2` WHERE ratio > 0.5 LIMIT 10", "SELECT * FROM `table_2` WHERE ratio > ? LIMIT ?"},
+		{"RELEASE SAVEPOINT sp_1", "RELEASE SAVEPOINT sp_1"},
+		{"SELECT * FROM t WHERE a = ?", "SELECT * FROM t WHERE a = ?"},
+	}
+	for _, c := range cases {
+		if sanitized := sanitizeQuery(c.query); sanitized != c.sanitized {
+			t.Errorf("%q sanitized to %q, expected %q", c.query, sanitized, c.sanitized)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package tracing records OpenTelemetry spans for HTTP requests, database queries, MinIO calls and queue messages,
+// and carries the trace context from the request that queues a bulk job to the worker that runs it.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TRACER_NAME = "school_vaccination_portal"
+
+	EXPORTER_NONE   = "none"
+	EXPORTER_OTLP   = "otlp"
+	EXPORTER_STDOUT = "stdout"
+
+	TRACE_ID_KEY = "trace_id"
+)
+
+// Init installs the tracer provider and the W3C propagators for this process, component tells the API and the
+// workers apart in the service name. The returned function flushes the spans still buffered, call it before exiting.
+// With the none exporter no spans are recorded but incoming trace context is still passed on.
+func Init(cfg config.TracingConfig, component string, logger *slog.Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(cfg.Exporter) {
+	case EXPORTER_NONE:
+		return func(context.Context) error { return nil }, nil
+	case EXPORTER_OTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+	case EXPORTER_STDOUT:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("invalid TRACING_EXPORTER %q, use none, otlp or stdout", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s trace exporter %s", cfg.Exporter, err.Error())
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName+"-"+component),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("tracing error", logging.Err(err))
+	}))
+	logger.Info("tracing enabled", "exporter", cfg.Exporter, "service", cfg.ServiceName+"-"+component, "sample_ratio", cfg.SampleRatio)
+	return provider.Shutdown, nil
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(TRACER_NAME)
+}
+
+// RecordError marks span failed with err, a nil err leaves it as it is
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Middleware starts a server span for every request, continuing the caller's trace when it sent a traceparent header,
+// and adds the trace id to the request logger. It names spans after the route template so ids in the path stay out.
+// skipper leaves out the requests not worth a span.
+func Middleware(skipper func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper != nil && skipper(c) {
+				return next(c)
+			}
+			req := c.Request()
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := Tracer().Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(req.URL.Path),
+					semconv.ClientAddress(c.RealIP()),
+				),
+			)
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+			if span.SpanContext().IsValid() {
+				c.Set(logging.CONTEXT_KEY, logging.From(c).With(TRACE_ID_KEY, span.SpanContext().TraceID().String()))
+			}
+
+			err := next(c)
+			//the error handler decides the status code, run it now so the status is known
+			if err != nil {
+				c.Error(err)
+			}
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return err
+		}
+	}
+}
+
+type transport struct {
+	name string
+	base http.RoundTripper
+}
+
+// Transport records a client span for every call made through base, name says which service is called
+func Transport(name string, base http.RoundTripper) http.RoundTripper {
+	return &transport{name: name, base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(req.Context(), t.name+" "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+	defer span.End()
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		RecordError(span, err)
+		return resp, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
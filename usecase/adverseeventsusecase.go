@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type AdverseEventUsecaseHandler interface {
+	CreateAdverseEvent(ctx context.Context, event *models.AdverseEvent) error
+	GetAdverseEvents(ctx context.Context, request *requests.GetAdverseEventRequest) (int, []models.AdverseEvent, error)
+	GenerateAdverseEventReport(ctx context.Context, request *requests.AdverseEventReportRequest) (string, error)
+}
+
+type AdverseEventUsecase struct {
+	repo                         repository.AdverseEventRepositoryHandler
+	studentVaccinationRecordRepo repository.StudentVaccinationRecordRepositoryHandler
+	bulkFileJobsRepo             repository.BulkFileJobsRepositoryHandler
+	config                       *config.Config
+	logger                       *slog.Logger
+}
+
+func (a *AdverseEventUsecase) CreateAdverseEvent(ctx context.Context, event *models.AdverseEvent) error {
+	records, err := a.studentVaccinationRecordRepo.GetVaccinationRecords(ctx, fmt.Sprintf("id = %d", event.VaccinationRecordId))
+	if err != nil {
+		a.logger.Error("error fetching vaccination record", logging.Err(err))
+		return errors.New("unable to report adverse event please try again later")
+	}
+	if len(records) == 0 || records[0].Status == models.RECORD_VOIDED {
+		return fmt.Errorf("no vaccination record exists with id %d", event.VaccinationRecordId)
+	}
+	//a corrected record no longer stands for the dose, the event belongs on the record that replaced it
+	if records[0].Status == models.RECORD_SUPERSEDED {
+		return fmt.Errorf("vaccination record %d was corrected, report the adverse event against record %d", event.VaccinationRecordId, records[0].SupersededBy)
+	}
+	record := records[0]
+	if event.OnsetAt.After(time.Now()) {
+		return fmt.Errorf("onset_at %s is in the future", event.OnsetAt.Format("2006-01-02 15:04"))
+	}
+	if record.AdministeredAt != nil && event.OnsetAt.Before(*record.AdministeredAt) {
+		return fmt.Errorf("onset_at %s is before the vaccination was administered on %s", event.OnsetAt.Format("2006-01-02 15:04"), record.AdministeredAt.Format("2006-01-02 15:04"))
+	}
+	//drive and lot are copied from the record so events can be grouped per batch
+	event.StudentId = record.StudentId
+	event.DriveId = record.DriveId
+	event.LotNumber = record.LotNumber
+	return a.repo.CreateAdverseEvent(ctx, event)
+}
+
+func (a *AdverseEventUsecase) GetAdverseEvents(ctx context.Context, request *requests.GetAdverseEventRequest) (int, []models.AdverseEvent, error) {
+	conditions := []string{}
+	if request.Id != 0 {
+		conditions = append(conditions, fmt.Sprintf("a.id = %d", request.Id))
+	}
+	if request.VaccinationRecordId != 0 {
+		conditions = append(conditions, fmt.Sprintf("a.vaccination_record_id = %d", request.VaccinationRecordId))
+	}
+	if request.StudentId != 0 {
+		conditions = append(conditions, fmt.Sprintf("a.student_id = %d", request.StudentId))
+	}
+	if request.DriveId != 0 {
+		conditions = append(conditions, fmt.Sprintf("a.drive_id = %d", request.DriveId))
+	}
+	if request.LotNumber != "" {
+		conditions = append(conditions, fmt.Sprintf("a.lot_number = '%s'", strings.ReplaceAll(request.LotNumber, "'", "''")))
+	}
+	if request.Severity != "" {
+		conditions = append(conditions, fmt.Sprintf("a.severity = '%s'", request.Severity))
+	}
+	filter := strings.Join(conditions, " AND ")
+	total, err := a.repo.GetAdverseEventCount(ctx, filter)
+	if err != nil {
+		a.logger.Error("error fetching adverse event count", logging.Err(err))
+		return total, nil, err
+	}
+	events, err := a.repo.GetAdverseEvents(ctx, filter, request.Pagination)
+	return total, events, err
+}
+
+func (a *AdverseEventUsecase) GenerateAdverseEventReport(ctx context.Context, request *requests.AdverseEventReportRequest) (string, error) {
+	conditions := []string{}
+	if request.DriveId != 0 {
+		conditions = append(conditions, fmt.Sprintf("a.drive_id = %d", request.DriveId))
+	}
+	if request.LotNumber != "" {
+		conditions = append(conditions, fmt.Sprintf("a.lot_number = '%s'", strings.ReplaceAll(request.LotNumber, "'", "''")))
+	}
+	summary, err := a.repo.GetAdverseEventSummary(ctx, strings.Join(conditions, " AND "))
+	if err != nil {
+		a.logger.Error("error fetching adverse event summary", logging.Err(err))
+		return "", err
+	}
+	if len(summary) == 0 {
+		return "", errors.New("no adverse events reported for the given filter")
+	}
+	byDrive := []models.AdverseEventSummary{}
+	driveIndex := map[int]int{}
+	for _, lot := range summary {
+		i, ok := driveIndex[lot.DriveId]
+		if !ok {
+			driveIndex[lot.DriveId] = len(byDrive)
+			byDrive = append(byDrive, models.AdverseEventSummary{DriveId: lot.DriveId, VaccineName: lot.VaccineName})
+			i = len(byDrive) - 1
+		}
+		byDrive[i].TotalEvents += lot.TotalEvents
+		byDrive[i].Mild += lot.Mild
+		byDrive[i].Moderate += lot.Moderate
+		byDrive[i].Severe += lot.Severe
+		byDrive[i].Serious += lot.Serious
+	}
+	//a drive's rate is over every dose it gave, not only the doses from lots that had events
+	driveIds := make([]int, len(byDrive))
+	for i, d := range byDrive {
+		driveIds[i] = d.DriveId
+	}
+	doses, err := a.repo.GetDriveDoses(ctx, driveIds, request.LotNumber)
+	if err != nil {
+		a.logger.Error("error fetching doses given per drive", logging.Err(err))
+		return "", err
+	}
+	for _, d := range doses {
+		byDrive[driveIndex[d.DriveId]].DosesAdministered = d.DosesAdministered
+	}
+	//Create report
+	reportFile := excelize.NewFile()
+	lotSheet := "By Lot"
+	driveSheet := "By Drive"
+	reportFile.SetSheetName("Sheet1", lotSheet)
+	reportFile.NewSheet(driveSheet)
+	writeAdverseEventSummary(reportFile, lotSheet, summary, true)
+	writeAdverseEventSummary(reportFile, driveSheet, byDrive, false)
+	reportFile.SetActiveSheet(0)
+	reportFileName, err := saveReportFile(reportFile, "AEFIReport.xlsx")
+	if err != nil {
+		a.logger.Error("unable to save report File Locally", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	defer os.RemoveAll(filepath.Dir(reportFileName))
+	uploadedReportFile, err := a.bulkFileJobsRepo.UploadFileToMinio(ctx, reportFileName, a.config.Minio.Bucket, "reports/", request.RequestId)
+	if err != nil {
+		a.logger.Error("error in uploading file to minio", logging.Err(err))
+		return "", err
+	}
+	return a.config.Minio.ObjectURL(uploadedReportFile), nil
+}
+
+func writeAdverseEventSummary(reportFile *excelize.File, sheet string, rows []models.AdverseEventSummary, withLot bool) {
+	reportHeaders := []string{"Drive Id", "Vaccine Name", "Lot Number", "Doses Administered", "Total Events", "Mild", "Moderate", "Severe", "Serious", "Events per 1000 Doses"}
+	if !withLot {
+		reportHeaders = append(reportHeaders[:2], reportHeaders[3:]...)
+	}
+	for col, header := range reportHeaders {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1) // (col+1, row=1)
+		reportFile.SetCellValue(sheet, cell, header)
+	}
+	for i, row := range rows {
+		values := []interface{}{row.DriveId, row.VaccineName}
+		if withLot {
+			values = append(values, row.LotNumber)
+		}
+		rate := 0.0
+		if row.DosesAdministered > 0 {
+			rate = float64(row.TotalEvents) * 1000 / float64(row.DosesAdministered)
+		}
+		values = append(values, row.DosesAdministered, row.TotalEvents, row.Mild, row.Moderate, row.Severe, row.Serious, fmt.Sprintf("%.2f", rate))
+		for col, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, i+2)
+			reportFile.SetCellValue(sheet, cell, value)
+		}
+	}
+}
+
+func NewAdverseEventUsecaseHandler(repo repository.AdverseEventRepositoryHandler, studentvaccinationrepo repository.StudentVaccinationRecordRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, cfg *config.Config, logger *slog.Logger) AdverseEventUsecaseHandler {
+	return &AdverseEventUsecase{
+		repo:                         repo,
+		studentVaccinationRecordRepo: studentvaccinationrepo,
+		bulkFileJobsRepo:             bulkfileJobsRepo,
+		config:                       cfg,
+		logger:                       logger,
+	}
+}
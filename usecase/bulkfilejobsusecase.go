@@ -2,387 +2,610 @@ package usecase
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/repository"
 	"school_vaccination_portal/requests"
 	"school_vaccination_portal/utils/validator"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
 type BulkFileJobUsecaseHandler interface {
-	UploadBulkRequestFile(req *models.BulkFileJobsModel) error
-	ProcessBulkStudentRecord(model *models.BulkFileJobsModel) error
-	ProcessBulkVaccineRecord(model *models.BulkFileJobsModel) error
-	GetBulkFileJobDetails(requestId string, pagination requests.Pagination) (int, []models.BulkFileJobsModel, error)
+	UploadBulkRequestFile(ctx context.Context, req *models.BulkFileJobsModel) error
+	StageBulkFile(ctx context.Context, req *models.BulkFileJobsModel) error
+	ProcessBulkStudentRecord(ctx context.Context, model *models.BulkFileJobsModel) error
+	ProcessBulkVaccineRecord(ctx context.Context, model *models.BulkFileJobsModel) error
+	ProcessBulkConsentRecord(ctx context.Context, model *models.BulkFileJobsModel) error
+	ProcessBulkGuardianRecord(ctx context.Context, model *models.BulkFileJobsModel) error
+	GetBulkFileJobDetails(ctx context.Context, requestId string, pagination requests.Pagination) (int, []models.BulkFileJobsModel, error)
 }
 
 type BulkFileJobUsecase struct {
 	studentManagementusecaseRepo StudentManagementUsecaseHandler
+	consentUsecaseRepo           ConsentUsecaseHandler
+	guardianUsecaseRepo          GuardianUsecaseHandler
 	bulkFileJobsRepo             repository.BulkFileJobsRepositoryHandler
+	unitOfWork                   repository.UnitOfWorkHandler
+	config                       *config.Config
+	logger                       *slog.Logger
 }
 
-func (b *BulkFileJobUsecase) GetBulkFileJobDetails(requestId string, pagination requests.Pagination) (int, []models.BulkFileJobsModel, error) {
+func (b *BulkFileJobUsecase) GetBulkFileJobDetails(ctx context.Context, requestId string, pagination requests.Pagination) (int, []models.BulkFileJobsModel, error) {
 	var count int
 	var result []models.BulkFileJobsModel
 	var err error
 	//get count
-	count, err = b.bulkFileJobsRepo.GetBulkFileJobCounts(requestId, pagination)
+	count, err = b.bulkFileJobsRepo.GetBulkFileJobCounts(ctx, requestId, pagination)
 	//handle error
 	if err != nil {
-		log.Println("error in fetching count", err.Error())
+		b.logger.Error("error in fetching count", logging.Err(err))
 		return count, result, err
 	}
 	//get data
-	result, err = b.bulkFileJobsRepo.GetBulkFileJobs(requestId, pagination)
+	result, err = b.bulkFileJobsRepo.GetBulkFileJobs(ctx, requestId, pagination)
 	//handle error
 	if err != nil {
-		log.Println("error in fetching count", err.Error())
+		b.logger.Error("error in fetching count", logging.Err(err))
 		return count, result, err
 	}
 	return count, result, err
 }
 
-func (b *BulkFileJobUsecase) UploadBulkRequestFile(req *models.BulkFileJobsModel) error {
+func (b *BulkFileJobUsecase) UploadBulkRequestFile(ctx context.Context, req *models.BulkFileJobsModel) error {
+	if err := b.StageBulkFile(ctx, req); err != nil {
+		return err
+	}
+	//dump in rmq to be picked by async worker
+	return b.bulkFileJobsRepo.SubmitToRabbitMQ(ctx, req)
+}
+
+// StageBulkFile uploads the file and records the PENDING job without queueing it, the caller runs it
+func (b *BulkFileJobUsecase) StageBulkFile(ctx context.Context, req *models.BulkFileJobsModel) error {
 	var err error
-	uploadLoc, err := b.bulkFileJobsRepo.UploadFileToMinio(req.FilePath, os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), "uploads/", req.RequestId)
+	uploadLoc, err := b.bulkFileJobsRepo.UploadFileToMinio(ctx, req.FilePath, b.config.Minio.Bucket, "uploads/", req.RequestId)
 	if err != nil {
-		log.Printf("error in uploading to minIo %s", err.Error())
+		b.logger.Error("error in uploading to minio", "job_id", req.RequestId, logging.Err(err))
 		return err
 	}
-	log.Println("Uploaded at: ", uploadLoc)
+	b.logger.Info("bulk file uploaded", "job_id", req.RequestId, "object", uploadLoc)
 	req.FilePath = uploadLoc
 	//Create Entry in DB
-	if err = b.bulkFileJobsRepo.CreateFileUpload(req); err != nil {
+	if err = b.bulkFileJobsRepo.CreateFileUpload(ctx, req); err != nil {
 		return fmt.Errorf("error in creating bulk upload file entry %s", err.Error())
 	}
-	//dump in rmq to be picked by async worker
-	return b.bulkFileJobsRepo.SubmitToRabbitMQ(req, "bulk_upload")
+	return nil
 }
-func (b *BulkFileJobUsecase) ProcessBulkVaccineRecord(model *models.BulkFileJobsModel) error {
-	b.bulkFileJobsRepo.UpdateFileUpload(&models.BulkFileJobsModel{Id: model.Id, Status: "PROCESSING"})
-	//Get the file
-	fileLoc, err := b.bulkFileJobsRepo.GetFileFromActiveServer(os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), model.FilePath)
-	if err != nil {
-		model.ErrorMessage = "Internal Server Error"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
-	}
-	//validate the file
-	file, err := os.Open(fileLoc)
-	if err != nil {
-		model.ErrorMessage = "Inavlid File, Only .xlsx or .xls allowed"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
-	}
-	defer file.Close()
-	header := make([]byte, 8)
-	_, err = file.Read(header)
-	if err != nil {
-		model.ErrorMessage = "Inavlid File, Only .xlsx or .xls allowed"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
-	}
-	if !(bytes.HasPrefix(header, []byte{0x50, 0x4B, 0x03, 0x04}) || bytes.Equal(header, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})) {
-		model.ErrorMessage = "Inavlid File, Only .xlsx or .xls allowed"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
-	}
-	f, err := excelize.OpenFile(fileLoc)
-	if err != nil {
-		log.Printf("failed to open Excel file: %v", err)
-		model.ErrorMessage = "Internal Server Error"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
-	}
-	defer f.Close()
-	sheets := f.GetSheetList()
-	log.Printf("Sheets: %v\n", sheets)
-	sheetName := sheets[0]
-
-	rows, err := f.GetRows(sheetName)
-	//Header Adjusted
-	model.TotalRecords = len(rows) - 1
-	if err != nil {
-		log.Fatalf("failed to read rows: %v", err)
-	}
+func (b *BulkFileJobUsecase) ProcessBulkVaccineRecord(ctx context.Context, model *models.BulkFileJobsModel) error {
+	logger := logging.ForJob(b.logger, model)
 	vaccineRecord := new([]models.StudentVaccineRecord)
 	insertionRecords := []models.VaccineInsertionDBRecord{}
 	validat := validator.NewValidator()
-	for i, row := range rows {
-		if i == 0 {
-			continue
-		}
-		if len(row) != 2 {
-			log.Printf("failed to open Excel file: %v", err)
-			model.ErrorMessage = "Missing Columns"
-			model.Status = "FAILED"
-			b.bulkFileJobsRepo.UpdateFileUpload(model)
-			return nil
-		}
+	//template: Student Id, Drive Id, Lot Number, Expiry Date, Administered By, Administered At, Dose Number, Injection Site
+	//dates as YYYY-MM-DD and YYYY-MM-DD HH:MM, or date cells, see parseTemplateDate
+	read := b.readUpload(ctx, logger, model, 5, 8, func(line int, row []string) error {
 		insertionRecord := models.VaccineInsertionDBRecord{}
 		studentId, err := strconv.Atoi(row[0])
 		if err != nil {
-			log.Printf("invalid insertion Record: %v", err.Error())
-			model.ErrorMessage = fmt.Sprintf("invalid entry at row %d", i+1)
-			model.Status = "FAILED"
-			b.bulkFileJobsRepo.UpdateFileUpload(model)
-			return nil
+			logger.Warn("invalid insertion record", "row", line, logging.Err(err))
+			return fmt.Errorf("invalid entry at row %d", line)
 		}
 		driveId, err := strconv.Atoi(row[1])
 		if err != nil {
-			log.Printf("invalid insertion Record: %v", err.Error())
-			model.ErrorMessage = fmt.Sprintf("invalid entry at row %d", i+1)
-			model.Status = "FAILED"
-			b.bulkFileJobsRepo.UpdateFileUpload(model)
-			return nil
+			logger.Warn("invalid insertion record", "row", line, logging.Err(err))
+			return fmt.Errorf("invalid entry at row %d", line)
 		}
+		optional := append(row, make([]string, 8-len(row))...)
 
 		sReq := requests.StudentVaccinationRecordCreateRequest{
-			StudentId: studentId,
-			DriveId:   driveId,
+			StudentId:      studentId,
+			DriveId:        driveId,
+			LotNumber:      strings.TrimSpace(row[2]),
+			AdministeredBy: strings.TrimSpace(row[4]),
+			InjectionSite:  strings.TrimSpace(optional[7]),
 		}
 		sModel := models.StudentVaccineRecord{
-			StudentId: studentId,
-			DriveId:   driveId,
+			StudentId:      studentId,
+			DriveId:        driveId,
+			LotNumber:      sReq.LotNumber,
+			AdministeredBy: sReq.AdministeredBy,
+			InjectionSite:  sReq.InjectionSite,
 		}
-		err = validat.Validate(sReq)
-		if err != nil {
-			insertionRecord.Record = sModel
-			insertionRecord.Status = false
-			insertionRecord.ErrorReason = "invalid input"
+		insertionRecord.Record = sModel
+		insertionRecord.Status = false
+		if sReq.ExpiryDate, err = parseTemplateDate(row[3]); err != nil {
+			insertionRecord.ErrorReason = fmt.Sprintf("invalid expiry date at row %d, use YYYY-MM-DD", line)
 			insertionRecords = append(insertionRecords, insertionRecord)
-			continue
+			return nil
+		}
+		if strings.TrimSpace(optional[5]) != "" {
+			if sReq.AdministeredAt, err = parseTemplateDate(optional[5]); err != nil {
+				insertionRecord.ErrorReason = fmt.Sprintf("invalid administered at date at row %d, use YYYY-MM-DD HH:MM", line)
+				insertionRecords = append(insertionRecords, insertionRecord)
+				return nil
+			}
+		}
+		if strings.TrimSpace(optional[6]) != "" {
+			if sReq.DoseNumber, err = strconv.Atoi(strings.TrimSpace(optional[6])); err != nil {
+				insertionRecord.ErrorReason = fmt.Sprintf("invalid dose number at row %d", line)
+				insertionRecords = append(insertionRecords, insertionRecord)
+				return nil
+			}
+		}
+		sModel.ExpiryDate = sReq.ExpiryDate
+		sModel.AdministeredAt = sReq.AdministeredAt
+		sModel.DoseNumber = sReq.DoseNumber
+		insertionRecord.Record = sModel
+		if err = validat.Validate(sReq); err != nil {
+			insertionRecord.ErrorReason = err.Error()
+			insertionRecords = append(insertionRecords, insertionRecord)
+			return nil
 		}
 		*vaccineRecord = append(*vaccineRecord, sModel)
+		return nil
+	})
+	if !read {
+		return nil
 	}
-	result := b.studentManagementusecaseRepo.CreateVaccinationRecords(vaccineRecord)
-
-	log.Println("Request Processing Complete", result)
-	result = append(result, insertionRecords...)
+	return b.importRows(ctx, logger, model, []string{"Student Id", "Drive Id", "Lot Number", "Dose Number"}, func(ctx context.Context) []bulkRow {
+		result := b.studentManagementusecaseRepo.CreateVaccinationRecords(ctx, vaccineRecord)
+		logger.Debug("valid rows sent to the database", "rows", len(result))
+		result = append(result, insertionRecords...)
+		rows := make([]bulkRow, len(result))
+		for i, insertion := range result {
+			record, accepted := insertion.Record, insertion.Status
+			rows[i] = bulkRow{accepted: accepted, reason: insertion.ErrorReason, cells: func(bool) []interface{} {
+				//the dose number is assigned on insert, a rejected row has none
+				if !accepted {
+					return []interface{}{record.StudentId, record.DriveId, record.LotNumber, nil}
+				}
+				return []interface{}{record.StudentId, record.DriveId, record.LotNumber, record.DoseNumber}
+			}}
+		}
+		return rows
+	})
+}
 
-	//Create report
-	reportFile := excelize.NewFile()
-	reportShheetName := "Report"
-	index, _ := reportFile.NewSheet(reportShheetName)
-	reportHeaders := []string{"Student Id", "Drive Id", "Status", "Remarks"}
-	for col, header := range reportHeaders {
-		cell, _ := excelize.CoordinatesToCellName(col+1, 1) // (col+1, row=1)
-		reportFile.SetCellValue(reportShheetName, cell, header)
+func (b *BulkFileJobUsecase) ProcessBulkStudentRecord(ctx context.Context, model *models.BulkFileJobsModel) error {
+	logger := logging.ForJob(b.logger, model)
+	studentSet := new([]models.StudentManagement)
+	insertionRecords := []models.DBInsertionRecord{}
+	validat := validator.NewValidator()
+	//template: Name, Class, Gender, Roll Number, Phone Number
+	read := b.readUpload(ctx, logger, model, 5, 5, func(line int, row []string) error {
+		sReq := requests.StudentManagementCreateRequest{
+			Name:    row[0],
+			Class:   row[1],
+			Gender:  row[2],
+			RollNo:  row[3],
+			PhoneNo: row[4],
+		}
+		sModel := models.StudentManagement{
+			Name:       row[0],
+			Class:      row[1],
+			Gender:     row[2],
+			RollNumber: row[3],
+			PhoneNo:    row[4],
+		}
+		if err := validat.Validate(sReq); err != nil {
+			insertionRecords = append(insertionRecords, models.DBInsertionRecord{Record: sModel, Status: false, ErrorReason: err.Error()})
+			return nil
+		}
+		*studentSet = append(*studentSet, sModel)
+		return nil
+	})
+	if !read {
+		return nil
 	}
-	//Keeping track Of Processed File
-	totalProcessed := 0
-	for i, insertion := range result {
-		rowNum := i + 2
-		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("A%d", rowNum), insertion.Record.StudentId)
-		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("B%d", rowNum), insertion.Record.DriveId)
-		if !insertion.Status {
-			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("C%d", rowNum), "Rejected")
-			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("D%d", rowNum), insertion.ErrorReason)
-		} else {
-			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("C%d", rowNum), "Accepted")
-			totalProcessed++
-		}
-	}
-	model.ProcessedRecords = totalProcessed
-	reportFile.SetActiveSheet(index)
-	reportFileName := "Report.xlsx"
-	err = reportFile.SaveAs(reportFileName)
-	if err != nil {
-		log.Println("error creating report file", err.Error(), reportFileName)
-		model.ErrorMessage = "Report File Not Genrated"
-		model.Status = "PROCESSED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
+	return b.importRows(ctx, logger, model, []string{"Name", "Class", "Gender", "Roll Number", "Phone Number"}, func(ctx context.Context) []bulkRow {
+		result := b.studentManagementusecaseRepo.CreateStudentRecords(ctx, studentSet)
+		logger.Debug("valid rows sent to the database", "rows", len(result))
+		result = append(result, insertionRecords...)
+		rows := make([]bulkRow, len(result))
+		for i, insertion := range result {
+			record := insertion.Record
+			rows[i] = bulkRow{accepted: insertion.Status, reason: insertion.ErrorReason, cells: func(bool) []interface{} {
+				return []interface{}{record.Name, record.Class, record.Gender, record.RollNumber, record.PhoneNo}
+			}}
+		}
+		return rows
+	})
+}
+
+func (b *BulkFileJobUsecase) ProcessBulkConsentRecord(ctx context.Context, model *models.BulkFileJobsModel) error {
+	logger := logging.ForJob(b.logger, model)
+	consents := new([]models.Consent)
+	insertionRecords := []models.ConsentInsertionRecord{}
+	validat := validator.NewValidator()
+	//template: Student Id, Drive Id, Vaccine Id, Status, Guardian Name, Consent Date, either drive or vaccine may be blank
+	//the consent date as YYYY-MM-DD or a date cell, see parseTemplateDate
+	read := b.readUpload(ctx, logger, model, 6, 6, func(line int, row []string) error {
+		ids := make([]int, 3)
+		invalid := false
+		for col := range ids {
+			if strings.TrimSpace(row[col]) == "" {
+				continue
+			}
+			var err error
+			if ids[col], err = strconv.Atoi(strings.TrimSpace(row[col])); err != nil {
+				invalid = true
+			}
+		}
+		sReq := requests.ConsentCreateRequest{
+			StudentId:    ids[0],
+			DriveId:      ids[1],
+			VaccineId:    ids[2],
+			Status:       strings.ToUpper(strings.TrimSpace(row[3])),
+			GuardianName: strings.TrimSpace(row[4]),
+		}
+		sModel := models.Consent{
+			StudentId:    sReq.StudentId,
+			DriveId:      sReq.DriveId,
+			VaccineId:    sReq.VaccineId,
+			Status:       sReq.Status,
+			GuardianName: sReq.GuardianName,
+		}
+		insertionRecord := models.ConsentInsertionRecord{Record: sModel, Status: false}
+		if invalid {
+			insertionRecord.ErrorReason = fmt.Sprintf("invalid id at row %d", line)
+			insertionRecords = append(insertionRecords, insertionRecord)
+			return nil
+		}
+		consentDate, err := parseTemplateDate(row[5])
+		if err != nil {
+			insertionRecord.ErrorReason = fmt.Sprintf("invalid consent date at row %d, use YYYY-MM-DD", line)
+			insertionRecords = append(insertionRecords, insertionRecord)
+			return nil
+		}
+		sReq.ConsentDate = consentDate.Format("2006-01-02")
+		sModel.ConsentDate = *consentDate
+		insertionRecord.Record = sModel
+		if err = validat.Validate(sReq); err != nil {
+			insertionRecord.ErrorReason = err.Error()
+			insertionRecords = append(insertionRecords, insertionRecord)
+			return nil
+		}
+		*consents = append(*consents, sModel)
+		return nil
+	})
+	if !read {
 		return nil
 	}
-	log.Println("Report File Created", reportFileName)
-	//Upload report
-	uploadedReportFile, err := b.bulkFileJobsRepo.UploadFileToMinio(reportFileName, os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), "reports/", model.RequestId)
-	os.Remove(reportFileName)
+	return b.importRows(ctx, logger, model, []string{"Student Id", "Drive Id", "Vaccine Id", "Consent Status", "Guardian Name"}, func(ctx context.Context) []bulkRow {
+		result := b.consentUsecaseRepo.CreateConsents(ctx, consents)
+		logger.Debug("valid rows sent to the database", "rows", len(result))
+		result = append(result, insertionRecords...)
+		rows := make([]bulkRow, len(result))
+		for i, insertion := range result {
+			record := insertion.Record
+			rows[i] = bulkRow{accepted: insertion.Status, reason: insertion.ErrorReason, cells: func(bool) []interface{} {
+				return []interface{}{record.StudentId, record.DriveId, record.VaccineId, record.Status, record.GuardianName}
+			}}
+		}
+		return rows
+	})
+}
 
-	if err != nil {
-		model.ErrorMessage = "Report File Not Genrated"
-		model.Status = "PROCESSED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
+func (b *BulkFileJobUsecase) ProcessBulkGuardianRecord(ctx context.Context, model *models.BulkFileJobsModel) error {
+	logger := logging.ForJob(b.logger, model)
+	guardians := new([]models.Guardian)
+	insertionRecords := []models.GuardianInsertionRecord{}
+	validat := validator.NewValidator()
+	//template: Name, Relationship, Phone, Secondary Phone, Email, Preferred Language, Notification Opt In, Student Ids
+	read := b.readUpload(ctx, logger, model, 3, 8, func(line int, row []string) error {
+		optional := append(row, make([]string, 8-len(row))...)
+		sReq := requests.GuardianCreateRequest{
+			Name:              strings.TrimSpace(row[0]),
+			Relationship:      strings.ToUpper(strings.TrimSpace(row[1])),
+			PhonePrimary:      strings.TrimSpace(row[2]),
+			PhoneSecondary:    strings.TrimSpace(optional[3]),
+			Email:             strings.TrimSpace(optional[4]),
+			PreferredLanguage: strings.TrimSpace(optional[5]),
+		}
+		insertionRecord := models.GuardianInsertionRecord{Record: requests.GuardianFromRequest(&sReq), Status: false}
+		if optIn := strings.TrimSpace(optional[6]); optIn != "" {
+			value, err := strconv.ParseBool(optIn)
+			if err != nil {
+				insertionRecord.ErrorReason = fmt.Sprintf("invalid notification opt in at row %d, use TRUE or FALSE", line)
+				insertionRecords = append(insertionRecords, insertionRecord)
+				return nil
+			}
+			sReq.NotificationOptIn = &value
+		}
+		//student ids are comma separated in a single cell
+		invalid := false
+		for _, id := range strings.Split(optional[7], ",") {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			studentId, err := strconv.Atoi(strings.TrimSpace(id))
+			if err != nil {
+				invalid = true
+				break
+			}
+			sReq.StudentIds = append(sReq.StudentIds, studentId)
+		}
+		sModel := requests.GuardianFromRequest(&sReq)
+		insertionRecord.Record = sModel
+		if invalid {
+			insertionRecord.ErrorReason = fmt.Sprintf("invalid student id at row %d", line)
+			insertionRecords = append(insertionRecords, insertionRecord)
+			return nil
+		}
+		if err := validat.Validate(sReq); err != nil {
+			insertionRecord.ErrorReason = err.Error()
+			insertionRecords = append(insertionRecords, insertionRecord)
+			return nil
+		}
+		*guardians = append(*guardians, sModel)
+		return nil
+	})
+	if !read {
 		return nil
 	}
-	log.Println("Report File Uploaded", reportFileName)
-	model.FilePath = fmt.Sprintf("http://%s:%s/%s/%s", os.Getenv("MINIO_SERVER"), os.Getenv("MINIO_PORT"), os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), uploadedReportFile)
-	//change status
-	model.Status = "PROCESSED"
-	//update db
-	b.bulkFileJobsRepo.UpdateFileUpload(model)
-	log.Println("Processing Complete", model)
-	return nil
+	return b.importRows(ctx, logger, model, []string{"Guardian Id", "Name", "Relationship", "Phone", "Student Ids"}, func(ctx context.Context) []bulkRow {
+		result := b.guardianUsecaseRepo.CreateGuardians(ctx, guardians)
+		logger.Debug("valid rows sent to the database", "rows", len(result))
+		result = append(result, insertionRecords...)
+		rows := make([]bulkRow, len(result))
+		for i, insertion := range result {
+			record := insertion.Record
+			rows[i] = bulkRow{accepted: insertion.Status, reason: insertion.ErrorReason, cells: func(imported bool) []interface{} {
+				//a guardian that was not imported has no id to give
+				if !imported {
+					return []interface{}{nil, record.Name, record.Relationship, record.PhonePrimary, joinIds(record.StudentIds)}
+				}
+				return []interface{}{record.Id, record.Name, record.Relationship, record.PhonePrimary, joinIds(record.StudentIds)}
+			}}
+		}
+		return rows
+	})
 }
 
-func (b *BulkFileJobUsecase) ProcessBulkStudentRecord(model *models.BulkFileJobsModel) error {
-	//change status to processing
-	b.bulkFileJobsRepo.UpdateFileUpload(&models.BulkFileJobsModel{Id: model.Id, Status: "PROCESSING"})
+// readUpload marks the job PROCESSING, fetches its workbook and hands each row of the first sheet after the header
+// to parse with its line in the sheet. A file that is not a spreadsheet, a row whose column count is off the
+// template, or an error from parse fails the whole job. The local copy of the file is removed once read.
+func (b *BulkFileJobUsecase) readUpload(ctx context.Context, logger *slog.Logger, model *models.BulkFileJobsModel, minColumns, maxColumns int, parse func(line int, row []string) error) bool {
+	b.bulkFileJobsRepo.UpdateFileUpload(ctx, &models.BulkFileJobsModel{Id: model.Id, Status: "PROCESSING"})
 	//Get the file
-	fileLoc, err := b.bulkFileJobsRepo.GetFileFromActiveServer(os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), model.FilePath)
+	fileLoc, err := b.bulkFileJobsRepo.GetFileFromActiveServer(ctx, b.config.Minio.Bucket, model.FilePath)
 	if err != nil {
-		model.ErrorMessage = "Internal Server Error"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
+		b.failJob(ctx, model, "Internal Server Error")
+		return false
+	}
+	defer os.Remove(fileLoc)
+	rows, err := readWorkbook(fileLoc)
+	if errors.Is(err, errNotWorkbook) {
+		b.failJob(ctx, model, "Inavlid File, Only .xlsx or .xls allowed")
+		return false
 	}
-	//validate the file
+	if err != nil {
+		logger.Error("failed to read rows", logging.Err(err))
+		b.failJob(ctx, model, "Internal Server Error")
+		return false
+	}
+	//Header Adjusted
+	if len(rows) > 0 {
+		rows = rows[1:]
+	}
+	model.TotalRecords = len(rows)
+	for i, row := range rows {
+		line := i + 2
+		if len(row) < minColumns || len(row) > maxColumns {
+			logger.Warn("wrong number of columns", "row", line, "columns", len(row))
+			b.failJob(ctx, model, "Missing Columns")
+			return false
+		}
+		if err = parse(line, row); err != nil {
+			b.failJob(ctx, model, err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+// errNotWorkbook is a file whose signature is neither xlsx nor xls
+var errNotWorkbook = errors.New("not a workbook")
+
+func readWorkbook(fileLoc string) ([][]string, error) {
 	file, err := os.Open(fileLoc)
 	if err != nil {
-		model.ErrorMessage = "Inavlid File, Only .xlsx or .xls allowed"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
+		return nil, errNotWorkbook
 	}
 	defer file.Close()
 	header := make([]byte, 8)
-	_, err = file.Read(header)
-	if err != nil {
-		model.ErrorMessage = "Inavlid File, Only .xlsx or .xls allowed"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
+	if _, err = file.Read(header); err != nil {
+		return nil, errNotWorkbook
 	}
 	if !(bytes.HasPrefix(header, []byte{0x50, 0x4B, 0x03, 0x04}) || bytes.Equal(header, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})) {
-		model.ErrorMessage = "Inavlid File, Only .xlsx or .xls allowed"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
+		return nil, errNotWorkbook
 	}
 	f, err := excelize.OpenFile(fileLoc)
 	if err != nil {
-		log.Printf("failed to open Excel file: %v", err)
-		model.ErrorMessage = "Internal Server Error"
-		model.Status = "FAILED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
+		return nil, err
 	}
 	defer f.Close()
-	sheets := f.GetSheetList()
-	log.Printf("Sheets: %v\n", sheets)
-	sheetName := sheets[0]
+	return f.GetRows(f.GetSheetList()[0])
+}
 
-	rows, err := f.GetRows(sheetName)
-	//Header Adjusted
-	model.TotalRecords = len(rows) - 1
-	if err != nil {
-		log.Fatalf("failed to read rows: %v", err)
-	}
-	studentSet := new([]models.StudentManagement)
-	insertionRecords := []models.DBInsertionRecord{}
-	validat := validator.NewValidator()
-	for i, row := range rows {
-		if i == 0 {
-			continue
-		}
-		if len(row) != 5 {
-			log.Printf("failed to open Excel file: %v", err)
-			model.ErrorMessage = "Missing Columns"
-			model.Status = "FAILED"
-			b.bulkFileJobsRepo.UpdateFileUpload(model)
-			return nil
-		}
-		sReq := requests.StudentManagementCreateRequest{
-			Name:    row[0],
-			Class:   row[1],
-			Gender:  row[2],
-			RollNo:  row[3],
-			PhoneNo: row[4],
+// failJob marks a job FAILED before any of its rows were imported
+func (b *BulkFileJobUsecase) failJob(ctx context.Context, model *models.BulkFileJobsModel, message string) {
+	model.ErrorMessage = message
+	model.Status = "FAILED"
+	b.bulkFileJobsRepo.UpdateFileUpload(ctx, model)
+}
+
+// bulkRow is an uploaded row as its report shows it
+type bulkRow struct {
+	accepted bool
+	reason   string
+	//the report columns ahead of Status and Remarks, imported tells whether the row is in the database
+	cells func(imported bool) []interface{}
+}
+
+// errRowsRejected rolls back an all or nothing upload that had a rejected row
+var errRowsRejected = errors.New("all or nothing upload")
+
+// importRows runs insert and marks the job in one transaction, so the job and its rows are committed together or
+// not at all. The report is written and uploaded after the commit, the dose stock locks taken by the insert are not
+// held while it is. An all or nothing upload with a rejected row is rolled back and still gets its report.
+func (b *BulkFileJobUsecase) importRows(ctx context.Context, logger *slog.Logger, model *models.BulkFileJobsModel, headers []string, insert func(ctx context.Context) []bulkRow) error {
+	var rows []bulkRow
+	err := b.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		rows = insert(ctx)
+		rejected := 0
+		for _, row := range rows {
+			if !row.accepted {
+				rejected++
+			}
 		}
-		sModel := models.StudentManagement{
-			Name:       row[0],
-			Class:      row[1],
-			Gender:     row[2],
-			RollNumber: row[3],
-			PhoneNo:    row[4],
+		if model.AllOrNothing && rejected > 0 {
+			return fmt.Errorf("%w, %d of %d rows rejected so none were imported", errRowsRejected, rejected, model.TotalRecords)
 		}
-		err := validat.Validate(sReq)
-		if err != nil {
-			insertionRecord := models.DBInsertionRecord{
-				Record:      sModel,
-				Status:      false,
-				ErrorReason: err.Error(),
-			}
-			insertionRecords = append(insertionRecords, insertionRecord)
-			continue
+		model.ProcessedRecords = len(rows) - rejected
+		model.Status = "PROCESSED"
+		return b.bulkFileJobsRepo.UpdateFileUpload(ctx, model)
+	})
+	if err != nil {
+		b.failImport(ctx, logger, model, err)
+		if !errors.Is(err, errRowsRejected) {
+			return nil
 		}
-		*studentSet = append(*studentSet, sModel)
 	}
-	result := b.studentManagementusecaseRepo.CreateStudentRecords(studentSet)
-
-	log.Println("Request Processing Complete", result)
-	result = append(result, insertionRecords...)
+	b.writeReport(ctx, logger, model, headers, rows, err != nil)
+	logger.Info("bulk job processed", "status", model.Status, "total_records", model.TotalRecords, "processed_records", model.ProcessedRecords)
+	return nil
+}
 
-	//Create report
+// writeReport uploads the report of an import that already committed or rolled back and points the job at it,
+// a report that cannot be written leaves the job's status as it is
+func (b *BulkFileJobUsecase) writeReport(ctx context.Context, logger *slog.Logger, model *models.BulkFileJobsModel, headers []string, rows []bulkRow, rolledBack bool) {
 	reportFile := excelize.NewFile()
 	reportShheetName := "Report"
 	index, _ := reportFile.NewSheet(reportShheetName)
-	reportHeaders := []string{"Name", "Class", "Gender", "Roll Number", "Phone Number", "Status", "Remarks"}
-	for col, header := range reportHeaders {
+	for col, header := range append(append([]string{}, headers...), "Status", "Remarks") {
 		cell, _ := excelize.CoordinatesToCellName(col+1, 1) // (col+1, row=1)
 		reportFile.SetCellValue(reportShheetName, cell, header)
 	}
-	//Keeping track Of Processed File
-	totalProcessed := 0
-	for i, insertion := range result {
-		rowNum := i + 2
-		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("A%d", rowNum), insertion.Record.Name)
-		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("B%d", rowNum), insertion.Record.Class)
-		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("C%d", rowNum), insertion.Record.Gender)
-		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("D%d", rowNum), insertion.Record.RollNumber)
-		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("E%d", rowNum), insertion.Record.PhoneNo)
-		if !insertion.Status {
-			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("F%d", rowNum), "Rejected")
-			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("G%d", rowNum), insertion.ErrorReason)
-		} else {
-			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("F%d", rowNum), "Accepted")
-			totalProcessed++
-		}
-	}
-	model.ProcessedRecords = totalProcessed
+	for i, row := range rows {
+		status, remark := "Rejected", row.reason
+		if row.accepted {
+			status, remark = acceptedStatus(rolledBack)
+		}
+		for col, value := range append(row.cells(row.accepted && !rolledBack), status, remark) {
+			if value == nil {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(col+1, i+2)
+			reportFile.SetCellValue(reportShheetName, cell, value)
+		}
+	}
 	reportFile.SetActiveSheet(index)
-	reportFileName := "Report.xlsx"
-	err = reportFile.SaveAs(reportFileName)
+	update := &models.BulkFileJobsModel{Id: model.Id}
+	reportFileName, err := saveReportFile(reportFile, "Report.xlsx")
+	if err == nil {
+		defer os.RemoveAll(filepath.Dir(reportFileName))
+	}
 	if err != nil {
-		log.Println("error creating report file", err.Error(), reportFileName)
-		model.ErrorMessage = "Report File Not Genrated"
-		model.Status = "PROCESSED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
+		logger.Error("error creating report file", logging.Err(err))
+		update.ErrorMessage = "Report File Not Genrated"
+	} else if uploadedReportFile, err := b.bulkFileJobsRepo.UploadFileToMinio(ctx, reportFileName, b.config.Minio.Bucket, "reports/", model.RequestId); err != nil {
+		logger.Error("error uploading report file", "file", reportFileName, logging.Err(err))
+		update.ErrorMessage = "Report File Not Genrated"
+	} else {
+		logger.Debug("report file uploaded", "object", uploadedReportFile)
+		update.FilePath = b.config.Minio.ObjectURL(uploadedReportFile)
+	}
+	//a failed job keeps the reason it failed for
+	if model.Status == "FAILED" && update.ErrorMessage != "" {
+		return
+	}
+	if err := b.bulkFileJobsRepo.UpdateFileUpload(ctx, update); err != nil {
+		logger.Error("unable to record the report of bulk job", logging.Err(err))
+		return
+	}
+	model.FilePath = update.FilePath
+	if update.ErrorMessage != "" {
+		model.ErrorMessage = update.ErrorMessage
+	}
+}
+
+// failImport marks a job whose import was rolled back, none of its rows are in the database
+func (b *BulkFileJobUsecase) failImport(ctx context.Context, logger *slog.Logger, model *models.BulkFileJobsModel, err error) {
+	model.Status = "FAILED"
+	model.ProcessedRecords = 0
+	model.ErrorMessage = err.Error()
+	if !errors.Is(err, errRowsRejected) {
+		logger.Error("bulk import rolled back", logging.Err(err))
+		model.ErrorMessage = "Internal Server Error"
+	}
+	if err = b.bulkFileJobsRepo.UpdateFileUpload(ctx, model); err != nil {
+		logger.Error("unable to mark bulk job failed", logging.Err(err))
+	}
+}
+
+// the status and remark the report gives a row the database accepted
+func acceptedStatus(rolledBack bool) (string, string) {
+	if rolledBack {
+		return "Not Imported", "another row was rejected and the upload is all or nothing"
 	}
-	log.Println("Report File Created", reportFileName)
-	//Upload report
-	uploadedReportFile, err := b.bulkFileJobsRepo.UploadFileToMinio(reportFileName, os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), "reports/", model.RequestId)
+	return "Accepted", ""
+}
 
+// saveReportFile saves the workbook as name in a directory of its own, jobs and requests run concurrently and
+// would otherwise overwrite each other's report. The caller removes the directory once the report is uploaded.
+func saveReportFile(reportFile *excelize.File, name string) (string, error) {
+	reportDir, err := os.MkdirTemp("", "report-*")
 	if err != nil {
-		model.ErrorMessage = "Report File Not Genrated"
-		model.Status = "PROCESSED"
-		b.bulkFileJobsRepo.UpdateFileUpload(model)
-		return nil
+		return "", err
 	}
-	log.Println("Report File Uploaded", reportFileName)
-	model.FilePath = fmt.Sprintf("http://%s:%s/%s/%s", os.Getenv("MINIO_SERVER"), os.Getenv("MINIO_PORT"), os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), uploadedReportFile)
-	//change status
-	model.Status = "PROCESSED"
-	//update db
-	b.bulkFileJobsRepo.UpdateFileUpload(model)
-	log.Println("Processing Complete", model)
-	return nil
+	reportFileName := filepath.Join(reportDir, name)
+	if err = reportFile.SaveAs(reportFileName); err != nil {
+		os.RemoveAll(reportDir)
+		return "", err
+	}
+	return reportFileName, nil
+}
+
+func joinIds(ids []int) string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return strings.Join(values, ",")
+}
+
+// dates in upload templates are typed as YYYY-MM-DD, with HH:MM or HH:MM:SS for a time. A cell the spreadsheet
+// stored as a date is read back in its built-in format, month first: MM-DD-YY for a date, M/D/YY HH:MM with a time.
+// No other day and month orders are accepted, 02/01 would otherwise be read differently per layout.
+func parseTemplateDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05", "01-02-06", "1/2/06 15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported date %s", value)
 }
 
-func NewBulkFileJobUsecaseHandler(studentUcRepo StudentManagementUsecaseHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler) BulkFileJobUsecaseHandler {
+func NewBulkFileJobUsecaseHandler(studentUcRepo StudentManagementUsecaseHandler, consentUcRepo ConsentUsecaseHandler, guardianUcRepo GuardianUsecaseHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, unitOfWork repository.UnitOfWorkHandler, cfg *config.Config, logger *slog.Logger) BulkFileJobUsecaseHandler {
 	return &BulkFileJobUsecase{
 		studentManagementusecaseRepo: studentUcRepo,
+		consentUsecaseRepo:           consentUcRepo,
+		guardianUsecaseRepo:          guardianUcRepo,
 		bulkFileJobsRepo:             bulkfileJobsRepo,
+		unitOfWork:                   unitOfWork,
+		config:                       cfg,
+		logger:                       logger,
 	}
 }
@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"school_vaccination_portal/certificate"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CertificateUsecaseHandler interface {
+	GenerateCertificate(ctx context.Context, request *requests.CertificateRequest) (models.Certificate, []byte, error)
+	GenerateClassCertificates(ctx context.Context, request *requests.ClassCertificateRequest) (string, error)
+	VerifyCertificate(ctx context.Context, request *requests.VerifyCertificateRequest) (models.CertificateVerification, error)
+	RevokeCertificate(ctx context.Context, request *requests.RevokeCertificateRequest) (models.Certificate, error)
+	GetRevokedCertificates(ctx context.Context) ([]models.Certificate, error)
+}
+
+type CertificateUsecase struct {
+	repo                  repository.CertificateRepositoryHandler
+	studentManagementRepo repository.StudentManagementRepositoryHandler
+	bulkFileJobsRepo      repository.BulkFileJobsRepositoryHandler
+	unitOfWork            repository.UnitOfWorkHandler
+	config                *config.Config
+	logger                *slog.Logger
+}
+
+// issues a new certificate for the student, a copy of the pdf is kept in the bucket under certificates/
+func (u *CertificateUsecase) GenerateCertificate(ctx context.Context, request *requests.CertificateRequest) (models.Certificate, []byte, error) {
+	students, err := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id = %d", request.Id))
+	if err != nil {
+		u.logger.Error("error fetching student", logging.Err(err))
+		return models.Certificate{}, nil, errors.New("unable to generate certificate please try again later")
+	}
+	if len(students) != 1 {
+		return models.Certificate{}, nil, fmt.Errorf("no student exists with student_id : %d", request.Id)
+	}
+	doses, err := u.repo.GetCertificateDoses(ctx, []int{request.Id})
+	if err != nil {
+		u.logger.Error("error fetching doses for certificate", logging.Err(err))
+		return models.Certificate{}, nil, errors.New("unable to generate certificate please try again later")
+	}
+	issued, content, err := u.issueCertificate(ctx, students[0], doses)
+	if err != nil {
+		return issued, nil, err
+	}
+	localFile := filepath.Join(os.TempDir(), issued.CertificateNumber+".pdf")
+	defer os.Remove(localFile)
+	if err = os.WriteFile(localFile, content, 0644); err != nil {
+		u.logger.Error("unable to save certificate locally", logging.Err(err))
+		return issued, content, nil
+	}
+	uploaded, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, localFile, u.config.Minio.Bucket, "certificates/", strconv.Itoa(issued.StudentId))
+	if err != nil {
+		u.logger.Error("error in uploading certificate to minio", logging.Err(err))
+		return issued, content, nil
+	}
+	if err = u.repo.UpdateCertificateFile(ctx, issued.Id, uploaded); err != nil {
+		u.logger.Error("error saving certificate file path", logging.Err(err))
+	}
+	issued.FilePath = uploaded
+	return issued, content, nil
+}
+
+// issues a certificate for every student in the class and uploads them together as one zip
+func (u *CertificateUsecase) GenerateClassCertificates(ctx context.Context, request *requests.ClassCertificateRequest) (string, error) {
+	students, err := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("class = '%s'", strings.ReplaceAll(request.Class, "'", "''")))
+	if err != nil {
+		u.logger.Error("error fetching students for class", logging.Err(err))
+		return "", errors.New("unable to generate certificates please try again later")
+	}
+	if len(students) == 0 {
+		return "", fmt.Errorf("no students found in class : %s", request.Class)
+	}
+	studentIds := []int{}
+	for _, student := range students {
+		studentIds = append(studentIds, student.Id)
+	}
+	doses, err := u.repo.GetCertificateDoses(ctx, studentIds)
+	if err != nil {
+		u.logger.Error("error fetching doses for certificates", logging.Err(err))
+		return "", errors.New("unable to generate certificates please try again later")
+	}
+	dosesByStudent := map[int][]models.CertificateDose{}
+	for _, dose := range doses {
+		dosesByStudent[dose.StudentId] = append(dosesByStudent[dose.StudentId], dose)
+	}
+	//a directory of its own so concurrent requests for the class do not share the archive
+	exportDir, err := os.MkdirTemp("", "certificates-*")
+	if err != nil {
+		u.logger.Error("unable to create certificate archive", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	defer os.RemoveAll(exportDir)
+	zipFileName := filepath.Join(exportDir, fmt.Sprintf("Certificates-%s.zip", strings.NewReplacer("/", "-", "\\", "-", " ", "-").Replace(request.Class)))
+	zipFile, err := os.Create(zipFileName)
+	if err != nil {
+		u.logger.Error("unable to create certificate archive", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	defer zipFile.Close()
+	archive := zip.NewWriter(zipFile)
+	certificates := []models.Certificate{}
+	for _, student := range students {
+		issued, content, err := u.renderCertificate(student, dosesByStudent[student.Id])
+		if err != nil {
+			return "", err
+		}
+		certificates = append(certificates, issued)
+		entry, err := archive.Create(fmt.Sprintf("%s-%s.pdf", student.RollNumber, issued.CertificateNumber))
+		if err == nil {
+			_, err = entry.Write(content)
+		}
+		if err != nil {
+			u.logger.Error("unable to add certificate to archive", logging.Err(err))
+			return "", errors.New("Internal server Error")
+		}
+	}
+	if err = archive.Close(); err != nil {
+		u.logger.Error("unable to write certificate archive", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	if err = zipFile.Close(); err != nil {
+		u.logger.Error("unable to write certificate archive", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	uploaded, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, zipFileName, u.config.Minio.Bucket, "certificates/", request.RequestId)
+	if err != nil {
+		u.logger.Error("error in uploading file to minio", logging.Err(err))
+		return "", err
+	}
+	//the certificates are recorded together once their archive is stored, a failure leaves none of them issued
+	//and removes the archive, its signed QR codes would otherwise verify for numbers that are not on record
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		for i := range certificates {
+			certificates[i].FilePath = uploaded
+			if err := u.repo.CreateCertificate(ctx, &certificates[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		u.logger.Error("error creating class certificates", "object", uploaded, logging.Err(err))
+		if removeErr := u.bulkFileJobsRepo.RemoveFileFromMinio(ctx, u.config.Minio.Bucket, uploaded); removeErr != nil {
+			u.logger.Error("unable to remove unrecorded certificates", "object", uploaded, logging.Err(removeErr))
+		}
+		return "", errors.New("unable to generate certificates please try again later")
+	}
+	return u.config.Minio.ObjectURL(uploaded), nil
+}
+
+// checks the signature offline first, then looks the certificate up to report whether it was revoked. A signed
+// certificate whose number was never recorded is not valid, it was not issued.
+func (u *CertificateUsecase) VerifyCertificate(ctx context.Context, request *requests.VerifyCertificateRequest) (models.CertificateVerification, error) {
+	key, err := certificate.VerifyKey(u.config.Certificate)
+	if err != nil {
+		u.logger.Error("unable to load certificate verify key", logging.Err(err))
+		return models.CertificateVerification{}, errors.New("certificate verification is not available")
+	}
+	payload, err := certificate.Verify(certificate.TokenFromQR(request.Token), key)
+	if errors.Is(err, certificate.ErrInvalidSignature) {
+		return models.CertificateVerification{Valid: false, Reason: err.Error()}, nil
+	}
+	if err != nil {
+		return models.CertificateVerification{}, err
+	}
+	result := models.CertificateVerification{Valid: true, Payload: payload}
+	certificates, err := u.repo.GetCertificates(ctx, fmt.Sprintf("certificate_number = '%s'", strings.ReplaceAll(payload.CertificateNumber, "'", "''")))
+	if err != nil {
+		u.logger.Error("error fetching certificate", logging.Err(err))
+		return result, errors.New("unable to check revocation please try again later")
+	}
+	if len(certificates) == 0 {
+		result.Valid = false
+		result.Reason = "certificate is not on record"
+		return result, nil
+	}
+	result.Certificate = &certificates[0]
+	if certificates[0].Status == models.CERTIFICATE_REVOKED {
+		result.Reason = "certificate has been revoked"
+	}
+	return result, nil
+}
+
+func (u *CertificateUsecase) RevokeCertificate(ctx context.Context, request *requests.RevokeCertificateRequest) (models.Certificate, error) {
+	certificates, err := u.repo.GetCertificates(ctx, fmt.Sprintf("id = %d", request.Id))
+	if err != nil {
+		u.logger.Error("error fetching certificate", logging.Err(err))
+		return models.Certificate{}, errors.New("unable to revoke certificate please try again later")
+	}
+	if len(certificates) == 0 {
+		return models.Certificate{}, fmt.Errorf("no certificate exists with id %d", request.Id)
+	}
+	if certificates[0].Status == models.CERTIFICATE_REVOKED {
+		return certificates[0], fmt.Errorf("certificate %s is already revoked", certificates[0].CertificateNumber)
+	}
+	if err = u.repo.RevokeCertificate(ctx, request.Id, request.Reason); err != nil {
+		u.logger.Error("error revoking certificate", logging.Err(err))
+		return certificates[0], errors.New("unable to revoke certificate please try again later")
+	}
+	certificates, err = u.repo.GetCertificates(ctx, fmt.Sprintf("id = %d", request.Id))
+	if err != nil || len(certificates) == 0 {
+		return models.Certificate{}, errors.New("certificate revoked but could not be fetched")
+	}
+	return certificates[0], nil
+}
+
+// the revocation list is what offline verifiers download to check certificates without calling us
+func (u *CertificateUsecase) GetRevokedCertificates(ctx context.Context) ([]models.Certificate, error) {
+	return u.repo.GetCertificates(ctx, fmt.Sprintf("status = '%s'", models.CERTIFICATE_REVOKED))
+}
+
+// stores a new certificate number for the student and renders the pdf with its signed QR code
+func (u *CertificateUsecase) issueCertificate(ctx context.Context, student models.StudentManagement, doses []models.CertificateDose) (models.Certificate, []byte, error) {
+	issued, content, err := u.renderCertificate(student, doses)
+	if err != nil {
+		return issued, nil, err
+	}
+	if err = u.repo.CreateCertificate(ctx, &issued); err != nil {
+		u.logger.Error("error creating certificate", logging.Err(err))
+		return models.Certificate{}, nil, errors.New("unable to generate certificate please try again later")
+	}
+	return issued, content, nil
+}
+
+// numbers, signs and renders a certificate without recording it
+func (u *CertificateUsecase) renderCertificate(student models.StudentManagement, doses []models.CertificateDose) (models.Certificate, []byte, error) {
+	key, err := certificate.SigningKey(u.config.Certificate)
+	if err != nil {
+		u.logger.Error("unable to load certificate signing key", logging.Err(err))
+		return models.Certificate{}, nil, errors.New("certificate signing is not configured")
+	}
+	number, err := newCertificateNumber()
+	if err != nil {
+		u.logger.Error("unable to generate certificate number", logging.Err(err))
+		return models.Certificate{}, nil, errors.New("unable to generate certificate please try again later")
+	}
+	issued := models.Certificate{
+		CertificateNumber: number,
+		StudentId:         student.Id,
+		IssuedAt:          time.Now(),
+		Status:            models.CERTIFICATE_ISSUED,
+	}
+	payload := models.CertificatePayload{
+		CertificateNumber: number,
+		StudentName:       student.Name,
+		IssuedAt:          issued.IssuedAt.Format("2006-01-02"),
+		Doses:             []models.CertificatePayloadDose{},
+	}
+	for _, dose := range doses {
+		line := models.CertificatePayloadDose{Vaccine: dose.VaccineName, Dose: dose.DoseNumber}
+		if dose.AdministeredAt != nil {
+			line.Date = dose.AdministeredAt.Format("2006-01-02")
+		}
+		payload.Doses = append(payload.Doses, line)
+	}
+	token, err := certificate.Sign(payload, key)
+	if err != nil {
+		u.logger.Error("unable to sign certificate", logging.Err(err))
+		return models.Certificate{}, nil, errors.New("unable to generate certificate please try again later")
+	}
+	content, err := certificate.Render(certificate.Data{SchoolName: u.config.School.Name, SchoolAddress: u.config.School.Address, Number: number, IssuedAt: issued.IssuedAt, Student: student, Doses: doses, QRCode: certificate.VerificationLink(u.config.Certificate.VerifyURL, token)})
+	if err != nil {
+		u.logger.Error("unable to render certificate", logging.Err(err))
+		return models.Certificate{}, nil, errors.New("unable to generate certificate please try again later")
+	}
+	return issued, content, nil
+}
+
+// certificate numbers read SVP-<year>-<16 hex chars>, random so they cannot be guessed from one another
+func newCertificateNumber() (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SVP-%d-%s", time.Now().Year(), strings.ToUpper(hex.EncodeToString(suffix))), nil
+}
+
+func NewCertificateUsecaseHandler(repo repository.CertificateRepositoryHandler, studentRepo repository.StudentManagementRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, unitOfWork repository.UnitOfWorkHandler, cfg *config.Config, logger *slog.Logger) CertificateUsecaseHandler {
+	return &CertificateUsecase{
+		repo:                  repo,
+		studentManagementRepo: studentRepo,
+		bulkFileJobsRepo:      bulkfileJobsRepo,
+		unitOfWork:            unitOfWork,
+		config:                cfg,
+		logger:                logger,
+	}
+}
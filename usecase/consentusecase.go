@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+type ConsentUsecaseHandler interface {
+	CreateConsents(ctx context.Context, consents *[]models.Consent) []models.ConsentInsertionRecord
+	UpdateConsent(ctx context.Context, request *requests.ConsentUpdateRequest) (models.Consent, error)
+	GetConsents(ctx context.Context, request *requests.GetConsentRequest) (int, []models.Consent, error)
+	GeneratePendingConsentReport(ctx context.Context, request *requests.PendingConsentReportRequest) (string, error)
+}
+
+type ConsentUsecase struct {
+	repo                  repository.ConsentRepositoryHandler
+	guardianRepo          repository.GuardianRepositoryHandler
+	studentManagementRepo repository.StudentManagementRepositoryHandler
+	vaccineInventoryRepo  repository.VaccineInventoryHandler
+	vaccineCatalogRepo    repository.VaccineCatalogRepositoryHandler
+	bulkFileJobsRepo      repository.BulkFileJobsRepositoryHandler
+	config                *config.Config
+	logger                *slog.Logger
+}
+
+func (u *ConsentUsecase) CreateConsents(ctx context.Context, consents *[]models.Consent) []models.ConsentInsertionRecord {
+	result := []models.ConsentInsertionRecord{}
+	for _, j := range *consents {
+		if err := u.validateConsent(ctx, &j); err != nil {
+			//the uploaded form is only kept for a consent that is recorded
+			os.Remove(j.FormPath)
+			result = append(result, models.ConsentInsertionRecord{Record: j, Status: false, ErrorReason: err.Error()})
+			continue
+		}
+		if err := u.uploadConsentForm(ctx, &j); err != nil {
+			u.logger.Error("error uploading consent form", logging.Err(err))
+			result = append(result, models.ConsentInsertionRecord{Record: j, Status: false, ErrorReason: "unable to upload consent form"})
+			continue
+		}
+		if err := u.repo.CreateConsent(ctx, &j); err != nil {
+			u.logger.Error("error creating consent", logging.Err(err))
+			result = append(result, models.ConsentInsertionRecord{Record: j, Status: false, ErrorReason: "unable to save consent please try again later"})
+			continue
+		}
+		result = append(result, models.ConsentInsertionRecord{Record: j, Status: true})
+	}
+	return result
+}
+
+// the student must exist and the consent must point at a known drive or vaccine, a drive consent also records the drive's vaccine
+func (u *ConsentUsecase) validateConsent(ctx context.Context, consent *models.Consent) error {
+	students, _ := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id = %d", consent.StudentId))
+	if len(students) != 1 {
+		return fmt.Errorf("no student exists with student_id : %d", consent.StudentId)
+	}
+	if err := u.applyGuardian(ctx, consent); err != nil {
+		return err
+	}
+	if consent.DriveId != 0 {
+		drives, err := u.vaccineInventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("id = %d", consent.DriveId))
+		if err != nil || len(drives) == 0 {
+			return fmt.Errorf("no drive exists with drive_id : %d", consent.DriveId)
+		}
+		if consent.VaccineId != 0 && consent.VaccineId != drives[0].VaccineId {
+			return fmt.Errorf("drive_id : %d is for vaccine_id : %d, not vaccine_id : %d", consent.DriveId, drives[0].VaccineId, consent.VaccineId)
+		}
+		consent.VaccineId = drives[0].VaccineId
+		return nil
+	}
+	vaccines, err := u.vaccineCatalogRepo.GetVaccines(ctx, fmt.Sprintf("id = %d", consent.VaccineId), requests.Pagination{})
+	if err != nil || len(vaccines) == 0 {
+		return fmt.Errorf("no vaccine exists with vaccine_id : %d", consent.VaccineId)
+	}
+	return nil
+}
+
+// a consent given by a registered guardian must come from one linked to the student, and carries their name
+func (u *ConsentUsecase) applyGuardian(ctx context.Context, consent *models.Consent) error {
+	if consent.GuardianId == 0 {
+		return nil
+	}
+	guardians, err := u.guardianRepo.GetGuardians(ctx, fmt.Sprintf("g.id = %d AND g.id IN (SELECT guardian_id FROM student_guardians WHERE student_id = %d)", consent.GuardianId, consent.StudentId), requests.Pagination{})
+	if err != nil {
+		u.logger.Error("error fetching guardian", logging.Err(err))
+		return errors.New("unable to verify guardian please try again later")
+	}
+	if len(guardians) == 0 {
+		return fmt.Errorf("guardian_id : %d is not a guardian of student_id : %d", consent.GuardianId, consent.StudentId)
+	}
+	if consent.GuardianName == "" {
+		consent.GuardianName = guardians[0].Name
+	}
+	return nil
+}
+
+// moves a scanned form from its temp location into the bucket, keeping the object key on the consent
+func (u *ConsentUsecase) uploadConsentForm(ctx context.Context, consent *models.Consent) error {
+	if consent.FormPath == "" {
+		return nil
+	}
+	uploaded, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, consent.FormPath, u.config.Minio.Bucket, "consents/", uuid.NewString())
+	if err != nil {
+		consent.FormPath = ""
+		return err
+	}
+	consent.FormPath = uploaded
+	return nil
+}
+
+func (u *ConsentUsecase) UpdateConsent(ctx context.Context, request *requests.ConsentUpdateRequest) (models.Consent, error) {
+	consents, err := u.repo.GetConsents(ctx, fmt.Sprintf("id = %d", request.Id), requests.Pagination{})
+	if err != nil {
+		u.logger.Error("error fetching consent", logging.Err(err))
+		os.Remove(request.FormPath)
+		return models.Consent{}, errors.New("unable to update consent please try again later")
+	}
+	if len(consents) == 0 {
+		os.Remove(request.FormPath)
+		return models.Consent{}, fmt.Errorf("no consent exists with id %d", request.Id)
+	}
+	form := models.Consent{FormPath: request.FormPath}
+	if err = u.uploadConsentForm(ctx, &form); err != nil {
+		u.logger.Error("error uploading consent form", logging.Err(err))
+		return consents[0], errors.New("unable to upload consent form")
+	}
+	request.FormPath = form.FormPath
+	if err = u.repo.UpdateConsent(ctx, request); err != nil {
+		u.logger.Error("error updating consent", logging.Err(err))
+		return consents[0], err
+	}
+	consents, err = u.repo.GetConsents(ctx, fmt.Sprintf("id = %d", request.Id), requests.Pagination{})
+	if err != nil || len(consents) == 0 {
+		return models.Consent{}, errors.New("consent updated but could not be fetched")
+	}
+	return consents[0], nil
+}
+
+func (u *ConsentUsecase) GetConsents(ctx context.Context, request *requests.GetConsentRequest) (int, []models.Consent, error) {
+	conditions := []string{}
+	if request.Id != 0 {
+		conditions = append(conditions, fmt.Sprintf("id = %d", request.Id))
+	}
+	if request.StudentId != 0 {
+		conditions = append(conditions, fmt.Sprintf("student_id = %d", request.StudentId))
+	}
+	if request.DriveId != 0 {
+		conditions = append(conditions, fmt.Sprintf("drive_id = %d", request.DriveId))
+	}
+	if request.VaccineId != 0 {
+		conditions = append(conditions, fmt.Sprintf("vaccine_id = %d", request.VaccineId))
+	}
+	if request.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = '%s'", request.Status))
+	}
+	filter := strings.Join(conditions, " AND ")
+	total, err := u.repo.GetConsentCount(ctx, filter)
+	if err != nil {
+		u.logger.Error("error fetching consent count", logging.Err(err))
+		return total, nil, err
+	}
+	consents, err := u.repo.GetConsents(ctx, filter, request.Pagination)
+	return total, consents, err
+}
+
+// lists every student the drive covers whose guardian has not granted or refused consent yet
+func (u *ConsentUsecase) GeneratePendingConsentReport(ctx context.Context, request *requests.PendingConsentReportRequest) (string, error) {
+	drives, err := u.vaccineInventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("id = %d", request.DriveId))
+	if err != nil || len(drives) == 0 {
+		return "", fmt.Errorf("no drive exists with drive_id : %d", request.DriveId)
+	}
+	drive := drives[0]
+	classes := []string{}
+	for _, class := range drive.Classes {
+		classes = append(classes, fmt.Sprintf("'%s'", strings.ReplaceAll(class, "'", "''")))
+	}
+	students, err := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("class IN (%s)", strings.Join(classes, ", ")))
+	if err != nil {
+		u.logger.Error("error fetching students for drive", logging.Err(err))
+		return "", err
+	}
+	consents, err := u.repo.GetConsents(ctx, fmt.Sprintf("drive_id = %d OR (drive_id = 0 AND vaccine_id = %d)", drive.Id, drive.VaccineId), requests.Pagination{})
+	if err != nil {
+		u.logger.Error("error fetching consents for drive", logging.Err(err))
+		return "", err
+	}
+	effective := effectiveConsents(consents)
+	//Create report
+	reportFile := excelize.NewFile()
+	reportSheetName := "Pending Consent"
+	reportFile.SetSheetName("Sheet1", reportSheetName)
+	reportHeaders := []string{"Name", "Class", "Roll Number", "Phone Number", "Consent Status", "Guardian Name"}
+	for col, header := range reportHeaders {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1) // (col+1, row=1)
+		reportFile.SetCellValue(reportSheetName, cell, header)
+	}
+	rowNum := 2
+	for _, student := range students {
+		consent, ok := effective[student.Id]
+		if !ok {
+			consent = models.Consent{Status: models.CONSENT_NO_RESPONSE}
+		}
+		if consent.Status != models.CONSENT_PENDING && consent.Status != models.CONSENT_NO_RESPONSE {
+			continue
+		}
+		values := []interface{}{student.Name, student.Class, student.RollNumber, student.PhoneNo, consent.Status, consent.GuardianName}
+		for col, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, rowNum)
+			reportFile.SetCellValue(reportSheetName, cell, value)
+		}
+		rowNum++
+	}
+	reportFileName, err := saveReportFile(reportFile, "PendingConsentReport.xlsx")
+	if err != nil {
+		u.logger.Error("unable to save report File Locally", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	defer os.RemoveAll(filepath.Dir(reportFileName))
+	uploadedReportFile, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, reportFileName, u.config.Minio.Bucket, "reports/", request.RequestId)
+	if err != nil {
+		u.logger.Error("error in uploading file to minio", logging.Err(err))
+		return "", err
+	}
+	return u.config.Minio.ObjectURL(uploadedReportFile), nil
+}
+
+// picks the consent in force per student the same way GetEffectiveConsent does, drive specific first, then the latest
+func effectiveConsents(consents []models.Consent) map[int]models.Consent {
+	effective := map[int]models.Consent{}
+	for _, consent := range consents {
+		current, ok := effective[consent.StudentId]
+		if !ok || consentOverrides(consent, current) {
+			effective[consent.StudentId] = consent
+		}
+	}
+	return effective
+}
+
+func consentOverrides(candidate, current models.Consent) bool {
+	if (candidate.DriveId != 0) != (current.DriveId != 0) {
+		return candidate.DriveId != 0
+	}
+	if !candidate.ConsentDate.Equal(current.ConsentDate) {
+		return candidate.ConsentDate.After(current.ConsentDate)
+	}
+	return candidate.Id > current.Id
+}
+
+func NewConsentUsecaseHandler(repo repository.ConsentRepositoryHandler, guardianRepo repository.GuardianRepositoryHandler, studentRepo repository.StudentManagementRepositoryHandler, vaccineinventoryRepo repository.VaccineInventoryHandler, vaccineCatalogRepo repository.VaccineCatalogRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, cfg *config.Config, logger *slog.Logger) ConsentUsecaseHandler {
+	return &ConsentUsecase{
+		repo:                  repo,
+		guardianRepo:          guardianRepo,
+		studentManagementRepo: studentRepo,
+		vaccineInventoryRepo:  vaccineinventoryRepo,
+		vaccineCatalogRepo:    vaccineCatalogRepo,
+		bulkFileJobsRepo:      bulkfileJobsRepo,
+		config:                cfg,
+		logger:                logger,
+	}
+}
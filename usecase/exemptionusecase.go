@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ExemptionUsecaseHandler interface {
+	CreateExemption(ctx context.Context, exemption *models.Exemption) error
+	GetExemptions(ctx context.Context, request *requests.GetExemptionRequest) (int, []models.Exemption, error)
+	DeleteExemption(ctx context.Context, id int) error
+}
+
+type ExemptionUsecase struct {
+	repo                  repository.ExemptionRepositoryHandler
+	studentManagementRepo repository.StudentManagementRepositoryHandler
+	vaccineCatalogRepo    repository.VaccineCatalogRepositoryHandler
+	bulkFileJobsRepo      repository.BulkFileJobsRepositoryHandler
+	config                *config.Config
+	logger                *slog.Logger
+}
+
+func (u *ExemptionUsecase) CreateExemption(ctx context.Context, exemption *models.Exemption) error {
+	students, _ := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id = %d", exemption.StudentId))
+	if len(students) != 1 {
+		os.Remove(exemption.DocumentPath)
+		return fmt.Errorf("no student exists with student_id : %d", exemption.StudentId)
+	}
+	vaccines, err := u.vaccineCatalogRepo.GetVaccines(ctx, fmt.Sprintf("id = %d", exemption.VaccineId), requests.Pagination{})
+	if err != nil || len(vaccines) == 0 {
+		os.Remove(exemption.DocumentPath)
+		return fmt.Errorf("no vaccine exists with vaccine_id : %d", exemption.VaccineId)
+	}
+	if exemption.ExpiryDate != nil && !exemption.IsActive(time.Now()) {
+		os.Remove(exemption.DocumentPath)
+		return fmt.Errorf("expiry_date %s is in the past", exemption.ExpiryDate.Format("2006-01-02"))
+	}
+	if exemption.DocumentPath != "" {
+		uploaded, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, exemption.DocumentPath, u.config.Minio.Bucket, "exemptions/", uuid.NewString())
+		if err != nil {
+			u.logger.Error("error uploading exemption document", logging.Err(err))
+			return errors.New("unable to upload supporting document")
+		}
+		exemption.DocumentPath = uploaded
+	}
+	if err = u.repo.CreateExemption(ctx, exemption); err != nil {
+		u.logger.Error("error creating exemption", logging.Err(err))
+		return errors.New("unable to save exemption please try again later")
+	}
+	exemption.VaccineName = vaccines[0].Name
+	return nil
+}
+
+func (u *ExemptionUsecase) GetExemptions(ctx context.Context, request *requests.GetExemptionRequest) (int, []models.Exemption, error) {
+	conditions := []string{}
+	if request.Id != 0 {
+		conditions = append(conditions, fmt.Sprintf("e.id = %d", request.Id))
+	}
+	if request.StudentId != 0 {
+		conditions = append(conditions, fmt.Sprintf("e.student_id = %d", request.StudentId))
+	}
+	if request.VaccineId != 0 {
+		conditions = append(conditions, fmt.Sprintf("e.vaccine_id = %d", request.VaccineId))
+	}
+	if request.ExemptionType != "" {
+		conditions = append(conditions, fmt.Sprintf("e.exemption_type = '%s'", request.ExemptionType))
+	}
+	if request.ActiveOnly {
+		conditions = append(conditions, repository.ACTIVE_EXEMPTION_FILTER)
+	}
+	filter := strings.Join(conditions, " AND ")
+	total, err := u.repo.GetExemptionCount(ctx, filter)
+	if err != nil {
+		u.logger.Error("error fetching exemption count", logging.Err(err))
+		return total, nil, err
+	}
+	exemptions, err := u.repo.GetExemptions(ctx, filter, request.Pagination)
+	return total, exemptions, err
+}
+
+func (u *ExemptionUsecase) DeleteExemption(ctx context.Context, id int) error {
+	exemptions, err := u.repo.GetExemptions(ctx, fmt.Sprintf("e.id = %d", id), requests.Pagination{})
+	if err != nil {
+		u.logger.Error("error fetching exemption", logging.Err(err))
+		return err
+	}
+	if len(exemptions) == 0 {
+		return fmt.Errorf("no exemption exists with id %d", id)
+	}
+	return u.repo.DeleteExemption(ctx, id)
+}
+
+func NewExemptionUsecaseHandler(repo repository.ExemptionRepositoryHandler, studentRepo repository.StudentManagementRepositoryHandler, vaccineCatalogRepo repository.VaccineCatalogRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, cfg *config.Config, logger *slog.Logger) ExemptionUsecaseHandler {
+	return &ExemptionUsecase{
+		repo:                  repo,
+		studentManagementRepo: studentRepo,
+		vaccineCatalogRepo:    vaccineCatalogRepo,
+		bulkFileJobsRepo:      bulkfileJobsRepo,
+		config:                cfg,
+		logger:                logger,
+	}
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/fhir"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"time"
+)
+
+type FHIRExportUsecaseHandler interface {
+	ExportBundle(ctx context.Context, request *requests.FHIRExportRequest) (fhir.Bundle, error)
+	ExportBundleFile(ctx context.Context, request *requests.FHIRExportRequest) (string, error)
+	ExportNDJSON(ctx context.Context, request *requests.FHIRExportRequest) (fhir.BulkManifest, error)
+}
+
+type FHIRExportUsecase struct {
+	studentManagementRepo        repository.StudentManagementRepositoryHandler
+	studentVaccinationRecordRepo repository.StudentVaccinationRecordRepositoryHandler
+	vaccineInventoryRepo         repository.VaccineInventoryHandler
+	vaccineCatalogRepo           repository.VaccineCatalogRepositoryHandler
+	bulkFileJobsRepo             repository.BulkFileJobsRepositoryHandler
+	config                       *config.Config
+	logger                       *slog.Logger
+}
+
+func (u *FHIRExportUsecase) ExportBundle(ctx context.Context, request *requests.FHIRExportRequest) (fhir.Bundle, error) {
+	patients, immunizations, err := u.collectResources(ctx, request)
+	if err != nil {
+		return fhir.Bundle{}, err
+	}
+	bundle := fhir.NewBundle(u.config.FHIR.BaseURL, request.RequestId, patients, immunizations)
+	encoded, err := json.Marshal(bundle)
+	if err != nil {
+		return bundle, err
+	}
+	if err = fhir.Validate(encoded); err != nil {
+		u.logger.Info("generated bundle is not valid FHIR", logging.Err(err))
+		return bundle, errors.New("unable to generate a valid FHIR export")
+	}
+	return bundle, nil
+}
+
+// the bundle as a report file in the bucket, for the report endpoint's fhir format
+func (u *FHIRExportUsecase) ExportBundleFile(ctx context.Context, request *requests.FHIRExportRequest) (string, error) {
+	bundle, err := u.ExportBundle(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	exportDir, err := os.MkdirTemp("", "fhir-export-*")
+	if err != nil {
+		u.logger.Error("unable to create export directory", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	defer os.RemoveAll(exportDir)
+	reportFileName := filepath.Join(exportDir, "VaccinationReport.fhir.json")
+	if err = os.WriteFile(reportFileName, encoded, 0644); err != nil {
+		u.logger.Error("unable to save report File Locally", logging.Err(err))
+		return "", errors.New("Internal server Error")
+	}
+	uploaded, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, reportFileName, u.config.Minio.Bucket, "reports/", request.RequestId)
+	if err != nil {
+		u.logger.Error("error in uploading file to minio", logging.Err(err))
+		return "", err
+	}
+	return u.config.Minio.ObjectURL(uploaded), nil
+}
+
+// writes one NDJSON file per resource type to exports/<request id>/ and describes them in a bulk data manifest
+func (u *FHIRExportUsecase) ExportNDJSON(ctx context.Context, request *requests.FHIRExportRequest) (fhir.BulkManifest, error) {
+	manifest := fhir.BulkManifest{
+		TransactionTime: time.Now().Format(time.RFC3339),
+		Request:         "school-vaccine-portal/exports/fhir?format=ndjson",
+		Output:          []fhir.BulkFileOutput{},
+		Error:           []fhir.BulkFileOutput{},
+	}
+	patients, immunizations, err := u.collectResources(ctx, request)
+	if err != nil {
+		return manifest, err
+	}
+	files := []struct {
+		resourceType string
+		resources    []interface{}
+	}{
+		{resourceType: fhir.RESOURCE_PATIENT, resources: []interface{}{}},
+		{resourceType: fhir.RESOURCE_IMMUNIZATION, resources: []interface{}{}},
+	}
+	for _, patient := range patients {
+		files[0].resources = append(files[0].resources, patient)
+	}
+	for _, immunization := range immunizations {
+		files[1].resources = append(files[1].resources, immunization)
+	}
+	exportDir, err := os.MkdirTemp("", "fhir-export-*")
+	if err != nil {
+		u.logger.Error("unable to create export directory", logging.Err(err))
+		return manifest, errors.New("Internal server Error")
+	}
+	defer os.RemoveAll(exportDir)
+	for _, file := range files {
+		localFile := filepath.Join(exportDir, file.resourceType+".ndjson")
+		out, err := os.Create(localFile)
+		if err != nil {
+			u.logger.Error("unable to create ndjson file", logging.Err(err))
+			return manifest, errors.New("Internal server Error")
+		}
+		err = fhir.WriteNDJSON(out, file.resources)
+		out.Close()
+		if err != nil {
+			u.logger.Error("unable to write ndjson file", "resource_type", file.resourceType, logging.Err(err))
+			return manifest, errors.New("unable to generate a valid FHIR export")
+		}
+		uploaded, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, localFile, u.config.Minio.Bucket, "exports/", request.RequestId)
+		if err != nil {
+			u.logger.Error("error in uploading file to minio", logging.Err(err))
+			return manifest, err
+		}
+		manifest.Output = append(manifest.Output, fhir.BulkFileOutput{Type: file.resourceType, Url: u.config.Minio.ObjectURL(uploaded), Count: len(file.resources)})
+	}
+	return manifest, nil
+}
+
+// maps the records in scope, see loadExportData for what is included
+func (u *FHIRExportUsecase) collectResources(ctx context.Context, request *requests.FHIRExportRequest) ([]fhir.Patient, []fhir.Immunization, error) {
+	scope := exportScope{Class: request.Class, StudentId: request.StudentId, DriveId: request.DriveId, VaccineId: request.VaccineId, VaccineName: request.VaccineName}
+	data, err := loadExportData(ctx, scope, u.studentManagementRepo, u.studentVaccinationRecordRepo, u.vaccineInventoryRepo, u.vaccineCatalogRepo, u.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	patients := []fhir.Patient{}
+	immunizations := []fhir.Immunization{}
+	recordsByStudent := data.RecordsByStudent()
+	for _, student := range data.Students {
+		patients = append(patients, fhir.PatientFromStudent(student))
+		for _, record := range recordsByStudent[student.Id] {
+			drive := data.Drives[record.DriveId]
+			immunizations = append(immunizations, fhir.ImmunizationFromRecord(record, drive, data.VaccineFor(drive)))
+		}
+	}
+	return patients, immunizations, nil
+}
+
+func NewFHIRExportUsecaseHandler(studentRepo repository.StudentManagementRepositoryHandler, studentvaccinationrepo repository.StudentVaccinationRecordRepositoryHandler, vaccineinventoryRepo repository.VaccineInventoryHandler, vaccineCatalogRepo repository.VaccineCatalogRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, cfg *config.Config, logger *slog.Logger) FHIRExportUsecaseHandler {
+	return &FHIRExportUsecase{
+		studentManagementRepo:        studentRepo,
+		studentVaccinationRecordRepo: studentvaccinationrepo,
+		vaccineInventoryRepo:         vaccineinventoryRepo,
+		vaccineCatalogRepo:           vaccineCatalogRepo,
+		bulkFileJobsRepo:             bulkfileJobsRepo,
+		config:                       cfg,
+		logger:                       logger,
+	}
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strings"
+)
+
+type GuardianUsecaseHandler interface {
+	CreateGuardians(ctx context.Context, guardians *[]models.Guardian) []models.GuardianInsertionRecord
+	UpdateGuardian(ctx context.Context, request *requests.GuardianUpdateRequest) (models.Guardian, error)
+	GetGuardians(ctx context.Context, request *requests.GetGuardianRequest) (int, []models.Guardian, error)
+	DeleteGuardian(ctx context.Context, id int) error
+	LinkStudents(ctx context.Context, guardianId int, studentIds []int) (models.Guardian, error)
+	UnlinkStudent(ctx context.Context, guardianId, studentId int) (models.Guardian, error)
+}
+
+type GuardianUsecase struct {
+	repo                  repository.GuardianRepositoryHandler
+	studentManagementRepo repository.StudentManagementRepositoryHandler
+	logger                *slog.Logger
+}
+
+func (u *GuardianUsecase) CreateGuardians(ctx context.Context, guardians *[]models.Guardian) []models.GuardianInsertionRecord {
+	result := []models.GuardianInsertionRecord{}
+	for _, j := range *guardians {
+		if err := u.verifyStudentsExist(ctx, j.StudentIds); err != nil {
+			result = append(result, models.GuardianInsertionRecord{Record: j, Status: false, ErrorReason: err.Error()})
+			continue
+		}
+		if err := u.repo.CreateGuardian(ctx, &j); err != nil {
+			u.logger.Error("error creating guardian", logging.Err(err))
+			result = append(result, models.GuardianInsertionRecord{Record: j, Status: false, ErrorReason: "unable to save guardian please try again later"})
+			continue
+		}
+		result = append(result, models.GuardianInsertionRecord{Record: j, Status: true})
+	}
+	return result
+}
+
+func (u *GuardianUsecase) verifyStudentsExist(ctx context.Context, studentIds []int) error {
+	if len(studentIds) == 0 {
+		return nil
+	}
+	ids := make([]string, len(studentIds))
+	for i, id := range studentIds {
+		ids[i] = fmt.Sprintf("%d", id)
+	}
+	students, err := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id IN (%s)", strings.Join(ids, ", ")))
+	if err != nil {
+		u.logger.Error("error fetching students", logging.Err(err))
+		return errors.New("unable to verify students please try again later")
+	}
+	found := map[int]bool{}
+	for _, s := range students {
+		found[s.Id] = true
+	}
+	for _, id := range studentIds {
+		if !found[id] {
+			return fmt.Errorf("no student exists with student_id : %d", id)
+		}
+	}
+	return nil
+}
+
+func (u *GuardianUsecase) getGuardian(ctx context.Context, id int) (models.Guardian, error) {
+	guardians, err := u.repo.GetGuardians(ctx, fmt.Sprintf("g.id = %d", id), requests.Pagination{})
+	if err != nil {
+		u.logger.Error("error fetching guardian", logging.Err(err))
+		return models.Guardian{}, errors.New("unable to fetch guardian please try again later")
+	}
+	if len(guardians) == 0 {
+		return models.Guardian{}, fmt.Errorf("no guardian exists with id %d", id)
+	}
+	if err = u.attachStudents(ctx, guardians); err != nil {
+		return guardians[0], err
+	}
+	return guardians[0], nil
+}
+
+// fills each guardian's linked student ids in one query
+func (u *GuardianUsecase) attachStudents(ctx context.Context, guardians []models.Guardian) error {
+	ids := make([]int, len(guardians))
+	for i, g := range guardians {
+		ids[i] = g.Id
+	}
+	links, err := u.repo.GetLinkedStudents(ctx, ids)
+	if err != nil {
+		u.logger.Error("error fetching guardian students", logging.Err(err))
+		return err
+	}
+	register := map[int][]int{}
+	for _, l := range links {
+		register[l.GuardianId] = append(register[l.GuardianId], l.StudentId)
+	}
+	for i := range guardians {
+		guardians[i].StudentIds = register[guardians[i].Id]
+		if guardians[i].StudentIds == nil {
+			guardians[i].StudentIds = []int{}
+		}
+	}
+	return nil
+}
+
+func (u *GuardianUsecase) UpdateGuardian(ctx context.Context, request *requests.GuardianUpdateRequest) (models.Guardian, error) {
+	if _, err := u.getGuardian(ctx, request.Id); err != nil {
+		return models.Guardian{}, err
+	}
+	if err := u.repo.UpdateGuardian(ctx, request); err != nil {
+		u.logger.Error("error updating guardian", logging.Err(err))
+		return models.Guardian{}, err
+	}
+	return u.getGuardian(ctx, request.Id)
+}
+
+func (u *GuardianUsecase) GetGuardians(ctx context.Context, request *requests.GetGuardianRequest) (int, []models.Guardian, error) {
+	conditions := []string{}
+	if request.Id != 0 {
+		conditions = append(conditions, fmt.Sprintf("g.id = %d", request.Id))
+	}
+	if request.StudentId != 0 {
+		conditions = append(conditions, fmt.Sprintf("g.id IN (SELECT guardian_id FROM student_guardians WHERE student_id = %d)", request.StudentId))
+	}
+	if request.Name != "" {
+		conditions = append(conditions, fmt.Sprintf("g.name LIKE '%%%s%%'", strings.ReplaceAll(request.Name, "'", "''")))
+	}
+	if request.Phone != "" {
+		phone := strings.ReplaceAll(request.Phone, "'", "''")
+		conditions = append(conditions, fmt.Sprintf("(g.phone_primary = '%s' OR g.phone_secondary = '%s')", phone, phone))
+	}
+	filter := strings.Join(conditions, " AND ")
+	total, err := u.repo.GetGuardianCount(ctx, filter)
+	if err != nil {
+		u.logger.Error("error fetching guardian count", logging.Err(err))
+		return total, nil, err
+	}
+	guardians, err := u.repo.GetGuardians(ctx, filter, request.Pagination)
+	if err != nil {
+		u.logger.Error("error fetching guardians", logging.Err(err))
+		return total, nil, err
+	}
+	return total, guardians, u.attachStudents(ctx, guardians)
+}
+
+func (u *GuardianUsecase) DeleteGuardian(ctx context.Context, id int) error {
+	if _, err := u.getGuardian(ctx, id); err != nil {
+		return err
+	}
+	return u.repo.DeleteGuardian(ctx, id)
+}
+
+func (u *GuardianUsecase) LinkStudents(ctx context.Context, guardianId int, studentIds []int) (models.Guardian, error) {
+	if _, err := u.getGuardian(ctx, guardianId); err != nil {
+		return models.Guardian{}, err
+	}
+	if err := u.verifyStudentsExist(ctx, studentIds); err != nil {
+		return models.Guardian{}, err
+	}
+	if err := u.repo.LinkStudents(ctx, guardianId, studentIds); err != nil {
+		u.logger.Error("error linking students", logging.Err(err))
+		return models.Guardian{}, err
+	}
+	return u.getGuardian(ctx, guardianId)
+}
+
+func (u *GuardianUsecase) UnlinkStudent(ctx context.Context, guardianId, studentId int) (models.Guardian, error) {
+	if _, err := u.getGuardian(ctx, guardianId); err != nil {
+		return models.Guardian{}, err
+	}
+	if err := u.repo.UnlinkStudent(ctx, guardianId, studentId); err != nil {
+		u.logger.Error("error unlinking student", logging.Err(err))
+		return models.Guardian{}, err
+	}
+	return u.getGuardian(ctx, guardianId)
+}
+
+func NewGuardianUsecaseHandler(repo repository.GuardianRepositoryHandler, studentRepo repository.StudentManagementRepositoryHandler, logger *slog.Logger) GuardianUsecaseHandler {
+	return &GuardianUsecase{
+		repo:                  repo,
+		studentManagementRepo: studentRepo,
+		logger:                logger,
+	}
+}
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/hl7"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strconv"
+	"time"
+)
+
+type HL7ExportUsecaseHandler interface {
+	QueueExport(ctx context.Context, model *models.BulkFileJobsModel) error
+	ExportBatchFile(ctx context.Context, request *requests.HL7ExportRequest) (string, error)
+	ProcessExportJob(ctx context.Context, model *models.BulkFileJobsModel) error
+}
+
+type HL7ExportUsecase struct {
+	studentManagementRepo        repository.StudentManagementRepositoryHandler
+	studentVaccinationRecordRepo repository.StudentVaccinationRecordRepositoryHandler
+	vaccineInventoryRepo         repository.VaccineInventoryHandler
+	vaccineCatalogRepo           repository.VaccineCatalogRepositoryHandler
+	bulkFileJobsRepo             repository.BulkFileJobsRepositoryHandler
+	config                       *config.Config
+	logger                       *slog.Logger
+}
+
+// records the export as a bulk job and hands it to the bulk worker
+func (u *HL7ExportUsecase) QueueExport(ctx context.Context, model *models.BulkFileJobsModel) error {
+	if err := u.bulkFileJobsRepo.CreateFileUpload(ctx, model); err != nil {
+		return fmt.Errorf("error in creating export job entry %s", err.Error())
+	}
+	return u.bulkFileJobsRepo.SubmitToRabbitMQ(ctx, model)
+}
+
+// the batch file as a report, for the report endpoint's hl7 format
+func (u *HL7ExportUsecase) ExportBatchFile(ctx context.Context, request *requests.HL7ExportRequest) (string, error) {
+	request.Batch = true
+	uploaded, _, err := u.export(ctx, request, "reports/")
+	if err != nil {
+		return "", err
+	}
+	return u.config.Minio.ObjectURL(uploaded), nil
+}
+
+func (u *HL7ExportUsecase) ProcessExportJob(ctx context.Context, model *models.BulkFileJobsModel) error {
+	logger := logging.ForJob(u.logger, model)
+	u.bulkFileJobsRepo.UpdateFileUpload(ctx, &models.BulkFileJobsModel{Id: model.Id, Status: "PROCESSING"})
+	request := new(requests.HL7ExportRequest)
+	if err := json.Unmarshal([]byte(model.Parameters), request); err != nil {
+		logger.Error("invalid hl7 export parameters", logging.Err(err))
+		model.ErrorMessage = "Invalid export parameters"
+		model.Status = "FAILED"
+		u.bulkFileJobsRepo.UpdateFileUpload(ctx, model)
+		return nil
+	}
+	request.RequestId = model.RequestId
+	uploaded, messages, err := u.export(ctx, request, "exports/")
+	if err != nil {
+		logger.Error("hl7 export failed", logging.Err(err))
+		model.ErrorMessage = err.Error()
+		model.Status = "FAILED"
+		u.bulkFileJobsRepo.UpdateFileUpload(ctx, model)
+		return nil
+	}
+	model.FilePath = u.config.Minio.ObjectURL(uploaded)
+	model.TotalRecords = messages
+	model.ProcessedRecords = messages
+	model.Status = "PROCESSED"
+	u.bulkFileJobsRepo.UpdateFileUpload(ctx, model)
+	logger.Info("hl7 export complete", "messages", messages)
+	return nil
+}
+
+// builds one VXU per student, checks the output parses back to the same messages and uploads it under root,
+// returning the object key and the number of messages
+func (u *HL7ExportUsecase) export(ctx context.Context, request *requests.HL7ExportRequest, root string) (string, int, error) {
+	scope := exportScope{Class: request.Class, StudentId: request.StudentId, DriveId: request.DriveId, VaccineId: request.VaccineId, VaccineName: request.VaccineName}
+	data, err := loadExportData(ctx, scope, u.studentManagementRepo, u.studentVaccinationRecordRepo, u.vaccineInventoryRepo, u.vaccineCatalogRepo, u.logger)
+	if err != nil {
+		return "", 0, err
+	}
+	header := hl7.NewHeader(u.config.HL7)
+	now := time.Now()
+	recordsByStudent := data.RecordsByStudent()
+	messages := []string{}
+	for _, student := range data.Students {
+		doses := []hl7.Dose{}
+		for _, record := range recordsByStudent[student.Id] {
+			doses = append(doses, vxuDose(record, data))
+		}
+		if len(doses) == 0 {
+			continue
+		}
+		message := hl7.BuildVXU(header, hl7.ControlId(now, len(messages)+1), student, doses, now)
+		if err = checkVXU(message, student, len(doses)); err != nil {
+			u.logger.Error("generated VXU does not parse back", "student_id", student.Id, logging.Err(err))
+			return "", 0, errors.New("unable to generate valid HL7 messages")
+		}
+		messages = append(messages, message)
+	}
+	exportDir, err := os.MkdirTemp("", "hl7-export-*")
+	if err != nil {
+		u.logger.Error("unable to create export directory", logging.Err(err))
+		return "", 0, errors.New("Internal server Error")
+	}
+	defer os.RemoveAll(exportDir)
+	var localFile string
+	if request.Batch {
+		localFile, err = writeBatchFile(exportDir, header, messages, now)
+	} else {
+		localFile, err = writeMessageArchive(exportDir, messages)
+	}
+	if err != nil {
+		u.logger.Error("unable to write hl7 export", logging.Err(err))
+		return "", 0, errors.New("Internal server Error")
+	}
+	uploaded, err := u.bulkFileJobsRepo.UploadFileToMinio(ctx, localFile, u.config.Minio.Bucket, root, request.RequestId)
+	if err != nil {
+		u.logger.Error("error in uploading file to minio", logging.Err(err))
+		return "", 0, err
+	}
+	return uploaded, len(messages), nil
+}
+
+func vxuDose(record models.StudentVaccineRecord, data exportData) hl7.Dose {
+	drive := data.Drives[record.DriveId]
+	dose := hl7.Dose{
+		RecordId:       record.Id,
+		VaccineCode:    strconv.Itoa(drive.VaccineId),
+		VaccineName:    drive.VaccineName,
+		AdministeredAt: drive.DriveDate,
+		LotNumber:      record.LotNumber,
+		ExpiryDate:     record.ExpiryDate,
+		AdministeredBy: record.AdministeredBy,
+		Voided:         record.Status == models.RECORD_VOIDED,
+	}
+	if record.AdministeredAt != nil {
+		dose.AdministeredAt = *record.AdministeredAt
+	}
+	if vaccine := data.VaccineFor(drive); vaccine != nil {
+		dose.VaccineName = vaccine.Name
+		dose.CvxCode = vaccine.CvxCode
+		dose.Manufacturer = vaccine.Manufacturer
+	}
+	return dose
+}
+
+// parses the message back and compares what a registry would read against what was meant to be sent
+func checkVXU(message string, student models.StudentManagement, doses int) error {
+	parsed, err := hl7.ParseMessage(message)
+	if err != nil {
+		return err
+	}
+	if parsed.Segments[0].Field(9) != hl7.Components("VXU", "V04", "VXU_V04") {
+		return fmt.Errorf("unexpected message type %s", parsed.Segments[0].Field(9))
+	}
+	pid := parsed.SegmentsNamed("PID")
+	if len(pid) != 1 || pid[0].Component(3, 1) != strconv.Itoa(student.Id) {
+		return errors.New("PID-3 does not identify the student")
+	}
+	if len(parsed.SegmentsNamed("RXA")) != doses || len(parsed.SegmentsNamed("ORC")) != doses {
+		return fmt.Errorf("expected %d ORC/RXA pairs", doses)
+	}
+	return nil
+}
+
+func writeBatchFile(dir string, header hl7.Header, messages []string, now time.Time) (string, error) {
+	batch := hl7.BuildBatch(header, hl7.ControlId(now, 0), messages, now)
+	if parsed, err := hl7.ParseBatch(batch); err != nil || len(parsed) != len(messages) {
+		return "", fmt.Errorf("batch file does not parse back %v", err)
+	}
+	localFile := filepath.Join(dir, "VXU-batch.hl7")
+	return localFile, os.WriteFile(localFile, []byte(batch), 0644)
+}
+
+func writeMessageArchive(dir string, messages []string) (string, error) {
+	localFile := filepath.Join(dir, "VXU-messages.zip")
+	out, err := os.Create(localFile)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	archive := zip.NewWriter(out)
+	for i, message := range messages {
+		entry, err := archive.Create(fmt.Sprintf("VXU-%05d.hl7", i+1))
+		if err != nil {
+			return "", err
+		}
+		if _, err = entry.Write([]byte(message)); err != nil {
+			return "", err
+		}
+	}
+	return localFile, archive.Close()
+}
+
+func NewHL7ExportUsecaseHandler(studentRepo repository.StudentManagementRepositoryHandler, studentvaccinationrepo repository.StudentVaccinationRecordRepositoryHandler, vaccineinventoryRepo repository.VaccineInventoryHandler, vaccineCatalogRepo repository.VaccineCatalogRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, cfg *config.Config, logger *slog.Logger) HL7ExportUsecaseHandler {
+	return &HL7ExportUsecase{
+		studentManagementRepo:        studentRepo,
+		studentVaccinationRecordRepo: studentvaccinationrepo,
+		vaccineInventoryRepo:         vaccineinventoryRepo,
+		vaccineCatalogRepo:           vaccineCatalogRepo,
+		bulkFileJobsRepo:             bulkfileJobsRepo,
+		config:                       cfg,
+		logger:                       logger,
+	}
+}
@@ -0,0 +1,373 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/notifier"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strings"
+)
+
+type NotificationUsecaseHandler interface {
+	QueueDriveReminders(ctx context.Context, daysBefore int) (int, error)
+	QueueVaccinationConfirmations(ctx context.Context, records []models.StudentVaccineRecord) int
+	QueueMissedDriveFollowUps(ctx context.Context, daysAfter int) (int, error)
+	DeliverNotification(ctx context.Context, id int) error
+	RetryNotification(ctx context.Context, id int) (models.Notification, error)
+	GetNotifications(ctx context.Context, request *requests.GetNotificationRequest) (int, []models.Notification, error)
+}
+
+type NotificationUsecase struct {
+	repo                         repository.NotificationRepositoryHandler
+	guardianRepo                 repository.GuardianRepositoryHandler
+	studentManagementRepo        repository.StudentManagementRepositoryHandler
+	studentVaccinationRecordRepo repository.StudentVaccinationRecordRepositoryHandler
+	vaccineInventoryRepo         repository.VaccineInventoryHandler
+	exemptionRepo                repository.ExemptionRepositoryHandler
+	notifiers                    map[string]notifier.Notifier
+	config                       *config.Config
+	logger                       *slog.Logger
+}
+
+// reminds guardians of every eligible, non exempt student still due a dose about drives scheduled daysBefore days from today
+func (u *NotificationUsecase) QueueDriveReminders(ctx context.Context, daysBefore int) (int, error) {
+	drives, err := u.vaccineInventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("status = '%s' AND DATE(drive_date) = DATE_ADD(CURDATE(), INTERVAL %d DAY)", models.DRIVE_SCHEDULED, daysBefore))
+	if err != nil {
+		u.logger.Error("error fetching upcoming drives", logging.Err(err))
+		return 0, err
+	}
+	queued := 0
+	for _, drive := range drives {
+		students, err := u.driveStudents(ctx, drive)
+		if err != nil {
+			return queued, err
+		}
+		if students, err = u.dueDose(ctx, drive, students); err != nil {
+			return queued, err
+		}
+		queued += u.queueForStudents(ctx, models.NOTIFY_DRIVE_REMINDER, drive, students)
+	}
+	return queued, nil
+}
+
+// follows up with guardians of students who were eligible for a drive held daysAfter days ago and are still due
+// the dose it gave, by the same rule the reminders use
+func (u *NotificationUsecase) QueueMissedDriveFollowUps(ctx context.Context, daysAfter int) (int, error) {
+	drives, err := u.vaccineInventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("status IN ('%s', '%s') AND DATE(drive_date) = DATE_SUB(CURDATE(), INTERVAL %d DAY)", models.DRIVE_IN_PROGRESS, models.DRIVE_COMPLETED, daysAfter))
+	if err != nil {
+		u.logger.Error("error fetching past drives", logging.Err(err))
+		return 0, err
+	}
+	queued := 0
+	for _, drive := range drives {
+		students, err := u.driveStudents(ctx, drive)
+		if err != nil {
+			return queued, err
+		}
+		missed, err := u.dueDose(ctx, drive, students)
+		if err != nil {
+			return queued, err
+		}
+		queued += u.queueForStudents(ctx, models.NOTIFY_MISSED_DRIVE, drive, missed)
+	}
+	return queued, nil
+}
+
+// students in the drive's classes, leaving out those exempt from its vaccine
+func (u *NotificationUsecase) driveStudents(ctx context.Context, drive models.VaccineInventory) ([]models.StudentManagement, error) {
+	if len(drive.Classes) == 0 {
+		return nil, nil
+	}
+	classes := []string{}
+	for _, class := range drive.Classes {
+		classes = append(classes, fmt.Sprintf("'%s'", strings.ReplaceAll(class, "'", "''")))
+	}
+	students, err := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("class IN (%s)", strings.Join(classes, ", ")))
+	if err != nil || len(students) == 0 {
+		return students, err
+	}
+	exemptions, err := u.exemptionRepo.GetExemptions(ctx, fmt.Sprintf("e.vaccine_id = %d AND e.student_id IN (%s) AND %s", drive.VaccineId, studentIdList(students), repository.ACTIVE_EXEMPTION_FILTER), requests.Pagination{})
+	if err != nil {
+		u.logger.Error("error fetching exemptions for drive", logging.Err(err))
+		return nil, err
+	}
+	exempt := map[int]bool{}
+	for _, e := range exemptions {
+		exempt[e.StudentId] = true
+	}
+	eligible := []models.StudentManagement{}
+	for _, s := range students {
+		if !exempt[s.Id] {
+			eligible = append(eligible, s)
+		}
+	}
+	return eligible, nil
+}
+
+// leaves out students dosed at the drive or who completed the series of its vaccine, a student part way through
+// the series is still due the next dose
+func (u *NotificationUsecase) dueDose(ctx context.Context, drive models.VaccineInventory, students []models.StudentManagement) ([]models.StudentManagement, error) {
+	if len(students) == 0 {
+		return students, nil
+	}
+	completed := fmt.Sprintf("SELECT d.student_id FROM student_vaccination_records d WHERE d.vaccine_id = %d AND d.status = '%s' GROUP BY d.student_id HAVING COUNT(*) >= (SELECT GREATEST(c.doses_in_series, 1) FROM vaccines c WHERE c.id = %d)", drive.VaccineId, models.RECORD_ACTIVE, drive.VaccineId)
+	records, err := u.studentVaccinationRecordRepo.GetVaccinationRecords(ctx, fmt.Sprintf("vaccine_id = %d AND status = '%s' AND student_id IN (%s) AND (drive_id = %d OR student_id IN (%s))", drive.VaccineId, models.RECORD_ACTIVE, studentIdList(students), drive.Id, completed))
+	if err != nil {
+		u.logger.Error("error fetching vaccination records for drive", logging.Err(err))
+		return nil, err
+	}
+	dosed := map[int]bool{}
+	for _, r := range records {
+		dosed[r.StudentId] = true
+	}
+	due := []models.StudentManagement{}
+	for _, s := range students {
+		if !dosed[s.Id] {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+func (u *NotificationUsecase) queueForStudents(ctx context.Context, notificationType string, drive models.VaccineInventory, students []models.StudentManagement) int {
+	contacts, err := u.studentContacts(ctx, students)
+	if err != nil {
+		return 0
+	}
+	queued := 0
+	for _, s := range students {
+		data := notifier.TemplateData{
+			StudentName: s.Name,
+			VaccineName: drive.VaccineName,
+			DriveDate:   drive.DriveDate.Format("02 Jan 2006"),
+		}
+		queued += u.queue(ctx, notificationType, s.Id, drive.Id, 0, data, contacts[s.Id])
+	}
+	return queued
+}
+
+// confirms each newly created vaccination to the student's guardians
+func (u *NotificationUsecase) QueueVaccinationConfirmations(ctx context.Context, records []models.StudentVaccineRecord) int {
+	if len(records) == 0 {
+		return 0
+	}
+	studentIds := make([]string, len(records))
+	driveIds := make([]string, len(records))
+	for i, r := range records {
+		studentIds[i] = fmt.Sprintf("%d", r.StudentId)
+		driveIds[i] = fmt.Sprintf("%d", r.DriveId)
+	}
+	students, err := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id IN (%s)", strings.Join(studentIds, ", ")))
+	if err != nil {
+		u.logger.Error("error fetching students for confirmation", logging.Err(err))
+		return 0
+	}
+	drives, err := u.vaccineInventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("id IN (%s)", strings.Join(driveIds, ", ")))
+	if err != nil {
+		u.logger.Error("error fetching drives for confirmation", logging.Err(err))
+		return 0
+	}
+	studentRegister := map[int]models.StudentManagement{}
+	for _, s := range students {
+		studentRegister[s.Id] = s
+	}
+	driveRegister := map[int]models.VaccineInventory{}
+	for _, d := range drives {
+		driveRegister[d.Id] = d
+	}
+	contacts, err := u.studentContacts(ctx, students)
+	if err != nil {
+		return 0
+	}
+	queued := 0
+	for _, r := range records {
+		drive := driveRegister[r.DriveId]
+		data := notifier.TemplateData{
+			StudentName: studentRegister[r.StudentId].Name,
+			VaccineName: drive.VaccineName,
+			DriveDate:   drive.DriveDate.Format("02 Jan 2006"),
+			DoseNumber:  r.DoseNumber,
+			LotNumber:   r.LotNumber,
+		}
+		if r.AdministeredAt != nil {
+			data.AdministeredAt = r.AdministeredAt.Format("02 Jan 2006")
+		}
+		queued += u.queue(ctx, models.NOTIFY_VACCINATION_CONFIRMATION, r.StudentId, r.DriveId, r.Id, data, contacts[r.StudentId])
+	}
+	return queued
+}
+
+func (u *NotificationUsecase) studentContacts(ctx context.Context, students []models.StudentManagement) (map[int][]models.GuardianContact, error) {
+	register := map[int][]models.GuardianContact{}
+	ids := make([]int, len(students))
+	for i, s := range students {
+		ids[i] = s.Id
+	}
+	contacts, err := u.guardianRepo.GetGuardianContacts(ctx, ids)
+	if err != nil {
+		u.logger.Error("error fetching guardian contacts", logging.Err(err))
+		return register, err
+	}
+	for _, c := range contacts {
+		register[c.StudentId] = append(register[c.StudentId], c)
+	}
+	return register, nil
+}
+
+// renders and stores one notification per guardian and channel they can be reached on, then hands it to the worker
+func (u *NotificationUsecase) queue(ctx context.Context, notificationType string, studentId, driveId, recordId int, data notifier.TemplateData, contacts []models.GuardianContact) int {
+	data.SchoolName = u.config.School.Name
+	queued := 0
+	for _, contact := range contacts {
+		data.GuardianName = contact.Name
+		recipients := map[string]string{
+			models.CHANNEL_SMS:   contact.PhonePrimary,
+			models.CHANNEL_EMAIL: contact.Email,
+		}
+		for _, channel := range []string{models.CHANNEL_SMS, models.CHANNEL_EMAIL} {
+			if recipients[channel] == "" {
+				continue
+			}
+			subject, body, err := notifier.Render(notificationType, contact.PreferredLanguage, channel, data)
+			if err != nil {
+				u.logger.Error("error rendering notification", "notification_type", notificationType, "channel", channel, logging.Err(err))
+				continue
+			}
+			notification := models.Notification{
+				NotificationType: notificationType,
+				Channel:          channel,
+				GuardianId:       contact.GuardianId,
+				StudentId:        studentId,
+				DriveId:          driveId,
+				RecordId:         recordId,
+				Recipient:        recipients[channel],
+				Language:         contact.PreferredLanguage,
+				Subject:          subject,
+				Body:             body,
+				Status:           models.NOTIFICATION_QUEUED,
+			}
+			created, err := u.repo.CreateNotification(ctx, &notification)
+			if err != nil {
+				u.logger.Error("error creating notification", "notification_type", notificationType, "guardian_id", contact.GuardianId, logging.Err(err))
+				continue
+			}
+			if !created {
+				continue
+			}
+			if err = u.repo.PublishNotification(ctx, notification.Id); err != nil {
+				u.logger.Error("error publishing notification", "notification_id", notification.Id, logging.Err(err))
+				u.repo.UpdateNotificationStatus(ctx, notification.Id, models.NOTIFICATION_FAILED, "unable to queue for delivery")
+				continue
+			}
+			queued++
+		}
+	}
+	return queued
+}
+
+func (u *NotificationUsecase) getNotification(ctx context.Context, id int) (models.Notification, error) {
+	notifications, err := u.repo.GetNotifications(ctx, fmt.Sprintf("id = %d", id), requests.Pagination{})
+	if err != nil {
+		u.logger.Error("error fetching notification", logging.Err(err))
+		return models.Notification{}, err
+	}
+	if len(notifications) == 0 {
+		return models.Notification{}, fmt.Errorf("no notification exists with id %d", id)
+	}
+	return notifications[0], nil
+}
+
+func (u *NotificationUsecase) DeliverNotification(ctx context.Context, id int) error {
+	notification, err := u.getNotification(ctx, id)
+	if err != nil {
+		return err
+	}
+	if notification.Status == models.NOTIFICATION_SENT {
+		return nil
+	}
+	provider, ok := u.notifiers[notification.Channel]
+	if !ok {
+		return u.repo.UpdateNotificationStatus(ctx, id, models.NOTIFICATION_FAILED, fmt.Sprintf("no provider for channel %s", notification.Channel))
+	}
+	if err = provider.Send(notifier.Message{To: notification.Recipient, Subject: notification.Subject, Body: notification.Body}); err != nil {
+		u.logger.Error("error delivering notification", "notification", notification, logging.Err(err))
+		return u.repo.UpdateNotificationStatus(ctx, id, models.NOTIFICATION_FAILED, err.Error())
+	}
+	return u.repo.UpdateNotificationStatus(ctx, id, models.NOTIFICATION_SENT, "")
+}
+
+func (u *NotificationUsecase) RetryNotification(ctx context.Context, id int) (models.Notification, error) {
+	notification, err := u.getNotification(ctx, id)
+	if err != nil {
+		return notification, err
+	}
+	if notification.Status != models.NOTIFICATION_FAILED {
+		return notification, fmt.Errorf("notification %d is %s, only failed notifications can be retried", id, strings.ToLower(notification.Status))
+	}
+	if err = u.repo.PublishNotification(ctx, id); err != nil {
+		u.logger.Error("error publishing notification", "notification_id", id, logging.Err(err))
+		return notification, errors.New("unable to queue notification please try again later")
+	}
+	return notification, nil
+}
+
+func (u *NotificationUsecase) GetNotifications(ctx context.Context, request *requests.GetNotificationRequest) (int, []models.Notification, error) {
+	conditions := []string{}
+	if request.Id != 0 {
+		conditions = append(conditions, fmt.Sprintf("id = %d", request.Id))
+	}
+	if request.StudentId != 0 {
+		conditions = append(conditions, fmt.Sprintf("student_id = %d", request.StudentId))
+	}
+	if request.GuardianId != 0 {
+		conditions = append(conditions, fmt.Sprintf("guardian_id = %d", request.GuardianId))
+	}
+	if request.DriveId != 0 {
+		conditions = append(conditions, fmt.Sprintf("drive_id = %d", request.DriveId))
+	}
+	if request.Type != "" {
+		conditions = append(conditions, fmt.Sprintf("notification_type = '%s'", request.Type))
+	}
+	if request.Channel != "" {
+		conditions = append(conditions, fmt.Sprintf("channel = '%s'", request.Channel))
+	}
+	if request.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = '%s'", request.Status))
+	}
+	filter := strings.Join(conditions, " AND ")
+	total, err := u.repo.GetNotificationCount(ctx, filter)
+	if err != nil {
+		u.logger.Error("error fetching notification count", logging.Err(err))
+		return total, nil, err
+	}
+	notifications, err := u.repo.GetNotifications(ctx, filter, request.Pagination)
+	return total, notifications, err
+}
+
+func studentIdList(students []models.StudentManagement) string {
+	ids := make([]string, len(students))
+	for i, s := range students {
+		ids[i] = fmt.Sprintf("%d", s.Id)
+	}
+	return strings.Join(ids, ", ")
+}
+
+func NewNotificationUsecaseHandler(repo repository.NotificationRepositoryHandler, guardianRepo repository.GuardianRepositoryHandler, studentRepo repository.StudentManagementRepositoryHandler, studentvaccinationrepo repository.StudentVaccinationRecordRepositoryHandler, vaccineinventoryRepo repository.VaccineInventoryHandler, exemptionRepo repository.ExemptionRepositoryHandler, notifiers map[string]notifier.Notifier, cfg *config.Config, logger *slog.Logger) NotificationUsecaseHandler {
+	return &NotificationUsecase{
+		repo:                         repo,
+		guardianRepo:                 guardianRepo,
+		studentManagementRepo:        studentRepo,
+		studentVaccinationRecordRepo: studentvaccinationrepo,
+		vaccineInventoryRepo:         vaccineinventoryRepo,
+		exemptionRepo:                exemptionRepo,
+		notifiers:                    notifiers,
+		config:                       cfg,
+		logger:                       logger,
+	}
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+	"strings"
+)
+
+// exportScope narrows a registry export, patients are limited to the students with exported records
+// whenever a drive or vaccine filter is given
+type exportScope struct {
+	Class       string
+	StudentId   int
+	DriveId     int
+	VaccineId   int
+	VaccineName string
+}
+
+// exportData is what the FHIR and HL7 exporters map from, records are ordered by id
+type exportData struct {
+	Students []models.StudentManagement
+	Records  []models.StudentVaccineRecord
+	Drives   map[int]models.VaccineInventory
+	Vaccines map[int]models.Vaccine
+	logger   *slog.Logger
+}
+
+// VaccineFor returns the catalog entry of the record's drive, nil for drives that predate the catalog
+func (d exportData) VaccineFor(drive models.VaccineInventory) *models.Vaccine {
+	if vaccine, ok := d.Vaccines[drive.VaccineId]; ok {
+		return &vaccine
+	}
+	return nil
+}
+
+// RecordsByStudent groups the records whose drive is known
+func (d exportData) RecordsByStudent() map[int][]models.StudentVaccineRecord {
+	grouped := map[int][]models.StudentVaccineRecord{}
+	for _, record := range d.Records {
+		if _, ok := d.Drives[record.DriveId]; !ok {
+			d.logger.Warn("skipping vaccination record with unknown drive", "record_id", record.Id, "drive_id", record.DriveId)
+			continue
+		}
+		grouped[record.StudentId] = append(grouped[record.StudentId], record)
+	}
+	return grouped
+}
+
+// loads the students and vaccination records in scope with their drives and vaccines, superseded records are
+// left out because the correction that replaced them is exported instead, voided ones are kept so receivers
+// can retract them
+func loadExportData(ctx context.Context, scope exportScope, studentRepo repository.StudentManagementRepositoryHandler, recordRepo repository.StudentVaccinationRecordRepositoryHandler, vaccineInventoryRepo repository.VaccineInventoryHandler, vaccineCatalogRepo repository.VaccineCatalogRepositoryHandler, logger *slog.Logger) (exportData, error) {
+	data := exportData{Drives: map[int]models.VaccineInventory{}, Vaccines: map[int]models.Vaccine{}, logger: logger}
+	studentConditions := []string{}
+	if scope.Class != "" {
+		studentConditions = append(studentConditions, fmt.Sprintf("class = '%s'", strings.ReplaceAll(scope.Class, "'", "''")))
+	}
+	if scope.StudentId != 0 {
+		studentConditions = append(studentConditions, fmt.Sprintf("id = %d", scope.StudentId))
+	}
+	students, err := studentRepo.GetStudents(ctx, strings.Join(studentConditions, " AND "))
+	if err != nil {
+		logger.Error("error fetching students for export", logging.Err(err))
+		return data, errors.New("unable to export please try again later")
+	}
+	if len(students) == 0 {
+		return data, nil
+	}
+	studentIds := []int{}
+	for _, student := range students {
+		studentIds = append(studentIds, student.Id)
+	}
+	recordConditions := []string{
+		fmt.Sprintf("student_id IN (%s)", joinIds(studentIds)),
+		fmt.Sprintf("status IN ('%s', '%s')", models.RECORD_ACTIVE, models.RECORD_VOIDED),
+	}
+	driveConditions := []string{}
+	if scope.DriveId != 0 {
+		driveConditions = append(driveConditions, fmt.Sprintf("id = %d", scope.DriveId))
+	}
+	if scope.VaccineId != 0 {
+		driveConditions = append(driveConditions, fmt.Sprintf("vaccine_id = %d", scope.VaccineId))
+	}
+	if scope.VaccineName != "" {
+		driveConditions = append(driveConditions, fmt.Sprintf("vaccine_name = '%s'", strings.ReplaceAll(scope.VaccineName, "'", "''")))
+	}
+	if len(driveConditions) > 0 {
+		matched, err := vaccineInventoryRepo.GetVaccineInventory(ctx, strings.Join(driveConditions, " AND "))
+		if err != nil {
+			logger.Error("error fetching drives for export", logging.Err(err))
+			return data, errors.New("unable to export please try again later")
+		}
+		driveIds := []int{0}
+		for _, drive := range matched {
+			data.Drives[drive.Id] = drive
+			driveIds = append(driveIds, drive.Id)
+		}
+		recordConditions = append(recordConditions, fmt.Sprintf("drive_id IN (%s)", joinIds(driveIds)))
+	}
+	data.Records, err = recordRepo.GetVaccinationRecords(ctx, strings.Join(recordConditions, " AND "))
+	if err != nil {
+		logger.Error("error fetching vaccination records for export", logging.Err(err))
+		return data, errors.New("unable to export please try again later")
+	}
+	missingDrives := []int{}
+	referenced := map[int]bool{}
+	for _, record := range data.Records {
+		referenced[record.StudentId] = true
+		if _, ok := data.Drives[record.DriveId]; !ok {
+			missingDrives = append(missingDrives, record.DriveId)
+		}
+	}
+	if len(missingDrives) > 0 {
+		fetched, err := vaccineInventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("id IN (%s)", joinIds(missingDrives)))
+		if err != nil {
+			logger.Error("error fetching drives for export", logging.Err(err))
+			return data, errors.New("unable to export please try again later")
+		}
+		for _, drive := range fetched {
+			data.Drives[drive.Id] = drive
+		}
+	}
+	vaccineIds := []int{}
+	for _, drive := range data.Drives {
+		vaccineIds = append(vaccineIds, drive.VaccineId)
+	}
+	if len(vaccineIds) > 0 {
+		catalog, err := vaccineCatalogRepo.GetVaccines(ctx, fmt.Sprintf("id IN (%s)", joinIds(vaccineIds)), requests.Pagination{})
+		if err != nil {
+			logger.Error("error fetching vaccines for export", logging.Err(err))
+			return data, errors.New("unable to export please try again later")
+		}
+		for _, vaccine := range catalog {
+			data.Vaccines[vaccine.Id] = vaccine
+		}
+	}
+	for _, student := range students {
+		if len(driveConditions) > 0 && !referenced[student.Id] {
+			continue
+		}
+		data.Students = append(data.Students, student)
+	}
+	return data, nil
+}
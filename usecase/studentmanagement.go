@@ -1,25 +1,34 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"school_vaccination_portal/config"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/repository"
 	"school_vaccination_portal/requests"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
 type StudentManagementUsecaseHandler interface {
-	CreateStudentRecords(records *[]models.StudentManagement) []models.DBInsertionRecord
-	UpdateStudentRecord(records models.StudentManagement) (models.StudentManagement, error)
-	GetVaccinationDashBoardData() (int, int, error)
-	CreateVaccinationRecords(records *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord
-	GetStudentVaccinationRecords(request *requests.GetStudentVaccinationRecordRequest) (int, []models.GetStudentCompleteDetails, error)
-	GenerateVaccinationReport(request *requests.GenerateReportRequest) (string, error)
+	CreateStudentRecords(ctx context.Context, records *[]models.StudentManagement) []models.DBInsertionRecord
+	UpdateStudentRecord(ctx context.Context, records models.StudentManagement) (models.StudentManagement, error)
+	GetVaccinationDashBoardData(ctx context.Context, vaccineName string, excludeExempt bool) (int, int, int, error)
+	CreateVaccinationRecords(ctx context.Context, records *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord
+	GetStudentVaccinationRecords(ctx context.Context, request *requests.GetStudentVaccinationRecordRequest) (int, []models.GetStudentCompleteDetails, error)
+	GenerateVaccinationReport(ctx context.Context, request *requests.GenerateReportRequest) (string, error)
+	VoidVaccinationRecord(ctx context.Context, id int, reason string) (models.StudentVaccineRecord, error)
+	CorrectVaccinationRecord(ctx context.Context, id int, reason string, record models.StudentVaccineRecord) models.VaccineInsertionDBRecord
+	GetStudentVaccinationHistory(ctx context.Context, studentId int) ([]models.StudentVaccineRecord, error)
 }
 
 type StudentManagementUsecase struct {
@@ -27,102 +36,520 @@ type StudentManagementUsecase struct {
 	studentVaccinationRecordRepo repository.StudentVaccinationRecordRepositoryHandler
 	bulkFileJobsRepo             repository.BulkFileJobsRepositoryHandler
 	vaccineInventoryRepo         repository.VaccineInventoryHandler
+	vaccineCatalogRepo           repository.VaccineCatalogRepositoryHandler
+	consentRepo                  repository.ConsentRepositoryHandler
+	exemptionRepo                repository.ExemptionRepositoryHandler
+	notificationUsecase          NotificationUsecaseHandler
+	unitOfWork                   repository.UnitOfWorkHandler
+	config                       *config.Config
+	logger                       *slog.Logger
 }
 
-func (u *StudentManagementUsecase) UpdateStudentRecord(records models.StudentManagement) (models.StudentManagement, error) {
-	var err error
-	//verify if student records with given entry exists????
-	studentData, err := u.studentManagementRepo.GetStudents(fmt.Sprintf("id = %d", records.Id))
-	if err != nil {
-		log.Println(fmt.Sprintf("observing error in getting student record with id %d", records.Id), err.Error())
-		return records, err
-	}
-	if len(studentData) == 0 || studentData[0].Id != records.Id {
-		return records, fmt.Errorf("student record with id %d is not available ====>>>>", records.Id)
+// exemptUnvaccinatedFilter matches unvaccinated students holding an exemption in force from each of the vaccines
+// reported on. With no vaccine given the student has to be exempt from every vaccine in the catalog, an exemption
+// from one vaccine does not take a student out of the coverage of another.
+func exemptUnvaccinatedFilter(vaccineIds []int) string {
+	vaccines := ""
+	if len(vaccineIds) > 0 {
+		vaccines = fmt.Sprintf("c.id IN (%s) AND ", joinIds(vaccineIds))
 	}
-	if err = u.studentManagementRepo.UpdateStudents(records); err != nil {
-		log.Println("record update failed")
-		return records, err
-	}
-	studentData, err = u.studentManagementRepo.GetStudents(fmt.Sprintf("id = %d", records.Id))
-	return studentData[0], err
+	return fmt.Sprintf("v.id IS NULL AND s.id IN (SELECT e.student_id FROM exemptions e WHERE %[1]s) AND NOT EXISTS (SELECT c.id FROM vaccines c WHERE %[2]sc.id NOT IN (SELECT e.vaccine_id FROM exemptions e WHERE e.student_id = s.id AND %[1]s))", repository.ACTIVE_EXEMPTION_FILTER, vaccines)
+}
+
+// the check, the update and the read back run in one transaction, so the record returned is the one written
+func (u *StudentManagementUsecase) UpdateStudentRecord(ctx context.Context, records models.StudentManagement) (models.StudentManagement, error) {
+	updated := records
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		//verify if student records with given entry exists????
+		studentData, err := u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id = %d", records.Id))
+		if err != nil {
+			u.logger.Error("error fetching student record", "student_id", records.Id, logging.Err(err))
+			return err
+		}
+		if len(studentData) == 0 || studentData[0].Id != records.Id {
+			return fmt.Errorf("student record with id %d is not available ====>>>>", records.Id)
+		}
+		if err = u.studentManagementRepo.UpdateStudents(ctx, records); err != nil {
+			u.logger.Error("student record update failed", "student_id", records.Id, logging.Err(err))
+			return err
+		}
+		studentData, err = u.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id = %d", records.Id))
+		if err != nil || len(studentData) == 0 {
+			return err
+		}
+		updated = studentData[0]
+		return nil
+	})
+	return updated, err
 }
-func (u *StudentManagementUsecase) CreateStudentRecords(records *[]models.StudentManagement) []models.DBInsertionRecord {
-	return u.studentManagementRepo.CreateStudentRecord(records)
+func (u *StudentManagementUsecase) CreateStudentRecords(ctx context.Context, records *[]models.StudentManagement) []models.DBInsertionRecord {
+	return u.studentManagementRepo.CreateStudentRecord(ctx, records)
 }
 
-func (u *StudentManagementUsecase) GetVaccinationDashBoardData() (int, int, error) {
-	totalStudents, err := u.studentVaccinationRecordRepo.GetStudentVaccinationRecordCount("", "LEFT JOIN student_vaccination_records v ON s.id = v.student_id")
+// exempt students can be left out of the total so coverage is measured only against students who should be vaccinated.
+// With a vaccine name the coverage is of that vaccine, and only exemptions from it count.
+func (u *StudentManagementUsecase) GetVaccinationDashBoardData(ctx context.Context, vaccineName string, excludeExempt bool) (int, int, int, error) {
+	recordJoin := "student_vaccination_records v ON s.id = v.student_id AND v.status = 'ACTIVE'"
+	vaccineIds := []int{}
+	if vaccineName != "" {
+		drives, err := u.verifyDriveExists(ctx, 0, vaccineName)
+		if err != nil {
+			u.logger.Error("error fetching drives of vaccine", "vaccine_name", vaccineName, logging.Err(err))
+			return 0, 0, 0, err
+		}
+		if len(drives) == 0 {
+			return 0, 0, 0, fmt.Errorf("no vaccination drive with vaccine : %s", vaccineName)
+		}
+		driveIds := make([]int, len(drives))
+		for i, d := range drives {
+			driveIds[i] = d.Id
+			vaccineIds = append(vaccineIds, d.VaccineId)
+		}
+		recordJoin += fmt.Sprintf(" AND v.drive_id IN (%s)", joinIds(driveIds))
+	}
+	totalStudents, err := u.studentVaccinationRecordRepo.GetStudentCount(ctx, "", "LEFT JOIN "+recordJoin)
 	if err != nil {
-		log.Println("error fetching vaccination record", err.Error())
-		return totalStudents, 0, err
+		u.logger.Error("error fetching vaccination record", logging.Err(err))
+		return totalStudents, 0, 0, err
 	}
-	vaccnatedStudents, err := u.studentVaccinationRecordRepo.GetStudentVaccinationRecordCount("", "INNER JOIN student_vaccination_records v ON s.id = v.student_id")
+	vaccnatedStudents, err := u.studentVaccinationRecordRepo.GetStudentCount(ctx, "", "INNER JOIN "+recordJoin)
 	if err != nil {
-		log.Println("error fetching vaccination record", err.Error())
+		u.logger.Error("error fetching vaccination record", logging.Err(err))
+		return totalStudents, vaccnatedStudents, 0, err
 	}
-	return totalStudents, vaccnatedStudents, err
-
+	exemptStudents, err := u.studentVaccinationRecordRepo.GetStudentCount(ctx, exemptUnvaccinatedFilter(vaccineIds), "LEFT JOIN "+recordJoin)
+	if err != nil {
+		u.logger.Error("error fetching exempt students", logging.Err(err))
+		return totalStudents, vaccnatedStudents, exemptStudents, err
+	}
+	if excludeExempt {
+		totalStudents -= exemptStudents
+	}
+	return totalStudents, vaccnatedStudents, exemptStudents, nil
 }
 
-func (v *StudentManagementUsecase) CreateVaccinationRecords(records *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord {
-	//verify if drive actually exists
+func (v *StudentManagementUsecase) CreateVaccinationRecords(ctx context.Context, records *[]models.StudentVaccineRecord) []models.VaccineInsertionDBRecord {
 	validRecords := new([]models.StudentVaccineRecord)
 	inValidRecords := []models.VaccineInsertionDBRecord{}
+	batch := newRecordBatch()
 	for _, j := range *records {
-		//checking if drive exists
-		driveData, err := v.verifyDriveExists(j.DriveId, "")
-		log.Println("drive Data ", driveData, err == nil)
-		if err != nil || len(driveData) == 0 {
+		if err := v.validateVaccinationRecord(ctx, &j, batch); err != nil {
 			invalid := models.VaccineInsertionDBRecord{
 				Record:      j,
 				Status:      false,
-				ErrorReason: fmt.Sprintf("no drive exists with drive_id : %d", j.DriveId),
+				ErrorReason: err.Error(),
 			}
-			log.Println("invalid due to wrong drive id", invalid)
-			inValidRecords = append(inValidRecords, invalid)
-			continue
-		}
-		//check if student is valid
-		resp, _ := v.studentManagementRepo.GetStudents(fmt.Sprintf("id = %d", j.StudentId))
-		if len(resp) != 1 {
-			invalid := models.VaccineInsertionDBRecord{
-				Record:      j,
-				Status:      false,
-				ErrorReason: fmt.Sprintf("no student exists with student_id : %d", j.StudentId),
-			}
-			log.Println("invalid due to wrong student id", invalid)
+			v.logger.Warn("invalid vaccination record", "student_id", j.StudentId, "drive_id", j.DriveId, "reason", invalid.ErrorReason)
 			inValidRecords = append(inValidRecords, invalid)
 			continue
 		}
 		*validRecords = append(*validRecords, j)
 	}
 	//proceed for insertion
-	log.Println("Valid Records", validRecords, "invalidRecords", inValidRecords)
-	resp := v.studentVaccinationRecordRepo.CreateVaccinationRecord(validRecords)
+	v.logger.Info("vaccination records validated", "valid", len(*validRecords), "invalid", len(inValidRecords))
+	resp := v.studentVaccinationRecordRepo.CreateVaccinationRecord(ctx, validRecords)
+	//let guardians know about the doses that were recorded
+	created := []models.StudentVaccineRecord{}
+	for _, r := range resp {
+		if r.Status {
+			created = append(created, r.Record)
+		}
+	}
+	v.logger.Info("vaccination confirmations queued", "count", v.notificationUsecase.QueueVaccinationConfirmations(ctx, created))
 	return append(resp, inValidRecords...)
 }
 
-func (v *StudentManagementUsecase) verifyDriveExists(id int, name string) ([]models.VaccineInventory, error) {
-	var selectionString string
+func (v *StudentManagementUsecase) VoidVaccinationRecord(ctx context.Context, id int, reason string) (models.StudentVaccineRecord, error) {
+	if err := v.studentVaccinationRecordRepo.VoidVaccinationRecord(ctx, id, reason); err != nil {
+		v.logger.Error("unable to void vaccination record", logging.Err(err))
+		return models.StudentVaccineRecord{Id: id}, err
+	}
+	records, err := v.studentVaccinationRecordRepo.GetVaccinationRecords(ctx, fmt.Sprintf("id = %d", id))
+	if err != nil || len(records) == 0 {
+		return models.StudentVaccineRecord{Id: id}, err
+	}
+	return records[0], nil
+}
+
+// a correction goes through the same checks as a new record, with the record it replaces left out of the dose history
+func (v *StudentManagementUsecase) CorrectVaccinationRecord(ctx context.Context, id int, reason string, record models.StudentVaccineRecord) models.VaccineInsertionDBRecord {
+	result := models.VaccineInsertionDBRecord{Record: record}
+	existing, err := v.studentVaccinationRecordRepo.GetVaccinationRecords(ctx, fmt.Sprintf("id = %d", id))
+	if err != nil {
+		v.logger.Error("unable to fetch vaccination record", logging.Err(err))
+		result.ErrorReason = "unable to fetch vaccination record, please try again later"
+		return result
+	}
+	if len(existing) == 0 || existing[0].Status != models.RECORD_ACTIVE {
+		result.ErrorReason = fmt.Sprintf("no active vaccination record with id %d", id)
+		return result
+	}
+	batch := newRecordBatch()
+	batch.replacing = existing[0]
+	if err = v.validateVaccinationRecord(ctx, &record, batch); err != nil {
+		result.Record = record
+		result.ErrorReason = err.Error()
+		return result
+	}
+	if err = v.studentVaccinationRecordRepo.SupersedeVaccinationRecord(ctx, id, reason, &record); err != nil {
+		v.logger.Error("unable to correct vaccination record", logging.Err(err))
+		result.Record = record
+		result.ErrorReason = err.Error()
+		return result
+	}
+	result.Record = record
+	result.Status = true
+	return result
+}
+
+// GetStudentVaccinationHistory returns every record of the student, voided and superseded ones included
+func (v *StudentManagementUsecase) GetStudentVaccinationHistory(ctx context.Context, studentId int) ([]models.StudentVaccineRecord, error) {
+	students, err := v.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id = %d", studentId))
+	if err != nil {
+		v.logger.Error("error fetching student", logging.Err(err))
+		return nil, err
+	}
+	if len(students) == 0 {
+		return nil, fmt.Errorf("no student exists with student_id : %d", studentId)
+	}
+	return v.studentVaccinationRecordRepo.GetVaccinationRecords(ctx, fmt.Sprintf("student_id = %d", studentId))
+}
+
+// recordBatch carries what earlier rows of the same request have claimed, so rows are checked against each other as well as the DB
+type recordBatch struct {
+	seenPairs       map[string]bool
+	batchDoses      map[string][]int
+	vaccineRegister map[int]models.Vaccine
+	replacing       models.StudentVaccineRecord
+}
+
+func newRecordBatch() *recordBatch {
+	return &recordBatch{
+		seenPairs:       map[string]bool{},
+		batchDoses:      map[string][]int{},
+		vaccineRegister: make(map[int]models.Vaccine),
+	}
+}
+
+func (v *StudentManagementUsecase) validateVaccinationRecord(ctx context.Context, j *models.StudentVaccineRecord, batch *recordBatch) error {
+	//check if the same student and drive repeat within this request
+	pairKey := fmt.Sprintf("%d-%d", j.StudentId, j.DriveId)
+	if batch.seenPairs[pairKey] {
+		return fmt.Errorf("student_id : %d and drive_id : %d are repeated in this request", j.StudentId, j.DriveId)
+	}
+	batch.seenPairs[pairKey] = true
+	//checking if drive exists
+	driveData, err := v.verifyDriveExists(ctx, j.DriveId, "")
+	if err != nil || len(driveData) == 0 {
+		return fmt.Errorf("no drive exists with drive_id : %d", j.DriveId)
+	}
+	//check if drive is open for recording, a correction within the same drive reuses the dose it gives back
+	if err = driveAcceptsVaccinations(driveData[0]); err != nil {
+		return err
+	}
+	if driveData[0].RemainingDoses() <= 0 && driveData[0].Id != batch.replacing.DriveId {
+		return fmt.Errorf("no doses remaining for drive_id : %d", j.DriveId)
+	}
+	//check if student is valid
+	resp, _ := v.studentManagementRepo.GetStudents(ctx, fmt.Sprintf("id = %d", j.StudentId))
+	if len(resp) != 1 {
+		return fmt.Errorf("no student exists with student_id : %d", j.StudentId)
+	}
+	//check if student's class is covered by the drive
+	if !driveData[0].Classes.Contains(resp[0].Class) {
+		return fmt.Errorf("student_id : %d in %s is not eligible for drive_id : %d, eligible classes: %s", j.StudentId, resp[0].Class, j.DriveId, strings.Join(driveData[0].Classes, ", "))
+	}
+	//check the guardian has granted consent for this drive
+	if err = v.checkConsent(ctx, j.StudentId, driveData[0]); err != nil {
+		return err
+	}
+	//check administration details against the drive
+	if err = checkAdministrationDetails(j, driveData[0]); err != nil {
+		return err
+	}
+	//check the student's earlier doses of this vaccine
+	return v.assignDoseNumber(ctx, j, driveData[0], batch)
+}
+
+func (v *StudentManagementUsecase) verifyDriveExists(ctx context.Context, id int, name string) ([]models.VaccineInventory, error) {
 	if id != 0 {
-		selectionString = fmt.Sprintf("id = %d", id)
-	} else {
-		selectionString = fmt.Sprintf("vaccine_name = '%s'", name)
+		return v.vaccineInventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("id = %d", id))
+	}
+	return v.vaccineInventoryRepo.GetVaccineInventory(ctx, "vaccine_id IN (SELECT id FROM vaccines WHERE name = ?)", strings.TrimSpace(name))
+
+}
+
+// a student is only vaccinated when the consent in force for the drive, or for its vaccine, is granted
+func (v *StudentManagementUsecase) checkConsent(ctx context.Context, studentId int, drive models.VaccineInventory) error {
+	consent, err := v.consentRepo.GetEffectiveConsent(ctx, studentId, drive.Id, drive.VaccineId)
+	if err != nil {
+		v.logger.Error("error fetching consent", logging.Err(err))
+		return errors.New("unable to verify consent please try again later")
+	}
+	status := models.CONSENT_NO_RESPONSE
+	if len(consent) != 0 {
+		status = consent[0].Status
+	}
+	if status != models.CONSENT_GRANTED {
+		return fmt.Errorf("student_id : %d has no granted consent for drive_id : %d (status %s)", studentId, drive.Id, status)
+	}
+	return nil
+}
+
+// vaccinations can only be recorded against drives that have started and were not called off
+func driveAcceptsVaccinations(drive models.VaccineInventory) error {
+	switch drive.Status {
+	case models.DRIVE_CANCELLED:
+		return fmt.Errorf("drive %d is cancelled, vaccinations cannot be recorded", drive.Id)
+	case models.DRIVE_POSTPONED:
+		return fmt.Errorf("drive %d is postponed, vaccinations cannot be recorded", drive.Id)
+	}
+	if isFutureDrive(drive) {
+		return fmt.Errorf("drive %d is scheduled on %s, vaccinations cannot be recorded before the drive date", drive.Id, drive.DriveDate.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// administration must happen during the drive, not in the future, and with a lot that has not expired
+func checkAdministrationDetails(record *models.StudentVaccineRecord, drive models.VaccineInventory) error {
+	now := time.Now()
+	if record.AdministeredAt == nil {
+		record.AdministeredAt = &now
+	}
+	if record.AdministeredAt.After(now) {
+		return fmt.Errorf("administered_at %s is in the future", record.AdministeredAt.Format("2006-01-02 15:04"))
+	}
+	if record.AdministeredAt.Before(drive.DriveDate.UTC().Truncate(24 * time.Hour)) {
+		return fmt.Errorf("administered_at %s is before drive_id : %d on %s", record.AdministeredAt.Format("2006-01-02 15:04"), drive.Id, drive.DriveDate.Format("2006-01-02"))
+	}
+	if record.ExpiryDate != nil && record.ExpiryDate.Before(record.AdministeredAt.UTC().Truncate(24*time.Hour)) {
+		return fmt.Errorf("lot %s expired on %s, before it was administered", record.LotNumber, record.ExpiryDate.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// sets the record's vaccine and dose number from the student's earlier doses, rejecting a repeat
+// of the same drive, a dose given before the vaccine's minimum interval, and doses past the series
+// unless the duplicate policy allows boosters
+func (v *StudentManagementUsecase) assignDoseNumber(ctx context.Context, record *models.StudentVaccineRecord, drive models.VaccineInventory, batch *recordBatch) error {
+	vaccine, err := v.getVaccine(ctx, drive.VaccineId, batch.vaccineRegister)
+	if err != nil {
+		return fmt.Errorf("no vaccine in catalog for drive_id : %d", drive.Id)
+	}
+	record.VaccineId = vaccine.Id
+	doses, err := v.studentVaccinationRecordRepo.GetStudentDoseHistory(ctx, record.StudentId, vaccine.Id)
+	if err != nil {
+		v.logger.Error("unable to fetch dose history", logging.Err(err))
+		return errors.New("unable to verify earlier doses, please try again later")
+	}
+	//the record being corrected no longer counts once it is superseded
+	history := []models.StudentDoseHistory{}
+	for _, dose := range doses {
+		if dose.Id != batch.replacing.Id {
+			history = append(history, dose)
+		}
+	}
+	for _, dose := range history {
+		if dose.DriveId == drive.Id {
+			return fmt.Errorf("student_id : %d is already vaccinated in drive_id : %d", record.StudentId, drive.Id)
+		}
+	}
+	//a correction of the same vaccine takes the place of the dose it replaces, later doses keep their numbers
+	if batch.replacing.Id != 0 && batch.replacing.VaccineId == vaccine.Id {
+		doseNumber := batch.replacing.DoseNumber
+		if record.DoseNumber != 0 && record.DoseNumber != doseNumber {
+			return fmt.Errorf("dose_number %d does not match the corrected dose of %s, expected %d", record.DoseNumber, vaccine.Name, doseNumber)
+		}
+		if err = checkDoseIntervals(record, history, vaccine, doseNumber); err != nil {
+			return err
+		}
+		record.DoseNumber = doseNumber
+		return nil
+	}
+	doseKey := fmt.Sprintf("%d-%d", record.StudentId, vaccine.Id)
+	doseNumber := nextDoseNumber(history, batch.batchDoses[doseKey])
+	if doseNumber > vaccine.DosesInSeries && v.duplicatePolicy() != models.DUPLICATE_POLICY_BOOSTER {
+		return fmt.Errorf("student_id : %d already received all %d dose(s) of %s", record.StudentId, vaccine.DosesInSeries, vaccine.Name)
+	}
+	if record.DoseNumber != 0 && record.DoseNumber != doseNumber {
+		return fmt.Errorf("dose_number %d does not match the student's next dose of %s, expected %d", record.DoseNumber, vaccine.Name, doseNumber)
+	}
+	if err = checkDoseIntervals(record, history, vaccine, doseNumber); err != nil {
+		return err
+	}
+	record.DoseNumber = doseNumber
+	batch.batchDoses[doseKey] = append(batch.batchDoses[doseKey], doseNumber)
+	return nil
+}
+
+// nextDoseNumber is the lowest dose number not held by an active dose or one recorded earlier in the batch,
+// so the slot of a voided dose is filled again before the series moves on
+func nextDoseNumber(history []models.StudentDoseHistory, batchDoses []int) int {
+	taken := map[int]bool{}
+	for _, dose := range history {
+		taken[dose.DoseNumber] = true
+	}
+	for _, doseNumber := range batchDoses {
+		taken[doseNumber] = true
+	}
+	doseNumber := 1
+	for taken[doseNumber] {
+		doseNumber++
+	}
+	return doseNumber
+}
+
+// checkDoseIntervals compares the dose being recorded as doseNumber with the active doses numbered just
+// before and just after it, history is ordered by dose number
+func checkDoseIntervals(record *models.StudentVaccineRecord, history []models.StudentDoseHistory, vaccine models.Vaccine, doseNumber int) error {
+	for i, dose := range history {
+		if dose.DoseNumber < doseNumber && (i+1 == len(history) || history[i+1].DoseNumber > doseNumber) {
+			if err := checkDoseInterval(record, dose, vaccine, dose.AdministeredAt, *record.AdministeredAt); err != nil {
+				return err
+			}
+		}
+		if dose.DoseNumber > doseNumber {
+			return checkDoseInterval(record, dose, vaccine, *record.AdministeredAt, dose.AdministeredAt)
+		}
+	}
+	return nil
+}
+
+// two doses of a series must be at least the vaccine's minimum interval apart, earlier and later are the two dates in order
+func checkDoseInterval(record *models.StudentVaccineRecord, dose models.StudentDoseHistory, vaccine models.Vaccine, earlier, later time.Time) error {
+	if vaccine.MinIntervalDays <= 0 || later.Sub(earlier) >= time.Duration(vaccine.MinIntervalDays)*24*time.Hour {
+		return nil
+	}
+	return fmt.Errorf("student_id : %d received dose %d of %s on %s, doses must be %d days apart", record.StudentId, dose.DoseNumber, vaccine.Name, dose.AdministeredAt.Format("2006-01-02"), vaccine.MinIntervalDays)
+}
+
+// duplicatePolicy decides whether doses beyond a vaccine's series are rejected or recorded as boosters
+func (v *StudentManagementUsecase) duplicatePolicy() string {
+	if strings.ToUpper(v.config.Vaccination.DuplicatePolicy) == models.DUPLICATE_POLICY_BOOSTER {
+		return models.DUPLICATE_POLICY_BOOSTER
+	}
+	return models.DUPLICATE_POLICY_REJECT
+}
+
+func (v *StudentManagementUsecase) getVaccine(ctx context.Context, id int, vaccineRegister map[int]models.Vaccine) (models.Vaccine, error) {
+	if vaccine, ok := vaccineRegister[id]; ok {
+		return vaccine, nil
+	}
+	vaccines, err := v.vaccineCatalogRepo.GetVaccines(ctx, fmt.Sprintf("id = %d", id), requests.Pagination{})
+	if err != nil {
+		return models.Vaccine{}, err
+	}
+	if len(vaccines) == 0 {
+		return models.Vaccine{}, fmt.Errorf("no vaccine exists with id %d", id)
+	}
+	vaccineRegister[id] = vaccines[0]
+	return vaccines[0], nil
+}
+
+// marks whether the student has completed the vaccine's dose series or is only partially protected
+func (v *StudentManagementUsecase) applySeriesProgress(ctx context.Context, studentDetail *models.GetStudentCompleteDetails, drive models.VaccineInventory, vaccineRegister map[int]models.Vaccine) {
+	if drive.VaccineId == 0 {
+		return
+	}
+	vaccine, err := v.getVaccine(ctx, drive.VaccineId, vaccineRegister)
+	if err != nil {
+		v.logger.Error("unable to fetch vaccine for drive", "drive_id", drive.Id, logging.Err(err))
+		return
+	}
+	doses, err := v.studentVaccinationRecordRepo.GetStudentDoseCount(ctx, studentDetail.Id, vaccine.Id)
+	if err != nil {
+		v.logger.Error("unable to fetch dose count", logging.Err(err))
+		return
 	}
+	studentDetail.DosesTaken = doses
+	studentDetail.SeriesDoses = vaccine.DosesInSeries
+	studentDetail.Protection = models.FULLY_PROTECTED
+	if doses < vaccine.DosesInSeries {
+		studentDetail.Protection = models.PARTIALLY_PROTECTED
+	}
+}
 
-	return v.vaccineInventoryRepo.GetVaccineInventory(selectionString)
+// loads the exemptions still in force for the students being listed, keyed by student
+func (v *StudentManagementUsecase) getActiveExemptions(ctx context.Context, details []models.StudentVaccinationDetail) map[int][]models.Exemption {
+	register := map[int][]models.Exemption{}
+	if len(details) == 0 {
+		return register
+	}
+	ids := make([]string, len(details))
+	for i, j := range details {
+		ids[i] = fmt.Sprintf("%d", j.Id)
+	}
+	exemptions, err := v.exemptionRepo.GetExemptions(ctx, fmt.Sprintf("e.student_id IN (%s) AND %s", strings.Join(ids, ", "), repository.ACTIVE_EXEMPTION_FILTER), requests.Pagination{})
+	if err != nil {
+		v.logger.Error("unable to fetch exemptions", logging.Err(err))
+		return register
+	}
+	for _, e := range exemptions {
+		register[e.StudentId] = append(register[e.StudentId], e)
+	}
+	return register
+}
 
+// the vaccines a listing reports on, those of the drives it was filtered to or else every vaccine in the catalog
+func (v *StudentManagementUsecase) reportedVaccines(ctx context.Context, driveRegister map[int]models.VaccineInventory) (map[int]bool, error) {
+	vaccines := map[int]bool{}
+	if len(driveRegister) != 0 {
+		for _, d := range driveRegister {
+			vaccines[d.VaccineId] = true
+		}
+		return vaccines, nil
+	}
+	catalog, err := v.vaccineCatalogRepo.GetVaccines(ctx, "", requests.Pagination{})
+	if err != nil {
+		v.logger.Error("unable to fetch vaccines", logging.Err(err))
+		return vaccines, err
+	}
+	for _, c := range catalog {
+		vaccines[c.Id] = true
+	}
+	return vaccines, nil
+}
+
+func vaccineIdList(vaccines map[int]bool) []int {
+	ids := make([]int, 0, len(vaccines))
+	for id := range vaccines {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
 }
 
-func (v *StudentManagementUsecase) GetStudentVaccinationRecords(request *requests.GetStudentVaccinationRecordRequest) (int, []models.GetStudentCompleteDetails, error) {
+// an unvaccinated student exempt from every vaccine reported on is reported as exempt rather than non vaccinated,
+// exemptions from other vaccines are left out and fewer than all of them are only listed
+func applyExemptions(studentDetail *models.GetStudentCompleteDetails, exemptions []models.Exemption, vaccines map[int]bool) {
+	exemptFrom := []string{}
+	covered := map[int]bool{}
+	for _, e := range exemptions {
+		if !vaccines[e.VaccineId] {
+			continue
+		}
+		covered[e.VaccineId] = true
+		exemptFrom = append(exemptFrom, fmt.Sprintf("%s - %s", e.VaccineName, strings.ToLower(e.ExemptionType)))
+	}
+	if len(exemptFrom) == 0 {
+		return
+	}
+	studentDetail.ExemptFrom = strings.Join(exemptFrom, ", ")
+	if len(covered) == len(vaccines) {
+		studentDetail.Exempt = true
+		studentDetail.Protection = models.EXEMPT
+	}
+}
+
+func (v *StudentManagementUsecase) GetStudentVaccinationRecords(ctx context.Context, request *requests.GetStudentVaccinationRecordRequest) (int, []models.GetStudentCompleteDetails, error) {
 	var studentDetails []models.GetStudentCompleteDetails
 	var vaccinationDetails []models.StudentVaccinationDetail
 	var err error
 	var total int
 	driveRegister := make(map[int]models.VaccineInventory)
+	vaccineRegister := make(map[int]models.Vaccine)
 
-	joinCondtion := "LEFT JOIN student_vaccination_records v ON s.id = v.student_id"
+	joinCondtion := "LEFT JOIN student_vaccination_records v ON s.id = v.student_id AND v.status = 'ACTIVE'"
 	queryString := ""
 
 	//if id is given
@@ -153,15 +580,15 @@ func (v *StudentManagementUsecase) GetStudentVaccinationRecords(request *request
 	if request.VaccineName != "" {
 		//get drive info or id by vaccine name
 		// var driveInfo VaccineDriveResponse
-		drive, err := v.verifyDriveExists(0, request.VaccineName)
+		drive, err := v.verifyDriveExists(ctx, 0, request.VaccineName)
 		if err != nil {
-			log.Println("error fetching vaccination record", err.Error())
+			v.logger.Error("error fetching vaccination record", logging.Err(err))
 			return total, studentDetails, err
 		}
 		for _, j := range drive {
 			driveRegister[j.Id] = j
 		}
-		log.Printf("Drive info is as below %+v", driveRegister)
+		v.logger.Debug("drives matched vaccine", "vaccine_name", request.VaccineName, "drives", len(driveRegister))
 		if len(driveRegister) == 0 {
 			return total, studentDetails, fmt.Errorf("no vaccination drive with vaccine : %s", request.VaccineName)
 		}
@@ -174,7 +601,6 @@ func (v *StudentManagementUsecase) GetStudentVaccinationRecords(request *request
 			placeholders[i] = fmt.Sprintf("%d", id)
 		}
 		inClause := strings.Join(placeholders, ", ")
-		log.Printf("student_vaccination_records.drive_id IN (%s)", inClause)
 		//get count
 		if queryString == "" {
 			queryString = fmt.Sprintf("v.drive_id IN (%s)", inClause)
@@ -184,18 +610,30 @@ func (v *StudentManagementUsecase) GetStudentVaccinationRecords(request *request
 		}
 		//all record scenario
 	}
-	log.Println("query string being used", queryString)
-	total, err = v.studentVaccinationRecordRepo.GetStudentVaccinationRecordCount(queryString, joinCondtion)
+	vaccines, err := v.reportedVaccines(ctx, driveRegister)
+	if err != nil {
+		return total, studentDetails, err
+	}
+	if request.ExcludeExempt {
+		if queryString == "" {
+			queryString = fmt.Sprintf("NOT (%s)", exemptUnvaccinatedFilter(vaccineIdList(vaccines)))
+		} else {
+			queryString += " AND " + fmt.Sprintf("NOT (%s)", exemptUnvaccinatedFilter(vaccineIdList(vaccines)))
+		}
+	}
+	v.logger.Debug("fetching student vaccination records", "filter", queryString)
+	total, err = v.studentVaccinationRecordRepo.GetStudentVaccinationRecordCount(ctx, queryString, joinCondtion)
 	if err != nil {
-		log.Println("error fetching vaccination record", err.Error())
+		v.logger.Error("error fetching vaccination record", logging.Err(err))
 		return total, studentDetails, err
 	}
-	vaccinationDetails, err = v.studentVaccinationRecordRepo.GetStudentVaccinationRecord(queryString, request.Pagination)
+	vaccinationDetails, err = v.studentVaccinationRecordRepo.GetStudentVaccinationRecord(ctx, queryString, request.Pagination)
 	if err != nil {
-		log.Println("error fetching vaccination record", err.Error())
+		v.logger.Error("error fetching vaccination record", logging.Err(err))
 		return total, studentDetails, err
 	}
 
+	exemptions := v.getActiveExemptions(ctx, vaccinationDetails)
 	//genrate consolidated Response
 	for _, j := range vaccinationDetails {
 		studentDetail := models.GetStudentCompleteDetails{}
@@ -207,41 +645,41 @@ func (v *StudentManagementUsecase) GetStudentVaccinationRecords(request *request
 		studentDetail.PhoneNo = j.PhoneNo
 		if j.DriveId == 0 {
 			studentDetail.Vaccination = false
+			applyExemptions(&studentDetail, exemptions[j.Id], vaccines)
 		} else {
 			studentDetail.Vaccination = true
-			drive, ok := driveRegister[j.Id]
+			drive, ok := driveRegister[j.DriveId]
 			if !ok {
 				var driveInfo []models.VaccineInventory
-				driveInfo, err = v.verifyDriveExists(j.DriveId, "")
-				if err != nil {
-					log.Println("error fetching vaccination record", err.Error())
+				driveInfo, err = v.verifyDriveExists(ctx, j.DriveId, "")
+				if err != nil || len(driveInfo) == 0 {
+					v.logger.Error("error fetching drive of vaccination record", "drive_id", j.DriveId, logging.Err(err))
 					continue
 				}
-				log.Printf("response from vaccine service %+v", driveInfo)
-				driveRegister[j.Id] = driveInfo[0]
+				driveRegister[j.DriveId] = driveInfo[0]
 				drive = driveInfo[0]
-				log.Printf(" drive is %+v drive info is %+v", drive, driveInfo)
 			}
-			log.Printf(" drive is %+v", drive)
 			studentDetail.VaccineName = drive.VaccineName
 			studentDetail.VaccineDate = drive.DriveDate.Format("2006-01-02")
+			v.applySeriesProgress(ctx, &studentDetail, drive, vaccineRegister)
 		}
 		studentDetails = append(studentDetails, studentDetail)
 	}
 	return total, studentDetails, err
 }
 
-func (v *StudentManagementUsecase) GenerateVaccinationReport(request *requests.GenerateReportRequest) (string, error) {
+func (v *StudentManagementUsecase) GenerateVaccinationReport(ctx context.Context, request *requests.GenerateReportRequest) (string, error) {
 	var studentDetails []models.GetStudentCompleteDetails
 	var vaccinationDetails []models.StudentVaccinationDetail
 	var err error
 	driveRegister := make(map[int]models.VaccineInventory)
+	vaccineRegister := make(map[int]models.Vaccine)
 	queryString := ""
 
 	if request.VaccineName != "" {
-		drive, err := v.verifyDriveExists(0, request.VaccineName)
+		drive, err := v.verifyDriveExists(ctx, 0, request.VaccineName)
 		if err != nil {
-			log.Println("error in getting vaccine name", err.Error())
+			v.logger.Error("error in getting vaccine name", logging.Err(err))
 			return "", err
 		}
 		if len(drive) == 0 {
@@ -250,13 +688,12 @@ func (v *StudentManagementUsecase) GenerateVaccinationReport(request *requests.G
 		for _, j := range drive {
 			driveRegister[j.Id] = j
 		}
-		log.Printf("Drive info is as below %+v", driveRegister)
+		v.logger.Debug("drives matched vaccine", "vaccine_name", request.VaccineName, "drives", len(driveRegister))
 		driveIds := []int{}
 		for i, _ := range driveRegister {
 			driveIds = append(driveIds, i)
 		}
 		placeholders := make([]string, len(driveIds))
-		fmt.Println(driveIds)
 		for i, id := range driveIds {
 			placeholders[i] = fmt.Sprintf("%d", id)
 		}
@@ -271,13 +708,26 @@ func (v *StudentManagementUsecase) GenerateVaccinationReport(request *requests.G
 			queryString = query
 		}
 	}
-	log.Println("query being used", queryString)
-	vaccinationDetails, err = v.studentVaccinationRecordRepo.GetStudentVaccinationRecord(queryString, requests.Pagination{})
+	vaccines, err := v.reportedVaccines(ctx, driveRegister)
+	if err != nil {
+		return "", err
+	}
+	if request.ExcludeExempt {
+		query := fmt.Sprintf("NOT (%s)", exemptUnvaccinatedFilter(vaccineIdList(vaccines)))
+		if queryString != "" {
+			queryString += " AND " + query
+		} else {
+			queryString = query
+		}
+	}
+	v.logger.Debug("fetching report records", "filter", queryString)
+	vaccinationDetails, err = v.studentVaccinationRecordRepo.GetStudentVaccinationRecord(ctx, queryString, requests.Pagination{})
 	if err != nil {
-		log.Println("error fetching vaccination record", err.Error())
+		v.logger.Error("error fetching vaccination record", logging.Err(err))
 		return "", err
 	}
-	log.Printf("details fetched %+v", vaccinationDetails)
+	v.logger.Debug("report records fetched", "count", len(vaccinationDetails))
+	exemptions := v.getActiveExemptions(ctx, vaccinationDetails)
 	for _, j := range vaccinationDetails {
 		studentDetail := models.GetStudentCompleteDetails{}
 		studentDetail.Id = j.Id
@@ -288,24 +738,23 @@ func (v *StudentManagementUsecase) GenerateVaccinationReport(request *requests.G
 		studentDetail.PhoneNo = j.PhoneNo
 		if j.DriveId == 0 {
 			studentDetail.Vaccination = false
+			applyExemptions(&studentDetail, exemptions[j.Id], vaccines)
 		} else {
 			studentDetail.Vaccination = true
-			drive, ok := driveRegister[j.Id]
+			drive, ok := driveRegister[j.DriveId]
 			if !ok {
 				var driveInfo []models.VaccineInventory
-				driveInfo, err = v.verifyDriveExists(j.DriveId, "")
-				if err != nil {
-					log.Println("error fetching vaccination record", err.Error())
+				driveInfo, err = v.verifyDriveExists(ctx, j.DriveId, "")
+				if err != nil || len(driveInfo) == 0 {
+					v.logger.Error("error fetching drive of vaccination record", "drive_id", j.DriveId, logging.Err(err))
 					continue
 				}
-				log.Printf("response from vaccine service %+v", driveInfo)
-				driveRegister[j.Id] = driveInfo[0]
+				driveRegister[j.DriveId] = driveInfo[0]
 				drive = driveInfo[0]
-				log.Printf(" drive is %+v drive info is %+v", drive, driveInfo)
 			}
-			log.Printf(" drive is %+v", drive)
 			studentDetail.VaccineName = drive.VaccineName
 			studentDetail.VaccineDate = drive.DriveDate.Format("2006-01-02")
+			v.applySeriesProgress(ctx, &studentDetail, drive, vaccineRegister)
 		}
 		studentDetails = append(studentDetails, studentDetail)
 	}
@@ -325,8 +774,14 @@ func (v *StudentManagementUsecase) GenerateVaccinationReport(request *requests.G
 		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("C%d", rowNum), student.Gender)
 		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("D%d", rowNum), student.RollNo)
 		reportFile.SetCellValue(reportShheetName, fmt.Sprintf("E%d", rowNum), student.PhoneNo)
-		if !student.Vaccination {
+		if student.Exempt {
+			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("F%d", rowNum), fmt.Sprintf("Exempt (%s)", student.ExemptFrom))
+		} else if !student.Vaccination {
 			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("F%d", rowNum), "Non Vaccinated")
+		} else if student.Protection == models.PARTIALLY_PROTECTED {
+			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("F%d", rowNum), fmt.Sprintf("Partially Vaccinated (%d/%d)", student.DosesTaken, student.SeriesDoses))
+			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("G%d", rowNum), student.VaccineName)
+			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("H%d", rowNum), student.VaccineDate)
 		} else {
 			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("F%d", rowNum), "Vaccinated")
 			reportFile.SetCellValue(reportShheetName, fmt.Sprintf("G%d", rowNum), student.VaccineName)
@@ -334,22 +789,22 @@ func (v *StudentManagementUsecase) GenerateVaccinationReport(request *requests.G
 		}
 	}
 	reportFile.SetActiveSheet(index)
-	reportFileName := "Report.xlsx"
-	err = reportFile.SaveAs(reportFileName)
+	reportFileName, err := saveReportFile(reportFile, "Report.xlsx")
 	if err != nil {
-		log.Println("Unable to save report File Locally", err.Error())
+		v.logger.Error("unable to save report File Locally", logging.Err(err))
 		return "", errors.New("Internal server Error")
 	}
-	uploadedReportFile, err := v.bulkFileJobsRepo.UploadFileToMinio(reportFileName, os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), "reports/", request.RequestId)
+	defer os.RemoveAll(filepath.Dir(reportFileName))
+	uploadedReportFile, err := v.bulkFileJobsRepo.UploadFileToMinio(ctx, reportFileName, v.config.Minio.Bucket, "reports/", request.RequestId)
 	if err != nil {
-		log.Println("error in uploading file to minio", err.Error())
+		v.logger.Error("error in uploading file to minio", logging.Err(err))
 		return "", err
 	}
-	filePath := fmt.Sprintf("http://%s:%s/%s/%s", os.Getenv("MINIO_SERVER"), os.Getenv("MINIO_PORT"), os.Getenv("MINIO_BULK_UPLOAD_BUCKET"), uploadedReportFile)
+	filePath := v.config.Minio.ObjectURL(uploadedReportFile)
 
 	return filePath, nil
 }
 
-func NewStudentManagementUsecaseHandler(studentRepo repository.StudentManagementRepositoryHandler, studentvaccinationrepo repository.StudentVaccinationRecordRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, vaccineinventoryRepo repository.VaccineInventoryHandler) StudentManagementUsecaseHandler {
-	return &StudentManagementUsecase{studentManagementRepo: studentRepo, studentVaccinationRecordRepo: studentvaccinationrepo, bulkFileJobsRepo: bulkfileJobsRepo, vaccineInventoryRepo: vaccineinventoryRepo}
+func NewStudentManagementUsecaseHandler(studentRepo repository.StudentManagementRepositoryHandler, studentvaccinationrepo repository.StudentVaccinationRecordRepositoryHandler, bulkfileJobsRepo repository.BulkFileJobsRepositoryHandler, vaccineinventoryRepo repository.VaccineInventoryHandler, vaccineCatalogRepo repository.VaccineCatalogRepositoryHandler, consentRepo repository.ConsentRepositoryHandler, exemptionRepo repository.ExemptionRepositoryHandler, notificationUsecase NotificationUsecaseHandler, unitOfWork repository.UnitOfWorkHandler, cfg *config.Config, logger *slog.Logger) StudentManagementUsecaseHandler {
+	return &StudentManagementUsecase{studentManagementRepo: studentRepo, studentVaccinationRecordRepo: studentvaccinationrepo, bulkFileJobsRepo: bulkfileJobsRepo, vaccineInventoryRepo: vaccineinventoryRepo, vaccineCatalogRepo: vaccineCatalogRepo, consentRepo: consentRepo, exemptionRepo: exemptionRepo, notificationUsecase: notificationUsecase, unitOfWork: unitOfWork, config: cfg, logger: logger}
 }
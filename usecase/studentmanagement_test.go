@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"school_vaccination_portal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2025, 1, d, 10, 0, 0, 0, time.UTC)
+}
+
+// the active history left after voiding some doses, ordered by dose number as the repository returns it
+func activeDoses(doses map[int]int) []models.StudentDoseHistory {
+	history := []models.StudentDoseHistory{}
+	for doseNumber := 1; doseNumber <= 5; doseNumber++ {
+		if d, ok := doses[doseNumber]; ok {
+			history = append(history, models.StudentDoseHistory{Id: doseNumber, DriveId: doseNumber, DoseNumber: doseNumber, AdministeredAt: day(d)})
+		}
+	}
+	return history
+}
+
+func TestRecordAfterVoid(t *testing.T) {
+	vaccine := models.Vaccine{Id: 2, Name: "HepB", DosesInSeries: 3, MinIntervalDays: 7}
+	cases := []struct {
+		name         string
+		active       map[int]int
+		batchDoses   []int
+		administered int
+		doseNumber   int
+		message      string
+	}{
+		{"first dose", nil, nil, 1, 1, ""},
+		{"next dose", map[int]int{1: 1}, nil, 10, 2, ""},
+		{"dose 1 voided, dose 2 kept", map[int]int{2: 20}, nil, 5, 1, ""},
+		{"dose 2 voided, doses 1 and 3 kept", map[int]int{1: 1, 3: 25}, nil, 12, 2, ""},
+		{"refill too close to the dose before", map[int]int{1: 1, 3: 25}, nil, 4, 2, "received dose 1"},
+		{"refill too close to the dose after", map[int]int{1: 1, 3: 25}, nil, 22, 2, "received dose 3"},
+		{"refill only checks its neighbours", map[int]int{1: 1, 3: 16, 4: 30}, nil, 8, 2, ""},
+		{"slot taken earlier in the batch", map[int]int{2: 20}, []int{1}, 29, 3, ""},
+		{"every dose voided", map[int]int{}, nil, 1, 1, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			history := activeDoses(c.active)
+			doseNumber := nextDoseNumber(history, c.batchDoses)
+			if doseNumber != c.doseNumber {
+				t.Fatalf("next dose number %d, expected %d", doseNumber, c.doseNumber)
+			}
+			administered := day(c.administered)
+			record := &models.StudentVaccineRecord{StudentId: 7, AdministeredAt: &administered}
+			err := checkDoseIntervals(record, history, vaccine, doseNumber)
+			if c.message == "" && err != nil {
+				t.Errorf("unexpected error %s", err.Error())
+			}
+			if c.message != "" && (err == nil || !strings.Contains(err.Error(), c.message)) {
+				t.Errorf("expected an error containing %q, got %v", c.message, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const MIN_PASSWORD_LENGTH = 12
+
+type UserUsecaseHandler interface {
+	CreateUser(ctx context.Context, user *models.User, password string) error
+}
+
+type UserUsecase struct {
+	repo   repository.UserRepositoryHandler
+	logger *slog.Logger
+}
+
+// stores the account with a bcrypt hash of the password, usernames are unique and case insensitive
+func (u *UserUsecase) CreateUser(ctx context.Context, user *models.User, password string) error {
+	user.Username = strings.ToLower(strings.TrimSpace(user.Username))
+	user.Role = strings.ToUpper(user.Role)
+	if user.Username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if user.Role != models.ROLE_ADMIN && user.Role != models.ROLE_STAFF {
+		return fmt.Errorf("role must be %s or %s", models.ROLE_ADMIN, models.ROLE_STAFF)
+	}
+	if len(password) < MIN_PASSWORD_LENGTH {
+		return fmt.Errorf("password must be at least %d characters", MIN_PASSWORD_LENGTH)
+	}
+	existing, err := u.repo.GetUserByUsername(ctx, user.Username)
+	if err != nil {
+		u.logger.Error("error fetching user", logging.Err(err))
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("user %s already exists with id %d", existing[0].Username, existing[0].Id)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("unable to hash password %s", err.Error())
+	}
+	user.PasswordHash = string(hash)
+	return u.repo.CreateUser(ctx, user)
+}
+
+func NewUserUsecaseHandler(repo repository.UserRepositoryHandler, logger *slog.Logger) UserUsecaseHandler {
+	return &UserUsecase{
+		repo:   repo,
+		logger: logger,
+	}
+}
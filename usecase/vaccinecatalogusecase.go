@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"school_vaccination_portal/logging"
+	"school_vaccination_portal/models"
+	"school_vaccination_portal/repository"
+	"school_vaccination_portal/requests"
+)
+
+type VaccineCatalogUsecaseHandler interface {
+	CreateVaccine(ctx context.Context, vaccine *models.Vaccine) error
+	GetVaccines(ctx context.Context, vaccine *models.Vaccine, pagination requests.Pagination) (int, []models.Vaccine, error)
+	UpdateVaccine(ctx context.Context, vaccine *requests.VaccineUpdateRequest) (models.Vaccine, error)
+	DeleteVaccine(ctx context.Context, id int) error
+}
+
+type VaccineCatalogUsecase struct {
+	repo          repository.VaccineCatalogRepositoryHandler
+	inventoryRepo repository.VaccineInventoryHandler
+	consentRepo   repository.ConsentRepositoryHandler
+	exemptionRepo repository.ExemptionRepositoryHandler
+	logger        *slog.Logger
+}
+
+func (v *VaccineCatalogUsecase) CreateVaccine(ctx context.Context, vaccine *models.Vaccine) error {
+	existing, err := v.repo.GetVaccineByName(ctx, vaccine.Name)
+	if err != nil {
+		v.logger.Error("error fetching vaccine", logging.Err(err))
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("vaccine %s already exists with id %d", existing[0].Name, existing[0].Id)
+	}
+	return v.repo.CreateVaccine(ctx, vaccine)
+}
+
+func (v *VaccineCatalogUsecase) GetVaccines(ctx context.Context, vaccine *models.Vaccine, pagination requests.Pagination) (int, []models.Vaccine, error) {
+	if vaccine.Id != 0 {
+		data, err := v.repo.GetVaccines(ctx, fmt.Sprintf("id = %d", vaccine.Id), requests.Pagination{})
+		return len(data), data, err
+	}
+	if vaccine.Name != "" {
+		data, err := v.repo.GetVaccineByName(ctx, vaccine.Name)
+		return len(data), data, err
+	}
+	total, err := v.repo.GetVaccineCount(ctx, "")
+	if err != nil {
+		v.logger.Error("error fetching vaccine count", logging.Err(err))
+		return total, nil, err
+	}
+	data, err := v.repo.GetVaccines(ctx, "", pagination)
+	return total, data, err
+}
+
+func (v *VaccineCatalogUsecase) UpdateVaccine(ctx context.Context, vaccine *requests.VaccineUpdateRequest) (models.Vaccine, error) {
+	existing, err := v.repo.GetVaccines(ctx, fmt.Sprintf("id = %d", vaccine.Id), requests.Pagination{})
+	if err != nil {
+		v.logger.Error("error fetching vaccine", logging.Err(err))
+		return models.Vaccine{}, err
+	}
+	if len(existing) == 0 {
+		return models.Vaccine{}, fmt.Errorf("no vaccine exists with id %d", vaccine.Id)
+	}
+	if vaccine.Name != nil {
+		sameName, err := v.repo.GetVaccineByName(ctx, *vaccine.Name)
+		if err != nil {
+			v.logger.Error("error fetching vaccine", logging.Err(err))
+			return existing[0], err
+		}
+		if len(sameName) > 0 && sameName[0].Id != vaccine.Id {
+			return existing[0], fmt.Errorf("vaccine %s already exists with id %d", sameName[0].Name, sameName[0].Id)
+		}
+	}
+	if err = v.repo.UpdateVaccine(ctx, vaccine); err != nil {
+		v.logger.Error("vaccine update failed", logging.Err(err))
+		return existing[0], err
+	}
+	updated, err := v.repo.GetVaccines(ctx, fmt.Sprintf("id = %d", vaccine.Id), requests.Pagination{})
+	if err != nil || len(updated) == 0 {
+		return existing[0], err
+	}
+	return updated[0], nil
+}
+
+func (v *VaccineCatalogUsecase) DeleteVaccine(ctx context.Context, id int) error {
+	existing, err := v.repo.GetVaccines(ctx, fmt.Sprintf("id = %d", id), requests.Pagination{})
+	if err != nil {
+		v.logger.Error("error fetching vaccine", logging.Err(err))
+		return err
+	}
+	if len(existing) == 0 {
+		return fmt.Errorf("no vaccine exists with id %d", id)
+	}
+	//vaccines referenced by a drive stay in the catalog
+	drives, err := v.inventoryRepo.GetVaccineInventory(ctx, fmt.Sprintf("vaccine_id = %d", id))
+	if err != nil {
+		v.logger.Error("error fetching drives for vaccine", logging.Err(err))
+		return err
+	}
+	if len(drives) > 0 {
+		return fmt.Errorf("vaccine with id %d is used by %d drive(s) and cannot be deleted", id, len(drives))
+	}
+	//so do vaccines guardians consented to or students are exempt from
+	consents, err := v.consentRepo.GetConsentCount(ctx, fmt.Sprintf("vaccine_id = %d", id))
+	if err != nil {
+		v.logger.Error("error fetching consents for vaccine", logging.Err(err))
+		return err
+	}
+	if consents > 0 {
+		return fmt.Errorf("vaccine with id %d is used by %d consent(s) and cannot be deleted", id, consents)
+	}
+	exemptions, err := v.exemptionRepo.GetExemptionCount(ctx, fmt.Sprintf("e.vaccine_id = %d", id))
+	if err != nil {
+		v.logger.Error("error fetching exemptions for vaccine", logging.Err(err))
+		return err
+	}
+	if exemptions > 0 {
+		return fmt.Errorf("vaccine with id %d is used by %d exemption(s) and cannot be deleted", id, exemptions)
+	}
+	return v.repo.DeleteVaccine(ctx, id)
+}
+
+func NewVaccineCatalogUsecaseHandler(repo repository.VaccineCatalogRepositoryHandler, inventoryRepo repository.VaccineInventoryHandler, consentRepo repository.ConsentRepositoryHandler, exemptionRepo repository.ExemptionRepositoryHandler, logger *slog.Logger) VaccineCatalogUsecaseHandler {
+	return &VaccineCatalogUsecase{
+		repo:          repo,
+		inventoryRepo: inventoryRepo,
+		consentRepo:   consentRepo,
+		exemptionRepo: exemptionRepo,
+		logger:        logger,
+	}
+}
@@ -1,91 +1,239 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"school_vaccination_portal/logging"
 	"school_vaccination_portal/models"
 	"school_vaccination_portal/repository"
 	"school_vaccination_portal/requests"
+	"strings"
 	"time"
 )
 
 type VaccineInventoryUsecaseHandler interface {
-	GetVaccineDriveDetails(inventory *models.VaccineInventory) ([]models.VaccineInventory, error)
-	CreatevaccineDrive(inventory *models.VaccineInventory) error
-	EditVaccineDrive(inventory *requests.VaccineInventoryUpdateRequest) error
+	GetVaccineDriveDetails(ctx context.Context, inventory *models.VaccineInventory) ([]models.VaccineInventory, error)
+	CreatevaccineDrive(ctx context.Context, inventory *models.VaccineInventory) error
+	EditVaccineDrive(ctx context.Context, inventory *requests.VaccineInventoryUpdateRequest) error
+	CancelVaccineDrive(ctx context.Context, inventory *models.VaccineInventory) error
 }
 type VaccineDriveUsecase struct {
-	repo repository.VaccineInventoryHandler
+	repo        repository.VaccineInventoryHandler
+	catalogRepo repository.VaccineCatalogRepositoryHandler
+	logger      *slog.Logger
 }
 
-func (v *VaccineDriveUsecase) GetVaccineDriveDetails(inventory *models.VaccineInventory) ([]models.VaccineInventory, error) {
-	return v.repo.GetVaccineInventory(createVaccineInventoryFilterString(inventory))
+func (v *VaccineDriveUsecase) GetVaccineDriveDetails(ctx context.Context, inventory *models.VaccineInventory) ([]models.VaccineInventory, error) {
+	filter, args := createVaccineInventoryFilterString(inventory)
+	return v.repo.GetVaccineInventory(ctx, filter, args...)
 }
-func (v *VaccineDriveUsecase) CreatevaccineDrive(drive *models.VaccineInventory) error {
+func (v *VaccineDriveUsecase) CreatevaccineDrive(ctx context.Context, drive *models.VaccineInventory) error {
 	//check if any vaccine drive is scheduled in that day
-	log.Println("drive being created", drive)
-	data, err := v.repo.GetVaccineInventory(fmt.Sprintf(`drive_date = "%s"`, drive.DriveDate.Format("2006-01-02")))
+	v.logger.Info("scheduling vaccination drive", "vaccine_id", drive.VaccineId, "drive_date", drive.DriveDate)
+	vaccine, err := v.getCatalogVaccine(ctx, drive.VaccineId)
 	if err != nil {
-		log.Println("Unable to schedule vaccination drive ", err.Error())
+		return err
+	}
+	drive.VaccineName = vaccine.Name
+	if err = checkClassesEligible(vaccine, drive.Classes); err != nil {
+		return err
+	}
+	data, err := v.repo.GetVaccineInventory(ctx, fmt.Sprintf(`drive_date = "%s"`, drive.DriveDate.Format("2006-01-02")))
+	if err != nil {
+		v.logger.Error("unable to schedule vaccination drive", logging.Err(err))
 		return errors.New("unable to schedule drive please try again later")
 	}
-	log.Println("data being fetched", data)
 	if len(data) > 0 {
 		return fmt.Errorf("vaccination drive exists on %s, drive id: %d", data[0].DriveDate.Format("2006-01-02"), data[0].Id)
 	}
 	//schedule drive
-	return v.repo.CreateInventory(drive)
+	return v.repo.CreateInventory(ctx, drive)
 
 }
 
-func (v *VaccineDriveUsecase) EditVaccineDrive(drive *requests.VaccineInventoryUpdateRequest) error {
+func (v *VaccineDriveUsecase) EditVaccineDrive(ctx context.Context, drive *requests.VaccineInventoryUpdateRequest) error {
 	var err error
 	//check if a drive with same id already exists or not
-	driveDetails, err := v.repo.GetVaccineInventory(fmt.Sprintf("id = %d", drive.Id))
+	driveDetails, err := v.repo.GetVaccineInventory(ctx, fmt.Sprintf("id = %d", drive.Id))
 	if err != nil {
-		log.Println("error fetching drive details", err.Error())
+		v.logger.Error("error fetching drive details", logging.Err(err))
 		return err
 	}
 	if len(driveDetails) == 0 {
 		return fmt.Errorf("no drive exists with id %d ", drive.Id)
 	}
+	current := driveDetails[0]
+	//check if they are editing a completed or cancelled drive, wastage can still be reported once a drive completes
+	if current.Status == models.DRIVE_CANCELLED || (current.Status == models.DRIVE_COMPLETED && !isWastageOnlyUpdate(drive)) {
+		return fmt.Errorf("drive with id %d is %s and can no longer be edited", current.Id, current.Status)
+	}
+	//drives are not completed on their own once their date passes, so a drive that took place or has doses recorded
+	//against it keeps the vaccine, classes and date its records were given under
+	if drive.VaccineId != nil || drive.Classes != nil || drive.DriveDate != nil {
+		if isPastDrive(current) {
+			return fmt.Errorf("drive with id %d took place on %s, its vaccine, classes and date can no longer be edited", current.Id, current.DriveDate.Format("2006-01-02"))
+		}
+		if current.DosesUsed > 0 {
+			return fmt.Errorf("drive with id %d has %d doses recorded, its vaccine, classes and date can no longer be edited", current.Id, current.DosesUsed)
+		}
+	}
+	if drive.Status != nil && *drive.Status != current.Status {
+		if err = checkDriveTransition(current.Status, *drive.Status); err != nil {
+			return err
+		}
+		if *drive.Status == models.DRIVE_CANCELLED {
+			if err = checkDriveCancellable(current); err != nil {
+				return err
+			}
+		}
+		if *drive.Status == models.DRIVE_POSTPONED && drive.DriveDate == nil {
+			return fmt.Errorf("postponing drive with id %d requires a new drive_date", current.Id)
+		}
+		if *drive.Status == models.DRIVE_IN_PROGRESS && isFutureDrive(current) {
+			return fmt.Errorf("drive with id %d cannot start before %s", current.Id, current.DriveDate.Format("2006-01-02"))
+		}
+	}
+	if drive.VaccineId != nil || drive.Classes != nil {
+		vaccineId, classes := current.VaccineId, current.Classes
+		if drive.VaccineId != nil {
+			vaccineId = *drive.VaccineId
+		}
+		if drive.Classes != nil {
+			classes = drive.Classes
+		}
+		vaccine, err := v.getCatalogVaccine(ctx, vaccineId)
+		if err != nil {
+			return err
+		}
+		if err = checkClassesEligible(vaccine, classes); err != nil {
+			return err
+		}
+	}
+	//stock can't drop below what has already been administered or wasted
+	if drive.Doses != nil || drive.DosesWasted != nil {
+		allocated, wasted := current.Doses, current.DosesWasted
+		if drive.Doses != nil {
+			allocated = *drive.Doses
+		}
+		if drive.DosesWasted != nil {
+			wasted = *drive.DosesWasted
+		}
+		if allocated < current.DosesUsed+wasted {
+			return fmt.Errorf("drive with id %d has %d doses used and %d wasted, allocation of %d doses is not enough", current.Id, current.DosesUsed, wasted, allocated)
+		}
+	}
 	//check if already any drive is scheduled on the new date
 	if drive.DriveDate != nil {
-		data, err := v.repo.GetVaccineInventory(fmt.Sprintf(`drive_date = "%s"`, drive.DriveDate.Format("2006-01-02")))
+		if current.Status == models.DRIVE_IN_PROGRESS {
+			return fmt.Errorf("drive with id %d is in progress and cannot be rescheduled", current.Id)
+		}
+		data, err := v.repo.GetVaccineInventory(ctx, fmt.Sprintf(`drive_date = "%s"`, drive.DriveDate.Format("2006-01-02")))
 		if err != nil {
-			log.Println("Unable to schedule vaccination drive ", err.Error())
+			v.logger.Error("unable to schedule vaccination drive", logging.Err(err))
 			return errors.New("unable to schedule drive please try again later")
 		}
-		log.Println("data being fetched", data)
-		if len(data) > 0 && data[0].Id != driveDetails[0].Id {
+		if len(data) > 0 && data[0].Id != current.Id {
 			return fmt.Errorf("vaccination drive exists on %s, drive id: %d", data[0].DriveDate.Format("2006-01-02"), data[0].Id)
 		}
-		//check if they are editing a completed drive
-		if time.Now().After(driveDetails[0].DriveDate) {
-			return fmt.Errorf("drive with id %d already completed on %s", driveDetails[0].Id, driveDetails[0].DriveDate)
-		}
 		//check if they are prescheduling the drive
-		if !drive.DriveDate.After(driveDetails[0].DriveDate) {
-			return fmt.Errorf("drive prescheduling is not possible, please schedule after %s", driveDetails[0].DriveDate)
+		if !drive.DriveDate.After(current.DriveDate) {
+			return fmt.Errorf("drive prescheduling is not possible, please schedule after %s", current.DriveDate)
 		}
 	}
-	return v.repo.UpdateVaccineInventory(drive)
+	return v.repo.UpdateVaccineInventory(ctx, drive)
 }
-func createVaccineInventoryFilterString(drive *models.VaccineInventory) string {
-	filterString := ""
+
+func (v *VaccineDriveUsecase) CancelVaccineDrive(ctx context.Context, drive *models.VaccineInventory) error {
+	driveDetails, err := v.repo.GetVaccineInventory(ctx, fmt.Sprintf("id = %d", drive.Id))
+	if err != nil {
+		v.logger.Error("error fetching drive details", logging.Err(err))
+		return err
+	}
+	if len(driveDetails) == 0 {
+		return fmt.Errorf("no drive exists with id %d ", drive.Id)
+	}
+	if err = checkDriveTransition(driveDetails[0].Status, models.DRIVE_CANCELLED); err != nil {
+		return err
+	}
+	if err = checkDriveCancellable(driveDetails[0]); err != nil {
+		return err
+	}
+	return v.repo.UpdateDriveStatus(ctx, drive.Id, models.DRIVE_CANCELLED, drive.CancellationReason)
+}
+
+func (v *VaccineDriveUsecase) getCatalogVaccine(ctx context.Context, id int) (models.Vaccine, error) {
+	vaccines, err := v.catalogRepo.GetVaccines(ctx, fmt.Sprintf("id = %d", id), requests.Pagination{})
+	if err != nil {
+		v.logger.Error("error fetching vaccine", logging.Err(err))
+		return models.Vaccine{}, errors.New("unable to schedule drive please try again later")
+	}
+	if len(vaccines) == 0 {
+		return models.Vaccine{}, fmt.Errorf("no vaccine exists in catalog with id %d", id)
+	}
+	return vaccines[0], nil
+}
+
+// a drive may only target grades the vaccine is approved for, an empty eligibility list allows every grade
+func checkClassesEligible(vaccine models.Vaccine, classes []string) error {
+	if len(vaccine.EligibleGrades) == 0 {
+		return nil
+	}
+	for _, class := range classes {
+		if !vaccine.EligibleGrades.Contains(class) {
+			return fmt.Errorf("%s is not eligible for vaccine %s, eligible grades: %s", class, vaccine.Name, strings.Join(vaccine.EligibleGrades, ", "))
+		}
+	}
+	return nil
+}
+
+func isWastageOnlyUpdate(drive *requests.VaccineInventoryUpdateRequest) bool {
+	return drive.DosesWasted != nil && drive.DriveDate == nil && drive.VaccineId == nil && drive.Doses == nil && drive.Classes == nil && drive.Status == nil
+}
+
+func checkDriveTransition(from, to string) error {
+	for _, allowed := range models.DriveStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("drive status cannot change from %s to %s", from, to)
+}
+
+// a drive that has doses recorded took place, cancelling it would leave those records active under a cancelled drive
+func checkDriveCancellable(drive models.VaccineInventory) error {
+	if drive.DosesUsed > 0 {
+		return fmt.Errorf("drive with id %d has %d doses recorded and cannot be cancelled, void the records first or complete the drive", drive.Id, drive.DosesUsed)
+	}
+	return nil
+}
+
+// a drive is in the future until its drive date has started
+func isFutureDrive(drive models.VaccineInventory) bool {
+	return drive.DriveDate.UTC().Truncate(24 * time.Hour).After(time.Now().UTC())
+}
+
+// a drive is past once the day after its drive date has started
+func isPastDrive(drive models.VaccineInventory) bool {
+	return drive.DriveDate.UTC().Truncate(24 * time.Hour).Before(time.Now().UTC().Truncate(24 * time.Hour))
+}
+
+// the vaccine name comes from the request, it is bound as an argument
+func createVaccineInventoryFilterString(drive *models.VaccineInventory) (string, []interface{}) {
 	if drive.Id != 0 {
-		filterString += fmt.Sprintf("id = %d", drive.Id)
-	} else if drive.VaccineName != "" {
-		filterString += fmt.Sprintf("vaccine_name LIKE '%s'", drive.VaccineName)
-	} else {
-		filterString += "drive_date <= DATE_ADD(NOW(), INTERVAL 30 DAY)"
+		return fmt.Sprintf("id = %d", drive.Id), nil
+	}
+	if drive.VaccineName != "" {
+		return "vaccine_id IN (SELECT id FROM vaccines WHERE name = ?)", []interface{}{strings.TrimSpace(drive.VaccineName)}
 	}
-	return filterString
+	return "drive_date <= DATE_ADD(NOW(), INTERVAL 30 DAY)", nil
 }
 
-func NewVaccineInventoryUsecaseHandler(repo repository.VaccineInventoryHandler) VaccineInventoryUsecaseHandler {
+func NewVaccineInventoryUsecaseHandler(repo repository.VaccineInventoryHandler, catalogRepo repository.VaccineCatalogRepositoryHandler, logger *slog.Logger) VaccineInventoryUsecaseHandler {
 	return &VaccineDriveUsecase{
-		repo: repo,
+		repo:        repo,
+		catalogRepo: catalogRepo,
+		logger:      logger,
 	}
 }
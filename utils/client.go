@@ -4,20 +4,20 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
 func MakeAPICall(method, url string, headers map[string]string, body interface{}) (int, []byte, error) {
 	req, err := prepareRequest(method, url, headers, body)
 	if err != nil {
-		log.Println("Error in Preparaing Request")
+		slog.Error("unable to prepare request", "url", url, "error", err.Error())
 		return http.StatusInternalServerError, nil, err
 	}
 	resp, err := http.DefaultClient.Do(req)
 
 	if err != nil {
-		log.Println("Error in Making API Call ", err)
+		slog.Error("api call failed", "url", url, "error", err.Error())
 		return http.StatusInternalServerError, nil, err
 	}
 	defer resp.Body.Close()
@@ -33,7 +33,7 @@ func prepareRequest(method, url string, headers map[string]string, body interfac
 	request, err := http.NewRequest(method, url, bytes.NewBuffer(marshallBody))
 
 	if err != nil {
-		log.Println("Error in Preparaing Request")
+		slog.Error("unable to prepare request", "url", url, "error", err.Error())
 		return nil, err
 	}
 	for k, v := range headers {